@@ -1,8 +1,6 @@
 package ldap
 
 import (
-	"log"
-
 	ber "github.com/go-asn1-ber/asn1-ber"
 )
 
@@ -57,6 +55,11 @@ func (req *AddRequest) Attribute(attrType string, attrVals []string) {
 	req.Attributes = append(req.Attributes, Attribute{Type: attrType, Vals: attrVals})
 }
 
+// AttributeBytes adds an attribute with the given type and binary values, e.g. jpegPhoto or userCertificate;binary
+func (req *AddRequest) AttributeBytes(attrType string, attrVals [][]byte) {
+	req.Attribute(attrType, bytesToStrings(attrVals))
+}
+
 // NewAddRequest returns an AddRequest for the given DN, with no attributes
 func NewAddRequest(dn string, controls []Control) *AddRequest {
 	return &AddRequest{
@@ -66,26 +69,59 @@ func NewAddRequest(dn string, controls []Control) *AddRequest {
 
 }
 
+// AddResult holds the server's response to an add request
+type AddResult struct {
+	// MatchedDN is the matchedDN returned by the server, if any
+	MatchedDN string
+	// DiagnosticMessage is the diagnostic message returned by the server, if any
+	DiagnosticMessage string
+	// Referrals are the referral URIs returned with LDAPResultReferral, if any
+	Referrals []string
+	// Controls are the returned controls
+	Controls []Control
+}
+
 // Add performs the given AddRequest
 func (l *Conn) Add(addRequest *AddRequest) error {
+	_, err := l.AddWithResult(addRequest)
+	return err
+}
+
+// AddWithResult performs the given AddRequest and returns the result
+func (l *Conn) AddWithResult(addRequest *AddRequest) (*AddResult, error) {
+	result, err := l.add(addRequest)
+	if l.chasesReferral(err) {
+		err = l.chaseReferrals(err, 0, map[string]bool{}, func(conn *Conn, u *LDAPURL) error {
+			referred := *addRequest
+			referred.DN = referredDN(addRequest.DN, u)
+			result, err = conn.AddWithResult(&referred)
+			return err
+		})
+	}
+	return result, err
+}
+
+func (l *Conn) add(addRequest *AddRequest) (*AddResult, error) {
 	msgCtx, err := l.doRequest(addRequest)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer l.finishMessage(msgCtx)
 
 	packet, err := l.readPacket(msgCtx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	result := &AddResult{}
 	if packet.Children[1].Tag == ApplicationAddResponse {
-		err := GetLDAPError(packet)
+		result.MatchedDN, result.DiagnosticMessage, result.Referrals = getLDAPResult(packet)
+		result.Controls, err = decodeResultControls(packet)
 		if err != nil {
-			return err
+			return result, err
 		}
 	} else {
-		log.Printf("Unexpected Response: %d", packet.Children[1].Tag)
+		l.log(LevelWarn, "unexpected response", LogKeyMessageID, msgCtx.id, LogKeyOperation, operationName(packet))
 	}
-	return nil
+	return result, nil
 }
@@ -0,0 +1,29 @@
+package ldap
+
+// ChangeADPassword changes the password of the Active Directory account with the given DN, as the account
+// itself, by deleting the old password and adding the new one in a single modify request. Active
+// Directory only accepts it over an encrypted connection, e.g. LDAPS.
+//
+// If Active Directory reports why the change failed, the returned *Error is one of the ErrAD* errors
+// for errors.Is, e.g. ErrADInvalidCredentials for a wrong old password.
+func (l *Conn) ChangeADPassword(dn, oldPassword, newPassword string) error {
+	req := NewModifyRequest(dn, nil)
+	req.DeleteBytes("unicodePwd", [][]byte{EncodeUnicodePwd(oldPassword)})
+	req.AddBytes("unicodePwd", [][]byte{EncodeUnicodePwd(newPassword)})
+	return l.Modify(req)
+}
+
+// ResetADPassword sets the password of the Active Directory account with the given DN, which requires
+// the Reset Password right on it, and optionally forces the user to change it at the next logon. Active
+// Directory only accepts it over an encrypted connection, e.g. LDAPS.
+//
+// If Active Directory reports why the reset failed, the returned *Error is one of the ErrAD* errors
+// for errors.Is, e.g. ErrADPasswordRestriction for a password not satisfying the password policy.
+func (l *Conn) ResetADPassword(dn, newPassword string, mustChange bool) error {
+	req := NewModifyRequest(dn, nil)
+	req.ReplaceUnicodePwd(newPassword)
+	if mustChange {
+		req.Replace("pwdLastSet", []string{"0"})
+	}
+	return l.Modify(req)
+}
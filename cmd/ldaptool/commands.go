@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-ldap/ldap"
+)
+
+func runCompare(args []string) error {
+	var opts connOptions
+	fs := newFlagSet("compare", "[flags] dn attribute:value|attribute::base64value", &opts)
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	dn, assertion := fs.Arg(0), fs.Arg(1)
+	i := strings.Index(assertion, ":")
+	if i <= 0 {
+		return fmt.Errorf("invalid assertion %q", assertion)
+	}
+	attribute, value := assertion[:i], assertion[i+1:]
+	if strings.HasPrefix(value, ":") {
+		b, err := base64.StdEncoding.DecodeString(value[1:])
+		if err != nil {
+			return fmt.Errorf("invalid base64 value: %s", err)
+		}
+		value = string(b)
+	}
+
+	conn, err := opts.connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	matched, err := conn.Compare(dn, attribute, value)
+	if err != nil {
+		return err
+	}
+	// exit with the compareTrue or compareFalse result code, as ldapcompare
+	if matched {
+		fmt.Println("TRUE")
+		return exitStatus(6)
+	}
+	fmt.Println("FALSE")
+	return exitStatus(5)
+}
+
+func runPasswd(args []string) error {
+	var opts connOptions
+	fs := newFlagSet("passwd", "[flags] [user]", &opts)
+	oldPassword := fs.String("a", "", "old password")
+	newPassword := fs.String("s", "", "new password, generated by the server if empty")
+	fs.Parse(args)
+	if fs.NArg() > 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	conn, err := opts.connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	result, err := conn.PasswordModify(ldap.NewPasswordModifyRequest(fs.Arg(0), *oldPassword, *newPassword))
+	if err != nil {
+		return err
+	}
+	if result.GeneratedPassword != "" {
+		fmt.Printf("New password: %s\n", result.GeneratedPassword)
+	}
+	return nil
+}
+
+func runWhoAmI(args []string) error {
+	var opts connOptions
+	fs := newFlagSet("whoami", "[flags]", &opts)
+	fs.Parse(args)
+
+	conn, err := opts.connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	result, err := conn.WhoAmI(nil)
+	if err != nil {
+		return err
+	}
+	if result.AuthzID == "" {
+		fmt.Println("anonymous")
+		return nil
+	}
+	fmt.Println(result.AuthzID)
+	return nil
+}
+
+func runRootDSE(args []string) error {
+	var opts connOptions
+	fs := newFlagSet("rootdse", "[flags]", &opts)
+	format := fs.String("o", "ldif", "output format: ldif or json")
+	fs.Parse(args)
+	w, err := newEntryWriter(os.Stdout, *format)
+	if err != nil {
+		return err
+	}
+
+	conn, err := opts.connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	rootDSE, err := conn.RootDSE()
+	if err != nil {
+		return err
+	}
+	if err := w.WriteEntry(rootDSE.Entry); err != nil {
+		return err
+	}
+	return w.Close()
+}
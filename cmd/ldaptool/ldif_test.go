@@ -0,0 +1,116 @@
+package main
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-ldap/ldap"
+)
+
+func readLDIF(t *testing.T, content, defaultChangeType string) []interface{} {
+	reader := newLDIFReader(strings.NewReader(content), defaultChangeType)
+	var requests []interface{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return requests
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		requests = append(requests, record.Request)
+	}
+}
+
+func TestLDIFReader(t *testing.T) {
+	content := `version: 1
+# a comment
+#  folded
+dn: cn=John Smith,dc=exa
+ mple,dc=org
+objectClass: person
+cn: John Smith
+sn:: U23DrXRo
+CN: Johnny
+
+dn: cn=a,dc=example,dc=org
+control: 1.2.840.113556.1.4.805 true
+changetype: delete
+
+dn: cn=b,dc=example,dc=org
+changetype: modify
+add: mail
+mail: b@example.org
+mail: b2@example.org
+-
+delete: description
+-
+replace: sn
+sn: Jones
+-
+increment: uidNumber
+uidNumber: 1
+-
+
+dn: cn=c,dc=example,dc=org
+changetype: modrdn
+newrdn: cn=d
+deleteoldrdn: 1
+newsuperior: ou=people,dc=example,dc=org
+`
+	add := ldap.NewAddRequest("cn=John Smith,dc=example,dc=org", nil)
+	add.Attribute("objectClass", []string{"person"})
+	add.Attribute("cn", []string{"John Smith", "Johnny"})
+	add.Attribute("sn", []string{"Smíth"})
+	modify := ldap.NewModifyRequest("cn=b,dc=example,dc=org", nil)
+	modify.Add("mail", []string{"b@example.org", "b2@example.org"})
+	modify.Delete("description", nil)
+	modify.Replace("sn", []string{"Jones"})
+	modify.Increment("uidNumber", "1")
+	want := []interface{}{
+		add,
+		ldap.NewDelRequest("cn=a,dc=example,dc=org", []ldap.Control{ldap.NewControlString("1.2.840.113556.1.4.805", true, "")}),
+		modify,
+		ldap.NewModifyDNRequest("cn=c,dc=example,dc=org", "cn=d", true, "ou=people,dc=example,dc=org"),
+	}
+
+	requests := readLDIF(t, content, changeTypeAdd)
+	if len(requests) != len(want) {
+		t.Fatalf("got %d records, want %d", len(requests), len(want))
+	}
+	for i := range want {
+		if !reflect.DeepEqual(requests[i], want[i]) {
+			t.Errorf("record %d: got %#v, want %#v", i, requests[i], want[i])
+		}
+	}
+}
+
+func TestLDIFReaderDefaultChangeType(t *testing.T) {
+	requests := readLDIF(t, "dn: cn=a,dc=example,dc=org\n\n\ndn: cn=b,dc=example,dc=org\n", changeTypeDelete)
+	if len(requests) != 2 || requests[1].(*ldap.DelRequest).DN != "cn=b,dc=example,dc=org" {
+		t.Errorf("unexpected requests %#v", requests)
+	}
+}
+
+func TestLDIFReaderErrors(t *testing.T) {
+	testcases := []struct {
+		content string
+		err     string
+	}{
+		{"cn: a\n", "line 1: record does not start with a dn"},
+		{"dn: cn=a\ncn:: !!!\n", "line 2: invalid base64 value"},
+		{"dn: cn=a\nchangetype: rename\n", "line 1: unknown changetype \"rename\""},
+		{"dn: cn=a\nchangetype: modify\nadd: mail\ncn: a\n", "line 4: got attribute \"cn\", want \"mail\""},
+		{"dn: cn=a\nchangetype: modrdn\nnewrdn: cn=b\ndeleteoldrdn: yes\n", "line 4: deleteoldrdn must be 0 or 1"},
+		{"dn: cn=a\n\ndn: cn=b\ncn: b\n", "entry \"cn=a\" has no attributes"},
+		{"version: 2\n", "line 1: unsupported LDIF version"},
+	}
+	for _, tc := range testcases {
+		_, err := newLDIFReader(strings.NewReader(tc.content), changeTypeAdd).Read()
+		if err == nil || !strings.HasPrefix(err.Error(), tc.err) {
+			t.Errorf("%q: got error %v, want %q", tc.content, err, tc.err)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+// Command ldaptool is a command-line LDAP client built on the ldap package, for environments where
+// the OpenLDAP tools are not available. Its commands and flags follow ldapsearch, ldapmodify,
+// ldapcompare, ldappasswd and ldapwhoami:
+//
+//	ldaptool search -H ldaps://ldap.example.org -D cn=admin,dc=example,dc=org -w secret \
+//		-b dc=example,dc=org -pagesize 500 -sort sn -o json '(objectClass=person)' cn mail
+//	ldaptool modify -H ldap://ldap.example.org -Z -Y EXTERNAL -cert client.pem -key client.key -f changes.ldif
+//	ldaptool whoami -H ldapi:// -Y EXTERNAL
+//
+// Run "ldaptool <command> -h" for the flags of a command. Failed commands exit with the LDAP result
+// code of the error when there is one, and 1 otherwise.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/go-ldap/ldap"
+)
+
+// command is a command of the tool
+type command struct {
+	name        string
+	description string
+	run         func(args []string) error
+}
+
+var commands = []command{
+	{"search", "search entries, written as LDIF or JSON", runSearch},
+	{"add", "add the entries of an LDIF file", runAdd},
+	{"modify", "apply the change records of an LDIF file", runModify},
+	{"delete", "delete entries given by DN or in an LDIF file", runDelete},
+	{"compare", "compare an attribute value of an entry", runCompare},
+	{"passwd", "change a password with the password modify extended operation", runPasswd},
+	{"whoami", "print the authorization identity of the bound user", runWhoAmI},
+	{"rootdse", "print the root DSE of the server", runRootDSE},
+}
+
+// exitStatus is returned by commands exiting with a status without reporting an error
+type exitStatus int
+
+func (s exitStatus) Error() string {
+	return fmt.Sprintf("exit status %d", int(s))
+}
+
+// newFlagSet returns the flag set of a command, with the connection flags registered in opts
+func newFlagSet(name, usage string, opts *connOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: ldaptool %s %s\n", name, usage)
+		fs.PrintDefaults()
+	}
+	opts.register(fs)
+	return fs
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: ldaptool <command> [flags] [arguments]\n\ncommands:")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", c.name, c.description)
+	}
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	for _, c := range commands {
+		if c.name != os.Args[1] {
+			continue
+		}
+		err := c.run(os.Args[2:])
+		if err == nil {
+			return
+		}
+		if status, ok := err.(exitStatus); ok {
+			os.Exit(int(status))
+		}
+		fmt.Fprintf(os.Stderr, "ldaptool: %s\n", err)
+		if e, ok := err.(*ldap.Error); ok && e.ResultCode > 0 && e.ResultCode < 256 {
+			os.Exit(int(e.ResultCode))
+		}
+		os.Exit(1)
+	}
+	usage()
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"unicode/utf8"
+
+	"github.com/go-ldap/ldap"
+)
+
+// ldifLineWidth is the width LDIF lines are folded at
+const ldifLineWidth = 76
+
+// entryWriter writes search results in an output format
+type entryWriter interface {
+	// WriteEntry writes an entry of the results
+	WriteEntry(entry *ldap.Entry) error
+	// WriteReferral writes a search result reference
+	WriteReferral(uri string) error
+	// Close writes the end of the results
+	Close() error
+}
+
+// newEntryWriter returns the writer of the given format: ldif or json
+func newEntryWriter(w io.Writer, format string) (entryWriter, error) {
+	switch format {
+	case "ldif":
+		return &ldifWriter{w: bufio.NewWriter(w)}, nil
+	case "json":
+		return &jsonWriter{w: w}, nil
+	}
+	return nil, fmt.Errorf("unknown output format %q", format)
+}
+
+// ldifWriter writes entries as LDIF content records, see https://tools.ietf.org/html/rfc2849
+type ldifWriter struct {
+	w *bufio.Writer
+}
+
+func (l *ldifWriter) WriteEntry(entry *ldap.Entry) error {
+	l.writeLine("dn", []byte(entry.DN))
+	for _, attribute := range entry.Attributes {
+		for _, value := range attribute.ByteValues {
+			l.writeLine(attribute.Name, value)
+		}
+	}
+	l.w.WriteString("\n")
+	return l.w.Flush()
+}
+
+func (l *ldifWriter) WriteReferral(uri string) error {
+	fmt.Fprintf(l.w, "# search reference\n# ref: %s\n\n", uri)
+	return l.w.Flush()
+}
+
+func (l *ldifWriter) Close() error {
+	return l.w.Flush()
+}
+
+// writeLine writes an attribute value, base64 encoded if it is not a safe string, folded at ldifLineWidth
+func (l *ldifWriter) writeLine(name string, value []byte) {
+	line := name + ": " + string(value)
+	if !isSafeLDIFString(value) {
+		line = name + ":: " + base64.StdEncoding.EncodeToString(value)
+	}
+	for len(line) > ldifLineWidth {
+		l.w.WriteString(line[:ldifLineWidth] + "\n")
+		line = " " + line[ldifLineWidth:]
+	}
+	l.w.WriteString(line + "\n")
+}
+
+// isSafeLDIFString returns whether the value can be written as is in LDIF, that is it has no NUL, CR,
+// LF or non-ASCII character, does not start with a space, colon or '<', and does not end with a space
+func isSafeLDIFString(value []byte) bool {
+	if len(value) == 0 {
+		return true
+	}
+	if value[0] == ' ' || value[0] == ':' || value[0] == '<' || value[len(value)-1] == ' ' {
+		return false
+	}
+	for _, c := range value {
+		if c == 0 || c == '\r' || c == '\n' || c >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+// jsonEntry is the JSON representation of an entry. Values which are not valid UTF-8 are put in
+// BinaryAttributes, encoded in base64.
+type jsonEntry struct {
+	DN               string              `json:"dn"`
+	Attributes       map[string][]string `json:"attributes"`
+	BinaryAttributes map[string][][]byte `json:"binaryAttributes,omitempty"`
+}
+
+// jsonWriter writes entries as a JSON array, and the referrals as objects with a "ref" member
+type jsonWriter struct {
+	w       io.Writer
+	started bool
+}
+
+func (j *jsonWriter) WriteEntry(entry *ldap.Entry) error {
+	e := jsonEntry{DN: entry.DN, Attributes: make(map[string][]string)}
+	for _, attribute := range entry.Attributes {
+		for _, value := range attribute.ByteValues {
+			if utf8.Valid(value) {
+				e.Attributes[attribute.Name] = append(e.Attributes[attribute.Name], string(value))
+				continue
+			}
+			if e.BinaryAttributes == nil {
+				e.BinaryAttributes = make(map[string][][]byte)
+			}
+			e.BinaryAttributes[attribute.Name] = append(e.BinaryAttributes[attribute.Name], value)
+		}
+	}
+	return j.write(e)
+}
+
+func (j *jsonWriter) WriteReferral(uri string) error {
+	return j.write(struct {
+		Ref string `json:"ref"`
+	}{uri})
+}
+
+func (j *jsonWriter) write(v interface{}) error {
+	b, err := json.MarshalIndent(v, "  ", "  ")
+	if err != nil {
+		return err
+	}
+	separator := ",\n  "
+	if !j.started {
+		separator = "[\n  "
+		j.started = true
+	}
+	_, err = io.WriteString(j.w, separator+string(b))
+	return err
+}
+
+func (j *jsonWriter) Close() error {
+	if !j.started {
+		_, err := io.WriteString(j.w, "[]\n")
+		return err
+	}
+	_, err := io.WriteString(j.w, "\n]\n")
+	return err
+}
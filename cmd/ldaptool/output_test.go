@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-ldap/ldap"
+)
+
+func newTestEntry() *ldap.Entry {
+	entry := ldap.NewEntry("cn=John Smith,dc=example,dc=org", map[string][]string{
+		"cn":          {"John Smith"},
+		"description": {strings.Repeat("x", 80)},
+		"sn":          {"Smíth", " padded"},
+	})
+	entry.Attributes = append(entry.Attributes, &ldap.EntryAttribute{
+		Name:       "jpegPhoto",
+		Values:     []string{"\xff\xd8"},
+		ByteValues: [][]byte{{0xff, 0xd8}},
+	})
+	return entry
+}
+
+func TestLDIFWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, _ := newEntryWriter(&buf, "ldif")
+	if err := writeSearchResult(w, &ldap.SearchResult{
+		Entries:   []*ldap.Entry{newTestEntry()},
+		Referrals: []string{"ldap://other.example.org/dc=example,dc=org"},
+	}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := `dn: cn=John Smith,dc=example,dc=org
+cn: John Smith
+description: xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx
+ xxxxxxxxxxxxxxxxx
+sn:: U23DrXRo
+sn:: IHBhZGRlZA==
+jpegPhoto:: /9g=
+
+# search reference
+# ref: ldap://other.example.org/dc=example,dc=org
+
+`
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+
+	// the output can be read back
+	requests := readLDIF(t, buf.String(), changeTypeAdd)
+	if len(requests) != 1 || len(requests[0].(*ldap.AddRequest).Attributes) != 4 {
+		t.Errorf("unexpected requests %#v", requests)
+	}
+}
+
+func TestJSONWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, _ := newEntryWriter(&buf, "json")
+	if err := w.Close(); err != nil || buf.String() != "[]\n" {
+		t.Errorf("got %q, %v for no entries", buf.String(), err)
+	}
+
+	buf.Reset()
+	w, _ = newEntryWriter(&buf, "json")
+	if err := writeSearchResult(w, &ldap.SearchResult{Entries: []*ldap.Entry{newTestEntry()}}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for _, s := range []string{`"dn": "cn=John Smith,dc=example,dc=org"`, `"Smíth"`, `"jpegPhoto": [`, `"/9g="`} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("expected %s in:\n%s", s, buf.String())
+		}
+	}
+}
+
+func TestParseSortKeys(t *testing.T) {
+	control, err := parseSortKeys("sn,-cn:caseExactOrderingMatch")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	want := []*ldap.SortKey{{AttributeType: "sn"}, {AttributeType: "cn", MatchingRule: "caseExactOrderingMatch", Reverse: true}}
+	if !reflect.DeepEqual(control.SortKeys, want) {
+		t.Errorf("got %v, want %v", control, want)
+	}
+	if _, err := parseSortKeys("sn,,cn"); err == nil {
+		t.Error("expected an error for an empty sort key")
+	}
+}
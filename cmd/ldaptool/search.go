@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-ldap/ldap"
+)
+
+var scopes = map[string]int{
+	"base": ldap.ScopeBaseObject,
+	"one":  ldap.ScopeSingleLevel,
+	"sub":  ldap.ScopeWholeSubtree,
+}
+
+var derefAliases = map[string]int{
+	"never":  ldap.NeverDerefAliases,
+	"search": ldap.DerefInSearching,
+	"find":   ldap.DerefFindingBaseObj,
+	"always": ldap.DerefAlways,
+}
+
+func runSearch(args []string) error {
+	var opts connOptions
+	fs := newFlagSet("search", "[flags] [filter [attribute...]]", &opts)
+	baseDN := fs.String("b", "", "base DN of the search")
+	scope := fs.String("s", "sub", "scope of the search: base, one or sub")
+	deref := fs.String("a", "never", "alias dereferencing: never, search, find or always")
+	sizeLimit := fs.Int("z", 0, "maximum number of entries returned, 0 for no limit")
+	timeLimit := fs.Int("l", 0, "time limit of the search in seconds, 0 for no limit")
+	typesOnly := fs.Bool("A", false, "return the attribute names only")
+	pageSize := fs.Uint("pagesize", 0, "retrieve the entries in pages of the given size with the paging control")
+	sortKeys := fs.String("sort", "", "sort the entries on the server by comma-separated keys [-]attribute[:orderingRule], - sorting in descending order")
+	format := fs.String("o", "ldif", "output format: ldif or json")
+	fs.Parse(args)
+
+	filter := "(objectClass=*)"
+	var attributes []string
+	if fs.NArg() > 0 {
+		filter, attributes = fs.Arg(0), fs.Args()[1:]
+	}
+	if _, err := ldap.CompileFilter(filter); err != nil {
+		return err
+	}
+	scopeValue, ok := scopes[*scope]
+	if !ok {
+		return fmt.Errorf("invalid scope %q", *scope)
+	}
+	derefValue, ok := derefAliases[*deref]
+	if !ok {
+		return fmt.Errorf("invalid alias dereferencing %q", *deref)
+	}
+	var controls []ldap.Control
+	if *sortKeys != "" {
+		control, err := parseSortKeys(*sortKeys)
+		if err != nil {
+			return err
+		}
+		controls = append(controls, control)
+	}
+	w, err := newEntryWriter(os.Stdout, *format)
+	if err != nil {
+		return err
+	}
+
+	conn, err := opts.connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	req := ldap.NewSearchRequest(*baseDN, scopeValue, derefValue, *sizeLimit, *timeLimit, *typesOnly, filter, attributes, controls)
+	var result *ldap.SearchResult
+	if *pageSize > 0 {
+		result, err = conn.SearchWithPaging(req, uint32(*pageSize))
+	} else {
+		result, err = conn.Search(req)
+	}
+	// entries are written even if the search failed, e.g. when the size limit was exceeded
+	if result != nil {
+		if err := writeSearchResult(w, result); err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+func writeSearchResult(w entryWriter, result *ldap.SearchResult) error {
+	for _, entry := range result.Entries {
+		if err := w.WriteEntry(entry); err != nil {
+			return err
+		}
+	}
+	for _, uri := range result.Referrals {
+		if err := w.WriteReferral(uri); err != nil {
+			return err
+		}
+	}
+	return w.Close()
+}
+
+// parseSortKeys returns the sort control of comma-separated keys [-]attribute[:orderingRule]
+func parseSortKeys(s string) (*ldap.ControlServerSideSorting, error) {
+	control := ldap.NewControlServerSideSorting(false)
+	for _, spec := range strings.Split(s, ",") {
+		key := &ldap.SortKey{}
+		if strings.HasPrefix(spec, "-") {
+			key.Reverse = true
+			spec = spec[1:]
+		}
+		if i := strings.Index(spec, ":"); i >= 0 {
+			spec, key.MatchingRule = spec[:i], spec[i+1:]
+		}
+		if spec == "" {
+			return nil, fmt.Errorf("invalid sort key in %q", s)
+		}
+		key.AttributeType = spec
+		control.SortKeys = append(control.SortKeys, key)
+	}
+	return control, nil
+}
@@ -3,6 +3,7 @@ package ldap
 import (
 	"fmt"
 	"strconv"
+	"strings"
 
 	ber "github.com/go-asn1-ber/asn1-ber"
 )
@@ -18,6 +19,8 @@ const (
 	ControlTypeVChuPasswordWarning = "2.16.840.1.113730.3.4.5"
 	// ControlTypeManageDsaIT - https://tools.ietf.org/html/rfc3296
 	ControlTypeManageDsaIT = "2.16.840.1.113730.3.4.2"
+	// ControlTypeServerSideSorting - https://tools.ietf.org/html/rfc2891
+	ControlTypeServerSideSorting = "1.2.840.113556.1.4.473"
 
 	// ControlTypeMicrosoftNotification - https://msdn.microsoft.com/en-us/library/aa366983(v=vs.85).aspx
 	ControlTypeMicrosoftNotification = "1.2.840.113556.1.4.528"
@@ -30,6 +33,7 @@ var ControlTypeMap = map[string]string{
 	ControlTypePaging:                "Paging",
 	ControlTypeBeheraPasswordPolicy:  "Password Policy - Behera Draft",
 	ControlTypeManageDsaIT:           "Manage DSA IT",
+	ControlTypeServerSideSorting:     "Server Side Sorting",
 	ControlTypeMicrosoftNotification: "Change Notification - Microsoft",
 	ControlTypeMicrosoftShowDeleted:  "Show Deleted Objects - Microsoft",
 }
@@ -159,6 +163,26 @@ func (c *ControlBeheraPasswordPolicy) String() string {
 		c.ErrorString)
 }
 
+// Err returns the password policy error reported by the server as a
+// BeheraPasswordPolicyError, or nil if the control carries no error
+func (c *ControlBeheraPasswordPolicy) Err() error {
+	if c.Error < 0 {
+		return nil
+	}
+	return BeheraPasswordPolicyError(c.Error)
+}
+
+// BeheraPasswordPolicyError is the error code sent in a ControlBeheraPasswordPolicy response
+// (see https://tools.ietf.org/html/draft-behera-ldap-password-policy-10#section-6.2)
+type BeheraPasswordPolicyError int8
+
+func (e BeheraPasswordPolicyError) Error() string {
+	if s, ok := BeheraPasswordPolicyErrorMap[int8(e)]; ok {
+		return fmt.Sprintf("LDAP Password Policy Error %d: %s", int8(e), s)
+	}
+	return fmt.Sprintf("LDAP Password Policy Error %d", int8(e))
+}
+
 // ControlVChuPasswordMustChange implements the control described in https://tools.ietf.org/html/draft-vchu-ldap-pwd-policy-00
 type ControlVChuPasswordMustChange struct {
 	// MustChange indicates if the password is required to be changed
@@ -305,6 +329,81 @@ func NewControlMicrosoftShowDeleted() *ControlMicrosoftShowDeleted {
 	return &ControlMicrosoftShowDeleted{}
 }
 
+// SortKey is a key of a ControlServerSideSorting
+type SortKey struct {
+	// AttributeType is the attribute to sort the entries by
+	AttributeType string
+	// MatchingRule is the optional ordering rule used to compare the values of the attribute
+	MatchingRule string
+	// Reverse sorts the entries in descending order
+	Reverse bool
+}
+
+// ControlServerSideSorting implements the sort request control described in https://tools.ietf.org/html/rfc2891
+type ControlServerSideSorting struct {
+	// Criticality makes the search fail if the server cannot sort the entries
+	Criticality bool
+	// SortKeys are the keys to sort the entries by, in order of precedence
+	SortKeys []*SortKey
+}
+
+// GetControlType returns the OID
+func (c *ControlServerSideSorting) GetControlType() string {
+	return ControlTypeServerSideSorting
+}
+
+// Encode returns the ber packet representation
+func (c *ControlServerSideSorting) Encode() *ber.Packet {
+	packet := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Control")
+	packet.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, ControlTypeServerSideSorting, "Control Type ("+ControlTypeMap[ControlTypeServerSideSorting]+")"))
+	if c.Criticality {
+		packet.AppendChild(ber.NewBoolean(ber.ClassUniversal, ber.TypePrimitive, ber.TagBoolean, c.Criticality, "Criticality"))
+	}
+
+	value := ber.Encode(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, nil, "Control Value (Server Side Sorting)")
+	keys := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Sort Key List")
+	for _, key := range c.SortKeys {
+		seq := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Sort Key")
+		seq.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, key.AttributeType, "Attribute Type"))
+		if key.MatchingRule != "" {
+			seq.AppendChild(ber.NewString(ber.ClassContext, ber.TypePrimitive, 0, key.MatchingRule, "Ordering Rule"))
+		}
+		if key.Reverse {
+			seq.AppendChild(ber.NewBoolean(ber.ClassContext, ber.TypePrimitive, 1, key.Reverse, "Reverse Order"))
+		}
+		keys.AppendChild(seq)
+	}
+	value.AppendChild(keys)
+
+	packet.AppendChild(value)
+	return packet
+}
+
+// String returns a human-readable description
+func (c *ControlServerSideSorting) String() string {
+	keys := make([]string, len(c.SortKeys))
+	for i, key := range c.SortKeys {
+		keys[i] = key.AttributeType
+		if key.MatchingRule != "" {
+			keys[i] += ":" + key.MatchingRule
+		}
+		if key.Reverse {
+			keys[i] = "-" + keys[i]
+		}
+	}
+	return fmt.Sprintf(
+		"Control Type: %s (%q)  Criticality: %t  SortKeys: %s",
+		ControlTypeMap[ControlTypeServerSideSorting],
+		ControlTypeServerSideSorting,
+		c.Criticality,
+		strings.Join(keys, ","))
+}
+
+// NewControlServerSideSorting returns a ControlServerSideSorting control sorting the entries by the given keys
+func NewControlServerSideSorting(criticality bool, keys ...*SortKey) *ControlServerSideSorting {
+	return &ControlServerSideSorting{Criticality: criticality, SortKeys: keys}
+}
+
 // FindControl returns the first control of the given type in the list, or nil
 func FindControl(controls []Control, controlType string) Control {
 	for _, c := range controls {
@@ -449,6 +548,48 @@ func DecodeControl(packet *ber.Packet) (Control, error) {
 		return NewControlMicrosoftNotification(), nil
 	case ControlTypeMicrosoftShowDeleted:
 		return NewControlMicrosoftShowDeleted(), nil
+	case ControlTypeServerSideSorting:
+		if value == nil {
+			return nil, fmt.Errorf("sort control without value")
+		}
+		value.Description += " (Server Side Sorting)"
+		c := NewControlServerSideSorting(Criticality)
+		if value.Value != nil {
+			valueChildren, err := ber.DecodePacketErr(value.Data.Bytes())
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode data bytes: %s", err)
+			}
+			value.Data.Truncate(0)
+			value.Value = nil
+			value.AppendChild(valueChildren)
+		}
+		if len(value.Children) == 0 {
+			return nil, fmt.Errorf("sort control without sort key list")
+		}
+		value = value.Children[0]
+		value.Description = "Sort Key List"
+		for _, child := range value.Children {
+			child.Description = "Sort Key"
+			if len(child.Children) == 0 {
+				return nil, fmt.Errorf("sort key without attribute type")
+			}
+			child.Children[0].Description = "Attribute Type"
+			key := &SortKey{AttributeType: ber.DecodeString(child.Children[0].Data.Bytes())}
+			for _, option := range child.Children[1:] {
+				switch option.Tag {
+				case 0:
+					option.Description = "Ordering Rule"
+					key.MatchingRule = ber.DecodeString(option.Data.Bytes())
+					option.Value = key.MatchingRule
+				case 1:
+					option.Description = "Reverse Order"
+					key.Reverse = len(option.Data.Bytes()) == 1 && option.Data.Bytes()[0] != 0
+					option.Value = key.Reverse
+				}
+			}
+			c.SortKeys = append(c.SortKeys, key)
+		}
+		return c, nil
 	default:
 		c := new(ControlString)
 		c.ControlType = ControlType
@@ -483,6 +624,40 @@ func NewControlBeheraPasswordPolicy() *ControlBeheraPasswordPolicy {
 	}
 }
 
+// decodeControls returns the controls sent along with the response in the given LDAPMessage packet
+func decodeControls(packet *ber.Packet) ([]Control, error) {
+	controls := make([]Control, 0)
+	if len(packet.Children) < 3 {
+		return controls, nil
+	}
+	for _, child := range packet.Children[2].Children {
+		decodedChild, err := DecodeControl(child)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode child control: %s", err)
+		}
+		controls = append(controls, decodedChild)
+	}
+	return controls, nil
+}
+
+// decodeResultControls returns the controls sent along with the response in the given LDAPMessage packet
+// and the LDAP error of the response. The result of a failed response takes precedence over an error
+// decoding its controls, which is then appended to the error of the response.
+func decodeResultControls(packet *ber.Packet) ([]Control, error) {
+	controls, controlErr := decodeControls(packet)
+	err := GetLDAPError(packet)
+	if controlErr == nil {
+		return controls, err
+	}
+	if err == nil {
+		return nil, controlErr
+	}
+	if ldapErr, ok := err.(*Error); ok {
+		ldapErr.Err = fmt.Errorf("%s (%s)", ldapErr.Err, controlErr)
+	}
+	return nil, err
+}
+
 func encodeControls(controls []Control) *ber.Packet {
 	packet := ber.Encode(ber.ClassContext, ber.TypeConstructed, 0, nil, "Controls")
 	for _, control := range controls {
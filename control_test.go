@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"reflect"
 	"runtime"
+	"strings"
 	"testing"
 
 	ber "github.com/go-asn1-ber/asn1-ber"
@@ -28,6 +29,38 @@ func TestControlMicrosoftShowDeleted(t *testing.T) {
 	runControlTest(t, NewControlMicrosoftShowDeleted())
 }
 
+func TestControlServerSideSorting(t *testing.T) {
+	runControlTest(t, NewControlServerSideSorting(false, &SortKey{AttributeType: "sn"}))
+	runControlTest(t, NewControlServerSideSorting(true, &SortKey{AttributeType: "sn", Reverse: true},
+		&SortKey{AttributeType: "cn", MatchingRule: "caseExactOrderingMatch"}))
+}
+
+func TestDecodeControlServerSideSortingInvalid(t *testing.T) {
+	newControl := func(value *ber.Packet) *ber.Packet {
+		packet := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Control")
+		packet.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, ControlTypeServerSideSorting, "Control Type"))
+		if value != nil {
+			packet.AppendChild(value)
+		}
+		// decode the control as received from a server
+		return ber.DecodePacket(packet.Bytes())
+	}
+
+	for _, value := range []*ber.Packet{
+		nil,
+		ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Control Value"),
+	} {
+		if _, err := DecodeControl(newControl(value)); err == nil {
+			t.Errorf("expected an error decoding a sort control with value %v", value)
+		}
+		controls := ber.Encode(ber.ClassContext, ber.TypeConstructed, 0, nil, "Controls")
+		controls.AppendChild(newControl(value))
+		if value != nil && addControlDescriptions(controls) == nil {
+			t.Errorf("expected an error describing a sort control with value %v", value)
+		}
+	}
+}
+
 func TestControlString(t *testing.T) {
 	runControlTest(t, NewControlString("x", true, "y"))
 	runControlTest(t, NewControlString("x", true, ""))
@@ -93,6 +126,11 @@ func TestDescribeControlMicrosoftShowDeleted(t *testing.T) {
 	runAddControlDescriptions(t, NewControlMicrosoftShowDeleted(), "Control Type (Show Deleted Objects - Microsoft)")
 }
 
+func TestDescribeControlServerSideSorting(t *testing.T) {
+	runAddControlDescriptions(t, NewControlServerSideSorting(false, &SortKey{AttributeType: "sn"}), "Control Type (Server Side Sorting)", "Control Value (Server Side Sorting)")
+	runAddControlDescriptions(t, NewControlServerSideSorting(true, &SortKey{AttributeType: "sn"}), "Control Type (Server Side Sorting)", "Criticality", "Control Value (Server Side Sorting)")
+}
+
 func TestDescribeControlString(t *testing.T) {
 	runAddControlDescriptions(t, NewControlString("x", true, "y"), "Control Type ()", "Criticality", "Control Value")
 	runAddControlDescriptions(t, NewControlString("x", true, ""), "Control Type ()", "Criticality")
@@ -174,3 +212,117 @@ func TestDecodeControl(t *testing.T) {
 		})
 	}
 }
+
+func TestControlBeheraPasswordPolicyErr(t *testing.T) {
+	c := NewControlBeheraPasswordPolicy()
+	if err := c.Err(); err != nil {
+		t.Errorf("expected no error for a control without error code, got: %v", err)
+	}
+	c.Error = BeheraPasswordInHistory
+	err := c.Err()
+	if err == nil {
+		t.Fatal("expected an error for a control with an error code")
+	}
+	if err != BeheraPasswordPolicyError(BeheraPasswordInHistory) {
+		t.Errorf("unexpected error: %#v", err)
+	}
+	if !strings.Contains(err.Error(), BeheraPasswordPolicyErrorMap[BeheraPasswordInHistory]) {
+		t.Errorf("error message does not contain the error description: %s", err)
+	}
+}
+
+func TestModifyWithResultControls(t *testing.T) {
+	ptc := newPacketTranslatorConn()
+	defer ptc.Close()
+
+	conn := NewConn(ptc, false)
+	conn.Start()
+	defer conn.Close()
+
+	go func() {
+		request, err := ptc.ReceiveRequest()
+		if err != nil {
+			t.Errorf("unable to receive request packet: %s", err)
+			return
+		}
+		response := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+		response.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, request.Children[0].Value, "MessageID"))
+		modifyResponse := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ApplicationModifyResponse, nil, "Modify Response")
+		modifyResponse.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, LDAPResultConstraintViolation, "resultCode"))
+		modifyResponse.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "matchedDN"))
+		modifyResponse.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "Password is in history of old passwords", "diagnosticMessage"))
+		response.AppendChild(modifyResponse)
+		// controls holding a password policy response with the passwordInHistory error
+		response.AppendChild(ber.DecodePacket([]byte{0xa0, 0x24, 0x30, 0x22, 0x4, 0x19, 0x31, 0x2e, 0x33, 0x2e, 0x36, 0x2e, 0x31, 0x2e, 0x34, 0x2e, 0x31, 0x2e, 0x34, 0x32, 0x2e, 0x32, 0x2e, 0x32, 0x37, 0x2e, 0x38, 0x2e, 0x35, 0x2e, 0x31, 0x4, 0x5, 0x30, 0x3, 0x81, 0x1, 0x8}))
+		if err := ptc.SendResponse(response); err != nil {
+			t.Errorf("unable to send response packet: %s", err)
+		}
+	}()
+
+	req := NewModifyRequest("uid=someone,dc=example,dc=org", []Control{NewControlBeheraPasswordPolicy()})
+	req.Replace("userPassword", []string{"secret"})
+	result, err := conn.ModifyWithResult(req)
+	if !IsErrorWithCode(err, LDAPResultConstraintViolation) {
+		t.Fatalf("expected a constraint violation error, got: %v", err)
+	}
+	if result == nil {
+		t.Fatal("expected a result along with the error")
+	}
+	control, ok := FindControl(result.Controls, ControlTypeBeheraPasswordPolicy).(*ControlBeheraPasswordPolicy)
+	if !ok {
+		t.Fatalf("expected a password policy control in the result, got: %v", result.Controls)
+	}
+	if control.Err() != BeheraPasswordPolicyError(BeheraPasswordInHistory) {
+		t.Errorf("unexpected password policy error: %v", control.Err())
+	}
+}
+
+func TestDecodeResultControls(t *testing.T) {
+	// controls holding a control without control type
+	invalidControls := ber.Encode(ber.ClassContext, ber.TypeConstructed, 0, nil, "Controls")
+	invalidControls.AppendChild(ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Control"))
+
+	packet := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+	packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, int64(1), "MessageID"))
+	packet.AppendChild(newTestLDAPResult(ApplicationDelResponse, LDAPResultNoSuchObject))
+	packet.AppendChild(invalidControls)
+
+	// the result code of a failed response is kept
+	controls, err := decodeResultControls(packet)
+	if controls != nil || !IsErrorWithCode(err, LDAPResultNoSuchObject) || !strings.Contains(err.Error(), "failed to decode child control") {
+		t.Errorf("unexpected controls %v and error %v", controls, err)
+	}
+
+	// the decoding error is returned for successful responses
+	packet.Children[1] = newTestLDAPResult(ApplicationDelResponse, LDAPResultSuccess)
+	if _, err := decodeResultControls(packet); err == nil || !strings.Contains(err.Error(), "failed to decode child control") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestSimpleBindUndecodableControls(t *testing.T) {
+	ptc := newPacketTranslatorConn()
+	conn := NewConn(ptc, false)
+	conn.Start()
+	defer conn.Close()
+
+	go func() {
+		request, err := ptc.ReceiveRequest()
+		if err != nil {
+			return
+		}
+		invalidControls := ber.Encode(ber.ClassContext, ber.TypeConstructed, 0, nil, "Controls")
+		invalidControls.AppendChild(ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Control"))
+		packet := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+		packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, request.Children[0].Value, "MessageID"))
+		packet.AppendChild(newTestLDAPResult(ApplicationBindResponse, LDAPResultInvalidCredentials))
+		packet.AppendChild(invalidControls)
+		ptc.SendResponse(packet)
+	}()
+
+	// the result code of the failed bind is kept
+	result, err := conn.SimpleBind(NewSimpleBindRequest("cn=admin,dc=example,dc=org", "wrong", nil))
+	if result == nil || !IsErrorWithCode(err, LDAPResultInvalidCredentials) {
+		t.Errorf("unexpected result %v and error %v", result, err)
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 // debugging type
 //     - has a Printf method to write the debug output
+//     - enables the debug records of a Conn without a Logger, see Conn.SetLogger
 type debugging bool
 
 // Enable controls debugging mode.
@@ -22,9 +23,9 @@ func (debug debugging) Printf(format string, args ...interface{}) {
 	}
 }
 
-// PrintPacket dumps a packet.
+// PrintPacket dumps a packet, with its credentials redacted.
 func (debug debugging) PrintPacket(packet *ber.Packet) {
 	if debug {
-		ber.PrintPacket(packet)
+		ber.PrintPacket(DefaultRedactor.Redact(packet))
 	}
 }
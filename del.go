@@ -1,8 +1,6 @@
 package ldap
 
 import (
-	"log"
-
 	ber "github.com/go-asn1-ber/asn1-ber"
 )
 
@@ -34,26 +32,59 @@ func NewDelRequest(DN string, Controls []Control) *DelRequest {
 	}
 }
 
+// DelResult holds the server's response to a delete request
+type DelResult struct {
+	// MatchedDN is the matchedDN returned by the server, if any
+	MatchedDN string
+	// DiagnosticMessage is the diagnostic message returned by the server, if any
+	DiagnosticMessage string
+	// Referrals are the referral URIs returned with LDAPResultReferral, if any
+	Referrals []string
+	// Controls are the returned controls
+	Controls []Control
+}
+
 // Del executes the given delete request
 func (l *Conn) Del(delRequest *DelRequest) error {
+	_, err := l.DelWithResult(delRequest)
+	return err
+}
+
+// DelWithResult executes the given delete request and returns the result
+func (l *Conn) DelWithResult(delRequest *DelRequest) (*DelResult, error) {
+	result, err := l.del(delRequest)
+	if l.chasesReferral(err) {
+		err = l.chaseReferrals(err, 0, map[string]bool{}, func(conn *Conn, u *LDAPURL) error {
+			referred := *delRequest
+			referred.DN = referredDN(delRequest.DN, u)
+			result, err = conn.DelWithResult(&referred)
+			return err
+		})
+	}
+	return result, err
+}
+
+func (l *Conn) del(delRequest *DelRequest) (*DelResult, error) {
 	msgCtx, err := l.doRequest(delRequest)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer l.finishMessage(msgCtx)
 
 	packet, err := l.readPacket(msgCtx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	result := &DelResult{}
 	if packet.Children[1].Tag == ApplicationDelResponse {
-		err := GetLDAPError(packet)
+		result.MatchedDN, result.DiagnosticMessage, result.Referrals = getLDAPResult(packet)
+		result.Controls, err = decodeResultControls(packet)
 		if err != nil {
-			return err
+			return result, err
 		}
 	} else {
-		log.Printf("Unexpected Response: %d", packet.Children[1].Tag)
+		l.log(LevelWarn, "unexpected response", LogKeyMessageID, msgCtx.id, LogKeyOperation, operationName(packet))
 	}
-	return nil
+	return result, nil
 }
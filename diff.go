@@ -0,0 +1,211 @@
+package ldap
+
+import (
+	"strings"
+)
+
+// operationalAttributes are attributes maintained by common servers, which DiffEntries ignores unless
+// DiffOptions.IncludeOperational is set
+var operationalAttributes = []string{
+	"createTimestamp", "modifyTimestamp", "creatorsName", "modifiersName", "entryDN", "entryUUID", "entryCSN",
+	"subschemaSubentry", "hasSubordinates", "numSubordinates", "structuralObjectClass", "memberOf",
+	"pwdChangedTime", "pwdAccountLockedTime", "pwdFailureTime", "contextCSN",
+	// Active Directory
+	"whenCreated", "whenChanged", "uSNCreated", "uSNChanged", "objectGUID", "objectSid", "distinguishedName",
+	"dSCorePropagationData", "instanceType", "name", "lastLogon", "lastLogonTimestamp", "logonCount",
+	"badPwdCount", "badPasswordTime", "sAMAccountType", "isCriticalSystemObject",
+}
+
+// DiffOptions controls how DiffEntries compares entries
+type DiffOptions struct {
+	// IgnoreAttributes are the attributes not compared, e.g. attributes managed elsewhere. Attribute
+	// names are compared case-insensitively.
+	IgnoreAttributes []string
+	// IncludeOperational also compares operational attributes, which are ignored by default. Operational
+	// attributes are identified by the schema if given and by a list of common attributes otherwise.
+	IncludeOperational bool
+	// AlwaysReplace replaces all values of changed attributes. By default, single values are added
+	// and deleted instead when this sends fewer values.
+	AlwaysReplace bool
+	// Schema, if set, identifies operational attributes and attribute types with several names
+	Schema *Schema
+}
+
+// diffAttribute holds the values of an attribute of an entry being compared
+type diffAttribute struct {
+	name   string
+	values []string
+}
+
+// DiffEntries returns the requests changing the entry old into the entry new. The ModifyDNRequest is
+// only returned if the DN changed and must be sent first, as the ModifyRequest then modifies the entry
+// at its new DN and accounts for the RDN values added and deleted by the rename. The ModifyRequest is
+// nil if no attributes need to change.
+//
+// Attribute names are compared case-insensitively and values byte by byte, using ByteValues if set.
+// New attributes are added, missing ones deleted, and changed ones modified with the smallest of a
+// replace or deletions and additions of single values.
+func DiffEntries(old, new *Entry, opts *DiffOptions) (*ModifyRequest, *ModifyDNRequest, error) {
+	if opts == nil {
+		opts = &DiffOptions{}
+	}
+	oldAttrs, oldOrder := opts.diffAttributes(old)
+	newAttrs, newOrder := opts.diffAttributes(new)
+
+	var modifyDNReq *ModifyDNRequest
+	oldDN, err := ParseDN(old.DN)
+	if err != nil {
+		return nil, nil, err
+	}
+	newDN, err := ParseDN(new.DN)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !oldDN.Equal(newDN) {
+		modifyDNReq = opts.diffRDN(oldDN, newDN, oldAttrs, newAttrs, &oldOrder)
+	}
+
+	req := NewModifyRequest(new.DN, nil)
+	for _, key := range newOrder {
+		attr := newAttrs[key]
+		if len(attr.values) == 0 {
+			// attributes without values are deleted below like missing ones
+			continue
+		}
+		oldAttr, ok := oldAttrs[key]
+		if !ok || len(oldAttr.values) == 0 {
+			req.Add(attr.name, attr.values)
+			continue
+		}
+		added, deleted := diffValues(oldAttr.values, attr.values), diffValues(attr.values, oldAttr.values)
+		switch {
+		case len(added) == 0 && len(deleted) == 0:
+		case opts.AlwaysReplace || len(added)+len(deleted) > len(attr.values):
+			req.Replace(attr.name, attr.values)
+		default:
+			if len(deleted) > 0 {
+				req.Delete(attr.name, deleted)
+			}
+			if len(added) > 0 {
+				req.Add(attr.name, added)
+			}
+		}
+	}
+	for _, key := range oldOrder {
+		if attr, ok := newAttrs[key]; (!ok || len(attr.values) == 0) && len(oldAttrs[key].values) > 0 {
+			req.Delete(oldAttrs[key].name, nil)
+		}
+	}
+
+	if len(req.Changes) == 0 {
+		req = nil
+	}
+	return req, modifyDNReq, nil
+}
+
+// diffRDN returns the request renaming the entry and updates the old attributes with the RDN values
+// the server adds and deletes when renaming it
+func (opts *DiffOptions) diffRDN(oldDN, newDN *DN, oldAttrs, newAttrs map[string]*diffAttribute, oldOrder *[]string) *ModifyDNRequest {
+	// the old RDN values are only kept if they are still wanted
+	var oldRDN []*AttributeTypeAndValue
+	if rdn := oldDN.RDN(); rdn != nil {
+		oldRDN = rdn.Attributes
+	}
+	deleteOld := false
+	for _, atv := range oldRDN {
+		if attr := newAttrs[opts.attributeKey(atv.Type)]; attr == nil || !containsString(attr.values, atv.Value) {
+			deleteOld = true
+		}
+	}
+
+	if deleteOld {
+		for _, atv := range oldRDN {
+			if attr := oldAttrs[opts.attributeKey(atv.Type)]; attr != nil {
+				attr.values = diffValues([]string{atv.Value}, attr.values)
+			}
+		}
+	}
+	if rdn := newDN.RDN(); rdn != nil {
+		for _, atv := range rdn.Attributes {
+			key := opts.attributeKey(atv.Type)
+			attr := oldAttrs[key]
+			if attr == nil {
+				attr = &diffAttribute{name: atv.Type}
+				oldAttrs[key] = attr
+				*oldOrder = append(*oldOrder, key)
+			}
+			if !containsString(attr.values, atv.Value) {
+				attr.values = append(attr.values, atv.Value)
+			}
+		}
+	}
+	return NewModifyDNRequestFromDN(oldDN, newDN, deleteOld)
+}
+
+// diffAttributes returns the compared attributes of the entry by key, and their keys in order
+func (opts *DiffOptions) diffAttributes(entry *Entry) (map[string]*diffAttribute, []string) {
+	attrs := make(map[string]*diffAttribute)
+	var order []string
+	for _, attr := range entry.Attributes {
+		if opts.ignored(attr.Name) {
+			continue
+		}
+		values := attr.Values
+		if attr.ByteValues != nil {
+			values = make([]string, len(attr.ByteValues))
+			for i, value := range attr.ByteValues {
+				values[i] = string(value)
+			}
+		}
+
+		key := opts.attributeKey(attr.Name)
+		if existing, ok := attrs[key]; ok {
+			existing.values = append(existing.values, values...)
+			continue
+		}
+		attrs[key] = &diffAttribute{name: attr.Name, values: append([]string(nil), values...)}
+		order = append(order, key)
+	}
+	return attrs, order
+}
+
+func (opts *DiffOptions) ignored(name string) bool {
+	key := opts.attributeKey(name)
+	for _, ignored := range opts.IgnoreAttributes {
+		if opts.attributeKey(ignored) == key {
+			return true
+		}
+	}
+	if opts.IncludeOperational {
+		return false
+	}
+	if opts.Schema != nil {
+		if at := opts.Schema.AttributeType(attributeDescriptionType(name)); at != nil {
+			return at.NoUserModification || (at.Usage != "" && at.Usage != AttributeUsageUserApplications)
+		}
+	}
+	for _, operational := range operationalAttributes {
+		if strings.EqualFold(operational, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (opts *DiffOptions) attributeKey(name string) string {
+	if opts.Schema != nil {
+		return opts.Schema.attributeDescriptionKey(name)
+	}
+	return strings.ToLower(name)
+}
+
+// diffValues returns the values of b which are not in a
+func diffValues(a, b []string) []string {
+	var diff []string
+	for _, value := range b {
+		if !containsString(a, value) {
+			diff = append(diff, value)
+		}
+	}
+	return diff
+}
@@ -0,0 +1,134 @@
+package ldap
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffEntries(t *testing.T) {
+	old := NewEntry("uid=jdoe,ou=People,dc=example,dc=org", map[string][]string{
+		"objectClass":     {"top", "person", "inetOrgPerson"},
+		"uid":             {"jdoe"},
+		"cn":              {"John Doe"},
+		"sn":              {"Doe"},
+		"mail":            {"jdoe@example.org", "john@example.org", "doe@example.org"},
+		"telephoneNumber": {"1234"},
+		"description":     {"old"},
+		"modifyTimestamp": {"20200101000000Z"},
+		"jpegPhoto":       {"\x00\xff\x01"},
+	})
+	new := NewEntry("uid=jdoe,ou=People,dc=example,dc=org", map[string][]string{
+		"objectclass":     {"top", "person", "inetOrgPerson"},
+		"uid":             {"jdoe"},
+		"cn":              {"John Doe"},
+		"SN":              {"Doe-Smith"},
+		"mail":            {"jdoe@example.org", "john@example.org", "doe@example.org", "j@example.org"},
+		"telephoneNumber": {"1234"},
+		"title":           {"Engineer"},
+		"modifyTimestamp": {"20210101000000Z"},
+		"jpegPhoto":       {"\x00\xff\x02"},
+	})
+
+	req, modifyDNReq, err := DiffEntries(old, new, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if modifyDNReq != nil {
+		t.Errorf("unexpected modify DN request %+v", modifyDNReq)
+	}
+	want := []Change{
+		{ReplaceAttribute, PartialAttribute{Type: "SN", Vals: []string{"Doe-Smith"}}},
+		{ReplaceAttribute, PartialAttribute{Type: "jpegPhoto", Vals: []string{"\x00\xff\x02"}}},
+		{AddAttribute, PartialAttribute{Type: "mail", Vals: []string{"j@example.org"}}},
+		{AddAttribute, PartialAttribute{Type: "title", Vals: []string{"Engineer"}}},
+		{DeleteAttribute, PartialAttribute{Type: "description"}},
+	}
+	if req.DN != new.DN || !reflect.DeepEqual(req.Changes, want) {
+		t.Errorf("got changes %+v for %q, want %+v", req.Changes, req.DN, want)
+	}
+
+	req, _, _ = DiffEntries(old, new, &DiffOptions{IgnoreAttributes: []string{"MAIL", "jpegphoto", "sn", "title", "description"}, IncludeOperational: true})
+	want = []Change{{ReplaceAttribute, PartialAttribute{Type: "modifyTimestamp", Vals: []string{"20210101000000Z"}}}}
+	if !reflect.DeepEqual(req.Changes, want) {
+		t.Errorf("got changes %+v, want %+v", req.Changes, want)
+	}
+
+	req, _, _ = DiffEntries(old, new, &DiffOptions{IgnoreAttributes: []string{"jpegPhoto", "sn", "title", "description"}, AlwaysReplace: true})
+	want = []Change{{ReplaceAttribute, PartialAttribute{Type: "mail", Vals: new.GetAttributeValues("mail")}}}
+	if !reflect.DeepEqual(req.Changes, want) {
+		t.Errorf("got changes %+v, want %+v", req.Changes, want)
+	}
+
+	// with a schema, different names of the same attribute type compare equal
+	schema := newTestSchema(t)
+	a := NewEntry("cn=a,dc=example,dc=org", map[string][]string{"cn": {"a"}, "surname": {"b"}, "createTimestamp": {"20200101000000Z"}})
+	b := NewEntry("cn=a,dc=example,dc=org", map[string][]string{"commonName": {"a"}, "SN": {"b"}, "createTimestamp": {"20210101000000Z"}})
+	if req, _, err := DiffEntries(a, b, &DiffOptions{Schema: schema}); req != nil || err != nil {
+		t.Errorf("unexpected request %+v (%v)", req, err)
+	}
+
+	// attributes without values are not added, and deleted if they have values in the old entry
+	a = NewEntry("cn=a,dc=example,dc=org", map[string][]string{"cn": {"a"}, "description": {"x"}})
+	b = NewEntry("cn=a,dc=example,dc=org", map[string][]string{"cn": {"a"}, "description": {}, "title": {}})
+	req, _, _ = DiffEntries(a, b, nil)
+	want = []Change{{DeleteAttribute, PartialAttribute{Type: "description"}}}
+	if req == nil || !reflect.DeepEqual(req.Changes, want) {
+		t.Errorf("got request %+v, want changes %+v", req, want)
+	}
+
+	if req, modifyDNReq, err := DiffEntries(old, old, nil); req != nil || modifyDNReq != nil || err != nil {
+		t.Errorf("unexpected requests %+v and %+v (%v) for the same entry", req, modifyDNReq, err)
+	}
+	if _, _, err := DiffEntries(old, &Entry{DN: "invalid"}, nil); err == nil {
+		t.Error("expected an error for an invalid DN")
+	}
+}
+
+func TestDiffEntriesRename(t *testing.T) {
+	old := NewEntry("cn=John Doe,ou=People,dc=example,dc=org", map[string][]string{
+		"cn": {"John Doe", "Johnny"},
+		"sn": {"Doe"},
+	})
+
+	testcases := []struct {
+		new         *Entry
+		modifyDNReq ModifyDNRequest
+		changes     []Change
+	}{
+		{
+			NewEntry("cn=John Smith,ou=People,dc=example,dc=org", map[string][]string{"cn": {"John Smith", "Johnny"}, "sn": {"Doe"}}),
+			ModifyDNRequest{DN: old.DN, NewRDN: "cn=John Smith", DeleteOldRDN: true},
+			nil,
+		},
+		{
+			NewEntry("cn=John Smith,ou=Staff,dc=example,dc=org", map[string][]string{"cn": {"John Smith", "John Doe"}, "sn": {"Smith"}}),
+			ModifyDNRequest{DN: old.DN, NewRDN: "cn=John Smith", NewSuperior: "ou=Staff,dc=example,dc=org"},
+			[]Change{
+				{DeleteAttribute, PartialAttribute{Type: "cn", Vals: []string{"Johnny"}}},
+				{ReplaceAttribute, PartialAttribute{Type: "sn", Vals: []string{"Smith"}}},
+			},
+		},
+		{
+			NewEntry("uid=jdoe,ou=People,dc=example,dc=org", map[string][]string{"uid": {"jdoe"}, "sn": {"Doe"}}),
+			ModifyDNRequest{DN: old.DN, NewRDN: "uid=jdoe", DeleteOldRDN: true},
+			[]Change{{DeleteAttribute, PartialAttribute{Type: "cn"}}},
+		},
+	}
+	for i, tc := range testcases {
+		req, modifyDNReq, err := DiffEntries(old, tc.new, nil)
+		if err != nil {
+			t.Errorf("#%d: unexpected error: %s", i, err)
+			continue
+		}
+		if modifyDNReq == nil || !reflect.DeepEqual(*modifyDNReq, tc.modifyDNReq) {
+			t.Errorf("#%d: got modify DN request %+v, want %+v", i, modifyDNReq, tc.modifyDNReq)
+		}
+		var changes []Change
+		if req != nil {
+			changes = req.Changes
+		}
+		if !reflect.DeepEqual(changes, tc.changes) {
+			t.Errorf("#%d: got changes %+v, want %+v", i, changes, tc.changes)
+		}
+	}
+}
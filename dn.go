@@ -5,6 +5,7 @@ import (
 	enchex "encoding/hex"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 
 	ber "github.com/go-asn1-ber/asn1-ber"
@@ -16,6 +17,9 @@ type AttributeTypeAndValue struct {
 	Type string
 	// Value is the attribute value
 	Value string
+
+	// rawBER is the BER encoding of the value if it was given in hexadecimal form in the parsed DN
+	rawBER []byte
 }
 
 // RelativeDN represents a relativeDistinguishedName from https://tools.ietf.org/html/rfc4514
@@ -99,6 +103,7 @@ func ParseDN(str string) (*DN, error) {
 					return nil, fmt.Errorf("failed to decode BER packet: %s", err)
 				}
 				buffer.WriteString(packet.Data.String())
+				attribute.rawBER = rawBER
 				i += len(data) - 1
 			}
 		case char == ',' || char == '+':
@@ -205,3 +210,254 @@ func (r *RelativeDN) hasAllAttributes(attrs []*AttributeTypeAndValue) bool {
 func (a *AttributeTypeAndValue) Equal(other *AttributeTypeAndValue) bool {
 	return strings.EqualFold(a.Type, other.Type) && a.Value == other.Value
 }
+
+// String returns the string representation of the DN as defined in https://tools.ietf.org/html/rfc4514#section-2
+func (d *DN) String() string {
+	rdns := make([]string, len(d.RDNs))
+	for i, rdn := range d.RDNs {
+		rdns[i] = rdn.String()
+	}
+	return strings.Join(rdns, ",")
+}
+
+// String returns the string representation of the RDN as defined in https://tools.ietf.org/html/rfc4514#section-2.
+// The attributes of multi-valued RDNs are sorted by type and value, as their order is not significant.
+func (r *RelativeDN) String() string {
+	attrs := make([]string, len(r.Attributes))
+	for i, attr := range r.Attributes {
+		attrs[i] = attr.String()
+	}
+	sort.Slice(attrs, func(i, j int) bool {
+		x, y := strings.ToLower(attrs[i]), strings.ToLower(attrs[j])
+		if x != y {
+			return x < y
+		}
+		return attrs[i] < attrs[j]
+	})
+	return strings.Join(attrs, "+")
+}
+
+// String returns the string representation of the attribute type and value as defined in
+// https://tools.ietf.org/html/rfc4514#section-2
+func (a *AttributeTypeAndValue) String() string {
+	if rawBER := a.BER(); rawBER != nil {
+		return a.Type + "=#" + enchex.EncodeToString(rawBER)
+	}
+	return a.Type + "=" + EscapeDNValue(a.Value)
+}
+
+// BER returns the BER encoding of the value if it was given in hexadecimal form ("#04024869") in the
+// parsed DN, or nil. The encoding is dropped by SetValue and ignored once Value no longer matches it.
+func (a *AttributeTypeAndValue) BER() []byte {
+	if a.rawBER == nil {
+		return nil
+	}
+	packet, err := ber.DecodePacketErr(a.rawBER)
+	if err != nil || packet.Data.String() != a.Value {
+		return nil
+	}
+	return a.rawBER
+}
+
+// SetValue sets the value of the attribute, dropping the BER encoding of the previous value
+func (a *AttributeTypeAndValue) SetValue(value string) {
+	a.Value, a.rawBER = value, nil
+}
+
+// HexEncoded returns true if the value was given in hexadecimal form in the parsed DN, see BER
+func (a *AttributeTypeAndValue) HexEncoded() bool {
+	return a.BER() != nil
+}
+
+// EscapeDNValue escapes the characters of an attribute value which are special in
+// a DN, see https://tools.ietf.org/html/rfc4514#section-2.4
+func EscapeDNValue(value string) string {
+	var buf strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case c == ' ' && (i == 0 || i == len(value)-1),
+			c == '#' && i == 0,
+			c == '"', c == '+', c == ',', c == ';', c == '<', c == '>', c == '\\', c == '=':
+			buf.WriteByte('\\')
+			buf.WriteByte(c)
+		case c == 0:
+			buf.WriteString(`\00`)
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	return buf.String()
+}
+
+// RDN returns the first, most specific, RDN of the DN, or nil for the empty DN
+func (d *DN) RDN() *RelativeDN {
+	if len(d.RDNs) == 0 {
+		return nil
+	}
+	return d.RDNs[0]
+}
+
+// Parent returns the DN of the parent entry, or nil for the empty DN. The RDNs are shared with d.
+// "ou=widgets,o=acme.com" is the parent of "ou=sprockets,ou=widgets,o=acme.com"
+func (d *DN) Parent() *DN {
+	if len(d.RDNs) == 0 {
+		return nil
+	}
+	return &DN{RDNs: d.RDNs[1:len(d.RDNs):len(d.RDNs)]}
+}
+
+// Child returns the DN of the child entry with the given RDN. The RDNs are shared with d.
+// "ou=sprockets,ou=widgets,o=acme.com" is the child "ou=sprockets" of "ou=widgets,o=acme.com"
+func (d *DN) Child(rdn *RelativeDN) *DN {
+	rdns := make([]*RelativeDN, 0, len(d.RDNs)+1)
+	rdns = append(rdns, rdn)
+	return &DN{RDNs: append(rdns, d.RDNs...)}
+}
+
+// Rebase returns the DN with the RDNs of oldBase at its end replaced by those of newBase, e.g. to compute
+// the new DN of an entry when moving a subtree. It returns an error if the DN is not oldBase or one of
+// its descendants.
+// "ou=sprockets,o=foo.com" is "ou=sprockets,ou=widgets,o=acme.com" rebased from "ou=widgets,o=acme.com" to "o=foo.com"
+func (d *DN) Rebase(oldBase, newBase *DN) (*DN, error) {
+	if !d.Equal(oldBase) && !oldBase.AncestorOf(d) {
+		return nil, fmt.Errorf("ldap: %q is not within %q", d, oldBase)
+	}
+	rdns := make([]*RelativeDN, 0, len(d.RDNs)-len(oldBase.RDNs)+len(newBase.RDNs))
+	rdns = append(rdns, d.RDNs[:len(d.RDNs)-len(oldBase.RDNs)]...)
+	return &DN{RDNs: append(rdns, newBase.RDNs...)}, nil
+}
+
+// DNBuilder builds a DN from its most specific RDN towards the root, e.g.
+//
+//	dn := NewDNBuilder().RDN("cn", "Smith, John").RDN("ou", "People").Base(baseDN).DN()
+//
+// Values are given unescaped and escaped as needed by DN.String.
+type DNBuilder struct {
+	rdns []*RelativeDN
+}
+
+// NewDNBuilder returns a builder for a new DN
+func NewDNBuilder() *DNBuilder {
+	return &DNBuilder{}
+}
+
+// RDN appends an RDN with the given attribute type and value
+func (b *DNBuilder) RDN(attrType, value string) *DNBuilder {
+	b.rdns = append(b.rdns, &RelativeDN{Attributes: []*AttributeTypeAndValue{{Type: attrType, Value: value}}})
+	return b
+}
+
+// Attribute adds an attribute type and value to the last RDN, making it multi-valued, or appends
+// an RDN if there is none yet
+func (b *DNBuilder) Attribute(attrType, value string) *DNBuilder {
+	if len(b.rdns) == 0 {
+		return b.RDN(attrType, value)
+	}
+	// copy the RDN, it may be shared with a base DN or a DN returned before
+	last := b.rdns[len(b.rdns)-1]
+	attrs := make([]*AttributeTypeAndValue, 0, len(last.Attributes)+1)
+	attrs = append(attrs, last.Attributes...)
+	b.rdns[len(b.rdns)-1] = &RelativeDN{Attributes: append(attrs, &AttributeTypeAndValue{Type: attrType, Value: value})}
+	return b
+}
+
+// Base appends the RDNs of the given DN
+func (b *DNBuilder) Base(dn *DN) *DNBuilder {
+	b.rdns = append(b.rdns, dn.RDNs...)
+	return b
+}
+
+// DN returns the DN built so far
+func (b *DNBuilder) DN() *DN {
+	return &DN{RDNs: append([]*RelativeDN{}, b.rdns...)}
+}
+
+// String returns the string representation of the DN built so far
+func (b *DNBuilder) String() string {
+	return b.DN().String()
+}
+
+// dnAttributeTypeAliases maps the lowercase names and OIDs of attribute types commonly used in DNs
+// to their canonical lowercase short name, see https://tools.ietf.org/html/rfc4514#section-3
+var dnAttributeTypeAliases = map[string]string{
+	"cn": "cn", "commonname": "cn", "2.5.4.3": "cn",
+	"sn": "sn", "surname": "sn", "2.5.4.4": "sn",
+	"serialnumber": "serialnumber", "2.5.4.5": "serialnumber",
+	"c": "c", "countryname": "c", "2.5.4.6": "c",
+	"l": "l", "localityname": "l", "2.5.4.7": "l",
+	"st": "st", "stateorprovincename": "st", "2.5.4.8": "st",
+	"street": "street", "streetaddress": "street", "2.5.4.9": "street",
+	"o": "o", "organizationname": "o", "2.5.4.10": "o",
+	"ou": "ou", "organizationalunitname": "ou", "2.5.4.11": "ou",
+	"title": "title", "2.5.4.12": "title",
+	"givenname": "givenname", "gn": "givenname", "2.5.4.42": "givenname",
+	"dc": "dc", "domaincomponent": "dc", "0.9.2342.19200300.100.1.25": "dc",
+	"uid": "uid", "userid": "uid", "0.9.2342.19200300.100.1.1": "uid",
+	"mail": "mail", "rfc822mailbox": "mail", "0.9.2342.19200300.100.1.3": "mail",
+	"emailaddress": "emailaddress", "email": "emailaddress", "1.2.840.113549.1.9.1": "emailaddress",
+}
+
+// Normalize returns a copy of the DN in canonical form, so that DNs which are equal according to
+// distinguishedNameMatch have the same string representation, e.g. for use as map keys:
+//   - attribute types are replaced by their first name in lowercase, using the schema if given and
+//     otherwise a table of common attribute types, so that aliases and OIDs compare equal
+//   - values are normalized with the equality matching rule of their attribute type, as in
+//     FilterEvaluator, e.g. case is ignored and insignificant spaces are removed for caseIgnoreMatch
+//   - the attributes of multi-valued RDNs are sorted
+func (d *DN) Normalize(schema *Schema) *DN {
+	ev := &FilterEvaluator{Schema: schema}
+	normalized := &DN{RDNs: make([]*RelativeDN, len(d.RDNs))}
+	for i, rdn := range d.RDNs {
+		attrs := make([]*AttributeTypeAndValue, len(rdn.Attributes))
+		for j, attr := range rdn.Attributes {
+			attrType := normalizeDNAttributeType(attr.Type, schema)
+			value := attr.Value
+			if m := ev.attributeMatcher(attrType, equalityRule); m != nil && m.Normalize != nil {
+				if v, err := m.Normalize(value); err == nil {
+					value = v
+				}
+			}
+			attrs[j] = &AttributeTypeAndValue{Type: attrType, Value: value}
+		}
+		sort.Slice(attrs, func(i, j int) bool {
+			if attrs[i].Type != attrs[j].Type {
+				return attrs[i].Type < attrs[j].Type
+			}
+			return attrs[i].Value < attrs[j].Value
+		})
+		normalized.RDNs[i] = &RelativeDN{Attributes: attrs}
+	}
+	return normalized
+}
+
+// EqualFold returns true if the DNs are equal according to distinguishedNameMatch with the usual
+// matching rules of their attribute types, e.g. "CN=Alice,DC=Example" and "commonName=alice, dc=example"
+// are equal. See Normalize for details.
+func (d *DN) EqualFold(other *DN) bool {
+	return d.Normalize(nil).Equal(other.Normalize(nil))
+}
+
+// NormalizeDN parses the DN and returns its normalized string representation, see DN.Normalize
+func NormalizeDN(dn string, schema *Schema) (string, error) {
+	parsed, err := ParseDN(dn)
+	if err != nil {
+		return "", err
+	}
+	return parsed.Normalize(schema).String(), nil
+}
+
+func normalizeDNAttributeType(attrType string, schema *Schema) string {
+	key := strings.ToLower(strings.TrimSpace(attrType))
+	// OIDs may be prefixed by "OID." as in https://tools.ietf.org/html/rfc1779
+	key = strings.TrimPrefix(key, "oid.")
+	if schema != nil {
+		if at := schema.AttributeType(key); at != nil {
+			return strings.ToLower(schemaDefinitionName(at.OID, at.Names))
+		}
+	}
+	if alias, ok := dnAttributeTypeAliases[key]; ok {
+		return alias
+	}
+	return key
+}
@@ -2,7 +2,9 @@ package ldap
 
 import (
 	"errors"
+	"fmt"
 	"net"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -97,6 +99,125 @@ func TestGetLDAPErrorSuccess(t *testing.T) {
 	}
 }
 
+// TestGetLDAPErrorReferrals tests parsing of the referral URIs of a referral result.
+func TestGetLDAPErrorReferrals(t *testing.T) {
+	referrals := []string{"ldap://a.example.org/dc=example,dc=org", "ldap://b.example.org/dc=example,dc=org"}
+	modifyResponse := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ApplicationModifyResponse, nil, "Modify Response")
+	modifyResponse.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(LDAPResultReferral), "resultCode"))
+	modifyResponse.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "dc=example,dc=org", "matchedDN"))
+	modifyResponse.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "go elsewhere", "diagnosticMessage"))
+	referral := ber.Encode(ber.ClassContext, ber.TypeConstructed, 3, nil, "Referral")
+	for _, uri := range referrals {
+		referral.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, uri, "URI"))
+	}
+	modifyResponse.AppendChild(referral)
+	packet := ber.NewSequence("LDAPMessage")
+	packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, int64(1), "messageID"))
+	packet.AppendChild(modifyResponse)
+	// Make sure the referrals survive a round-trip on the wire
+	packet = ber.DecodePacket(packet.Bytes())
+
+	err := GetLDAPError(packet)
+	if !IsErrorWithCode(err, LDAPResultReferral) {
+		t.Fatalf("Expected a referral error, got: %v", err)
+	}
+	if !reflect.DeepEqual(err.(*Error).Referrals, referrals) {
+		t.Errorf("Got incorrect referrals in LDAP error; got %v, expected %v", err.(*Error).Referrals, referrals)
+	}
+
+	matchedDN, diagnosticMessage, gotReferrals := getLDAPResult(packet)
+	if matchedDN != "dc=example,dc=org" || diagnosticMessage != "go elsewhere" {
+		t.Errorf("Got incorrect LDAPResult: matchedDN %q, diagnosticMessage %q", matchedDN, diagnosticMessage)
+	}
+	if !reflect.DeepEqual(gotReferrals, referrals) {
+		t.Errorf("Got incorrect referrals in LDAPResult; got %v, expected %v", gotReferrals, referrals)
+	}
+}
+
+// TestGetLDAPErrorADDetails tests parsing of Active Directory diagnostic messages.
+func TestGetLDAPErrorADDetails(t *testing.T) {
+	bindResponse := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ApplicationBindResponse, nil, "Bind Response")
+	bindResponse.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(LDAPResultInvalidCredentials), "resultCode"))
+	bindResponse.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "matchedDN"))
+	bindResponse.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString,
+		"80090308: LdapErr: DSID-0C09044E, comment: AcceptSecurityContext error, data 533, v4563\x00", "diagnosticMessage"))
+	packet := ber.NewSequence("LDAPMessage")
+	packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, int64(1), "messageID"))
+	packet.AppendChild(bindResponse)
+	packet = ber.DecodePacket(packet.Bytes())
+
+	err := GetLDAPError(packet)
+	want := &ADErrorDetails{Win32Error: 0x80090308, Kind: "LdapErr", DSID: "0C09044E", Comment: "AcceptSecurityContext error", Data: 0x533}
+	if ldapErr, ok := err.(*Error); !ok || !reflect.DeepEqual(ldapErr.ADDetails, want) {
+		t.Fatalf("Got incorrect Active Directory details for %v", err)
+	}
+	if !errors.Is(err, ErrADAccountDisabled) || errors.Is(err, ErrADAccountExpired) || errors.Is(err, nil) {
+		t.Errorf("Got incorrect Active Directory error for %v", err)
+	}
+
+	testcases := map[string]*ADErrorDetails{
+		"0000052D: SvcErr: DSID-031A12D2, problem 5003 (WILL_NOT_PERFORM), data 0\n": {Win32Error: 0x52d, Kind: "SvcErr", DSID: "031A12D2", Problem: 5003},
+		"00000056: AtrErr: DSID-03190F80, #1:\n\t0: 00000056: DSID-03190F80, problem 1005 (CONSTRAINT_ATT_TYPE), data 0, Att 9005a (unicodePwd)\n": {
+			Win32Error: 0x56, Kind: "AtrErr", DSID: "03190F80", Problem: 1005},
+		"No Such Object": nil,
+		"":               nil,
+	}
+	for message, want := range testcases {
+		if got := ParseADErrorDetails(message); !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: got %+v, want %+v", message, got, want)
+		}
+	}
+}
+
+// TestErrorIs tests the integration with errors.Is and errors.As.
+func TestErrorIs(t *testing.T) {
+	err := fmt.Errorf("deleting: %w", NewError(LDAPResultNoSuchObject, errors.New("no such entry")))
+	if !errors.Is(err, ErrNoSuchObject) || errors.Is(err, ErrNoSuchAttribute) || errors.Is(err, ErrADUserNotFound) {
+		t.Errorf("Got incorrect errors.Is results for %v", err)
+	}
+	var ldapErr *Error
+	if !errors.As(err, &ldapErr) || ldapErr.ResultCode != LDAPResultNoSuchObject {
+		t.Errorf("Expected to find the LDAP error in %v", err)
+	}
+	if !IsErrorWithCode(err, LDAPResultNoSuchObject) || !IsNotFound(err) || IsTransient(err) || IsAuthFailure(err) {
+		t.Errorf("Got incorrect categories for %v", err)
+	}
+	if s := ErrNoSuchObject.Error(); s != `LDAP Result Code 32 "No Such Object"` {
+		t.Errorf("Got incorrect sentinel error message %q", s)
+	}
+
+	violations := SchemaViolations{{ResultCode: LDAPResultObjectClassViolation, Message: "missing"}}
+	var gotViolations SchemaViolations
+	if err := NewError(LDAPResultObjectClassViolation, violations); !errors.As(err, &gotViolations) || len(gotViolations) != 1 {
+		t.Errorf("Expected to find the schema violations in %v", err)
+	}
+
+	testcases := []struct {
+		err                    error
+		transient, authFailure bool
+	}{
+		{NewError(LDAPResultBusy, errors.New("busy")), true, false},
+		{NewError(ErrorNetwork, errors.New("connection reset")), true, false},
+		{&net.OpError{Op: "read", Err: timeoutError{}}, true, false},
+		{NewError(LDAPResultInvalidCredentials, errors.New("invalid credentials")), false, true},
+		{NewError(ErrorEmptyPassword, errors.New("empty password")), false, true},
+		{NewError(LDAPResultConstraintViolation, errors.New("constraint violation")), false, false},
+		{errors.New("other"), false, false},
+		{nil, false, false},
+	}
+	for _, tc := range testcases {
+		if IsTransient(tc.err) != tc.transient || IsAuthFailure(tc.err) != tc.authFailure || IsNotFound(tc.err) {
+			t.Errorf("Got incorrect categories for %v", tc.err)
+		}
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
 // signalErrConn is a helpful type used with TestConnReadErr. It implements the
 // net.Conn interface to be used as a connection for the test. Most methods are
 // no-ops but the Read() method blocks until it receives a signal which it
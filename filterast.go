@@ -0,0 +1,527 @@
+package ldap
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+)
+
+// Filter is a search filter built with And, Or, Not, Eq, Substr, GE, LE, Present, Approx and Extensible,
+// or parsed with ParseFilter or DecodeFilter. Values are escaped as needed when rendering or encoding
+// a filter, so they must be given unescaped.
+type Filter interface {
+	// String returns the string representation of the filter as defined in https://tools.ietf.org/html/rfc4515
+	String() string
+	// Encode returns the BER encoding of the filter, as returned by CompileFilter for its string representation
+	Encode() *ber.Packet
+	// Matches returns true if the entry matches the filter, evaluated on the client side without a
+	// schema, see FilterEvaluator
+	Matches(entry *Entry) bool
+}
+
+// AndFilter matches entries matched by all of its filters
+type AndFilter struct {
+	Filters []Filter
+}
+
+// OrFilter matches entries matched by any of its filters
+type OrFilter struct {
+	Filters []Filter
+}
+
+// NotFilter matches entries not matched by its filter
+type NotFilter struct {
+	Filter Filter
+}
+
+// EqualityFilter matches entries with an attribute value equal to the given value
+type EqualityFilter struct {
+	Attribute string
+	Value     string
+}
+
+// SubstringsFilter matches entries with an attribute value starting with Initial, containing all of Any
+// in order and ending with Final. Empty parts are ignored, but at least one part should be set.
+type SubstringsFilter struct {
+	Attribute string
+	Initial   string
+	Any       []string
+	Final     string
+}
+
+// GreaterOrEqualFilter matches entries with an attribute value greater than or equal to the given value
+type GreaterOrEqualFilter struct {
+	Attribute string
+	Value     string
+}
+
+// LessOrEqualFilter matches entries with an attribute value less than or equal to the given value
+type LessOrEqualFilter struct {
+	Attribute string
+	Value     string
+}
+
+// PresentFilter matches entries having the attribute
+type PresentFilter struct {
+	Attribute string
+}
+
+// ApproxFilter matches entries with an attribute value approximately equal to the given value
+type ApproxFilter struct {
+	Attribute string
+	Value     string
+}
+
+// ExtensibleFilter matches entries using the given matching rule, see https://tools.ietf.org/html/rfc4511#section-4.5.1.7.7.
+// At least one of MatchingRule and Attribute must be set.
+type ExtensibleFilter struct {
+	MatchingRule string
+	Attribute    string
+	Value        string
+	// DNAttributes also matches against the attributes of the entry's DN
+	DNAttributes bool
+}
+
+// And returns a filter matching entries matched by all of the given filters
+func And(filters ...Filter) *AndFilter {
+	return &AndFilter{Filters: filters}
+}
+
+// Or returns a filter matching entries matched by any of the given filters
+func Or(filters ...Filter) *OrFilter {
+	return &OrFilter{Filters: filters}
+}
+
+// Not returns a filter matching entries not matched by the given filter
+func Not(filter Filter) *NotFilter {
+	return &NotFilter{Filter: filter}
+}
+
+// Eq returns an equality filter for the given attribute and value
+func Eq(attribute, value string) *EqualityFilter {
+	return &EqualityFilter{Attribute: attribute, Value: value}
+}
+
+// Substr returns a substrings filter for the given attribute. Empty parts are ignored, and a filter
+// without parts is encoded as a presence filter like its string representation.
+func Substr(attribute, initial string, any []string, final string) *SubstringsFilter {
+	return &SubstringsFilter{Attribute: attribute, Initial: initial, Any: any, Final: final}
+}
+
+// GE returns a greater-or-equal filter for the given attribute and value
+func GE(attribute, value string) *GreaterOrEqualFilter {
+	return &GreaterOrEqualFilter{Attribute: attribute, Value: value}
+}
+
+// LE returns a less-or-equal filter for the given attribute and value
+func LE(attribute, value string) *LessOrEqualFilter {
+	return &LessOrEqualFilter{Attribute: attribute, Value: value}
+}
+
+// Present returns a presence filter for the given attribute
+func Present(attribute string) *PresentFilter {
+	return &PresentFilter{Attribute: attribute}
+}
+
+// Approx returns an approximate match filter for the given attribute and value
+func Approx(attribute, value string) *ApproxFilter {
+	return &ApproxFilter{Attribute: attribute, Value: value}
+}
+
+// Extensible returns an extensible match filter, e.g. Extensible("1.2.840.113556.1.4.803", "userAccountControl", "2", false)
+func Extensible(matchingRule, attribute, value string, dnAttributes bool) *ExtensibleFilter {
+	return &ExtensibleFilter{MatchingRule: matchingRule, Attribute: attribute, Value: value, DNAttributes: dnAttributes}
+}
+
+func (f *AndFilter) String() string {
+	return filterSetString('&', f.Filters)
+}
+
+// Encode returns the BER encoding of the filter
+func (f *AndFilter) Encode() *ber.Packet {
+	return encodeFilterSet(FilterAnd, f.Filters)
+}
+
+func (f *OrFilter) String() string {
+	return filterSetString('|', f.Filters)
+}
+
+// Encode returns the BER encoding of the filter
+func (f *OrFilter) Encode() *ber.Packet {
+	return encodeFilterSet(FilterOr, f.Filters)
+}
+
+func (f *NotFilter) String() string {
+	return "(!" + f.Filter.String() + ")"
+}
+
+// Encode returns the BER encoding of the filter
+func (f *NotFilter) Encode() *ber.Packet {
+	packet := ber.Encode(ber.ClassContext, ber.TypeConstructed, FilterNot, nil, FilterMap[FilterNot])
+	packet.AppendChild(f.Filter.Encode())
+	return packet
+}
+
+func (f *EqualityFilter) String() string {
+	return "(" + f.Attribute + "=" + EscapeFilter(f.Value) + ")"
+}
+
+// Encode returns the BER encoding of the filter
+func (f *EqualityFilter) Encode() *ber.Packet {
+	return encodeAttributeValueAssertion(FilterEqualityMatch, f.Attribute, f.Value)
+}
+
+// empty returns whether the filter has no parts, matching any value like a presence filter
+func (f *SubstringsFilter) empty() bool {
+	if f.Initial != "" || f.Final != "" {
+		return false
+	}
+	for _, any := range f.Any {
+		if any != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *SubstringsFilter) String() string {
+	var buf strings.Builder
+	buf.WriteString("(" + f.Attribute + "=")
+	buf.WriteString(EscapeFilter(f.Initial))
+	buf.WriteByte('*')
+	for _, any := range f.Any {
+		if any != "" {
+			buf.WriteString(EscapeFilter(any))
+			buf.WriteByte('*')
+		}
+	}
+	buf.WriteString(EscapeFilter(f.Final))
+	buf.WriteByte(')')
+	return buf.String()
+}
+
+// Encode returns the BER encoding of the filter
+func (f *SubstringsFilter) Encode() *ber.Packet {
+	if f.empty() {
+		// a substrings filter must have at least one substring
+		return Present(f.Attribute).Encode()
+	}
+	packet := ber.Encode(ber.ClassContext, ber.TypeConstructed, FilterSubstrings, nil, FilterMap[FilterSubstrings])
+	packet.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, f.Attribute, "Attribute"))
+	seq := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Substrings")
+	appendSubstring := func(tag ber.Tag, value string) {
+		if value != "" {
+			seq.AppendChild(ber.NewString(ber.ClassContext, ber.TypePrimitive, tag, value, FilterSubstringsMap[uint64(tag)]))
+		}
+	}
+	appendSubstring(FilterSubstringsInitial, f.Initial)
+	for _, any := range f.Any {
+		appendSubstring(FilterSubstringsAny, any)
+	}
+	appendSubstring(FilterSubstringsFinal, f.Final)
+	packet.AppendChild(seq)
+	return packet
+}
+
+func (f *GreaterOrEqualFilter) String() string {
+	return "(" + f.Attribute + ">=" + EscapeFilter(f.Value) + ")"
+}
+
+// Encode returns the BER encoding of the filter
+func (f *GreaterOrEqualFilter) Encode() *ber.Packet {
+	return encodeAttributeValueAssertion(FilterGreaterOrEqual, f.Attribute, f.Value)
+}
+
+func (f *LessOrEqualFilter) String() string {
+	return "(" + f.Attribute + "<=" + EscapeFilter(f.Value) + ")"
+}
+
+// Encode returns the BER encoding of the filter
+func (f *LessOrEqualFilter) Encode() *ber.Packet {
+	return encodeAttributeValueAssertion(FilterLessOrEqual, f.Attribute, f.Value)
+}
+
+func (f *PresentFilter) String() string {
+	return "(" + f.Attribute + "=*)"
+}
+
+// Encode returns the BER encoding of the filter
+func (f *PresentFilter) Encode() *ber.Packet {
+	return ber.NewString(ber.ClassContext, ber.TypePrimitive, FilterPresent, f.Attribute, FilterMap[FilterPresent])
+}
+
+func (f *ApproxFilter) String() string {
+	return "(" + f.Attribute + "~=" + EscapeFilter(f.Value) + ")"
+}
+
+// Encode returns the BER encoding of the filter
+func (f *ApproxFilter) Encode() *ber.Packet {
+	return encodeAttributeValueAssertion(FilterApproxMatch, f.Attribute, f.Value)
+}
+
+func (f *ExtensibleFilter) String() string {
+	var buf strings.Builder
+	buf.WriteString("(" + f.Attribute)
+	if f.DNAttributes {
+		buf.WriteString(":dn")
+	}
+	if f.MatchingRule != "" {
+		buf.WriteString(":" + f.MatchingRule)
+	}
+	buf.WriteString(":=" + EscapeFilter(f.Value) + ")")
+	return buf.String()
+}
+
+// Encode returns the BER encoding of the filter
+func (f *ExtensibleFilter) Encode() *ber.Packet {
+	packet := ber.Encode(ber.ClassContext, ber.TypeConstructed, FilterExtensibleMatch, nil, FilterMap[FilterExtensibleMatch])
+	if f.MatchingRule != "" {
+		packet.AppendChild(ber.NewString(ber.ClassContext, ber.TypePrimitive, MatchingRuleAssertionMatchingRule, f.MatchingRule, MatchingRuleAssertionMap[MatchingRuleAssertionMatchingRule]))
+	}
+	if f.Attribute != "" {
+		packet.AppendChild(ber.NewString(ber.ClassContext, ber.TypePrimitive, MatchingRuleAssertionType, f.Attribute, MatchingRuleAssertionMap[MatchingRuleAssertionType]))
+	}
+	packet.AppendChild(ber.NewString(ber.ClassContext, ber.TypePrimitive, MatchingRuleAssertionMatchValue, f.Value, MatchingRuleAssertionMap[MatchingRuleAssertionMatchValue]))
+	if f.DNAttributes {
+		packet.AppendChild(ber.NewBoolean(ber.ClassContext, ber.TypePrimitive, MatchingRuleAssertionDNAttributes, f.DNAttributes, MatchingRuleAssertionMap[MatchingRuleAssertionDNAttributes]))
+	}
+	return packet
+}
+
+func filterSetString(operator byte, filters []Filter) string {
+	var buf strings.Builder
+	buf.WriteByte('(')
+	buf.WriteByte(operator)
+	for _, filter := range filters {
+		buf.WriteString(filter.String())
+	}
+	buf.WriteByte(')')
+	return buf.String()
+}
+
+func encodeFilterSet(tag ber.Tag, filters []Filter) *ber.Packet {
+	packet := ber.Encode(ber.ClassContext, ber.TypeConstructed, tag, nil, FilterMap[uint64(tag)])
+	for _, filter := range filters {
+		packet.AppendChild(filter.Encode())
+	}
+	return packet
+}
+
+func encodeAttributeValueAssertion(tag ber.Tag, attribute, value string) *ber.Packet {
+	packet := ber.Encode(ber.ClassContext, ber.TypeConstructed, tag, nil, FilterMap[uint64(tag)])
+	packet.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, attribute, "Attribute"))
+	packet.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, "Condition"))
+	return packet
+}
+
+// ParseFilter parses the string representation of a filter as accepted by CompileFilter
+func ParseFilter(filter string) (Filter, error) {
+	packet, err := CompileFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeFilter(packet)
+}
+
+// DecodeFilter converts the BER encoding of a filter, as returned by CompileFilter or received by a server,
+// into a Filter
+func DecodeFilter(packet *ber.Packet) (Filter, error) {
+	if packet.ClassType != ber.ClassContext {
+		return nil, NewError(ErrorFilterDecompile, fmt.Errorf("ldap: unexpected class %d of filter", packet.ClassType))
+	}
+
+	switch packet.Tag {
+	case FilterAnd, FilterOr:
+		filters := make([]Filter, 0, len(packet.Children))
+		for _, child := range packet.Children {
+			filter, err := DecodeFilter(child)
+			if err != nil {
+				return nil, err
+			}
+			filters = append(filters, filter)
+		}
+		if packet.Tag == FilterAnd {
+			return And(filters...), nil
+		}
+		return Or(filters...), nil
+	case FilterNot:
+		if len(packet.Children) != 1 {
+			return nil, NewError(ErrorFilterDecompile, errors.New("ldap: not filter must have a single child"))
+		}
+		filter, err := DecodeFilter(packet.Children[0])
+		if err != nil {
+			return nil, err
+		}
+		return Not(filter), nil
+	case FilterEqualityMatch, FilterGreaterOrEqual, FilterLessOrEqual, FilterApproxMatch:
+		if len(packet.Children) != 2 {
+			return nil, NewError(ErrorFilterDecompile, fmt.Errorf("ldap: %s filter must have an attribute and a value", FilterMap[uint64(packet.Tag)]))
+		}
+		attribute, value := filterPacketString(packet.Children[0]), filterPacketString(packet.Children[1])
+		switch packet.Tag {
+		case FilterEqualityMatch:
+			return Eq(attribute, value), nil
+		case FilterGreaterOrEqual:
+			return GE(attribute, value), nil
+		case FilterLessOrEqual:
+			return LE(attribute, value), nil
+		default:
+			return Approx(attribute, value), nil
+		}
+	case FilterSubstrings:
+		if len(packet.Children) != 2 {
+			return nil, NewError(ErrorFilterDecompile, errors.New("ldap: substrings filter must have an attribute and substrings"))
+		}
+		filter := Substr(filterPacketString(packet.Children[0]), "", nil, "")
+		for i, child := range packet.Children[1].Children {
+			switch {
+			case child.Tag == FilterSubstringsInitial && i == 0 && filter.Initial == "":
+				filter.Initial = filterPacketString(child)
+			case child.Tag == FilterSubstringsAny:
+				filter.Any = append(filter.Any, filterPacketString(child))
+			case child.Tag == FilterSubstringsFinal && i == len(packet.Children[1].Children)-1:
+				filter.Final = filterPacketString(child)
+			default:
+				return nil, NewError(ErrorFilterDecompile, fmt.Errorf("ldap: unexpected substring choice %d", child.Tag))
+			}
+		}
+		if filter.empty() {
+			return Present(filter.Attribute), nil
+		}
+		return filter, nil
+	case FilterPresent:
+		return Present(filterPacketString(packet)), nil
+	case FilterExtensibleMatch:
+		filter := &ExtensibleFilter{}
+		for _, child := range packet.Children {
+			switch child.Tag {
+			case MatchingRuleAssertionMatchingRule:
+				filter.MatchingRule = filterPacketString(child)
+			case MatchingRuleAssertionType:
+				filter.Attribute = filterPacketString(child)
+			case MatchingRuleAssertionMatchValue:
+				filter.Value = filterPacketString(child)
+			case MatchingRuleAssertionDNAttributes:
+				// the value is not decoded for context specific tags
+				filter.DNAttributes = child.Data.Len() > 0 && child.Data.Bytes()[0] != 0
+			}
+		}
+		return filter, nil
+	}
+	return nil, NewError(ErrorFilterDecompile, fmt.Errorf("ldap: unknown filter choice %d", packet.Tag))
+}
+
+func filterPacketString(packet *ber.Packet) string {
+	return ber.DecodeString(packet.Data.Bytes())
+}
+
+// NormalizeFilter returns the canonical string representation of a filter, so that equivalent filters,
+// e.g. "(&(CN=a)(sn=\62))" and "(&(sn=b)(&(cn=a)))", produce identical strings. See CanonicalFilter.
+func NormalizeFilter(filter string) (string, error) {
+	f, err := ParseFilter(filter)
+	if err != nil {
+		return "", err
+	}
+	return CanonicalFilter(f).String(), nil
+}
+
+// CanonicalFilter returns a simplified copy of the filter, with nested AND and OR filters flattened,
+// duplicate and redundant children removed, children sorted by their string representation, double
+// negations removed and attribute descriptions and matching rules in lowercase. Values are kept as is,
+// as their matching rules are unknown, and are escaped canonically by String.
+func CanonicalFilter(filter Filter) Filter {
+	switch f := filter.(type) {
+	case *AndFilter:
+		return canonicalFilterSet(FilterAnd, f.Filters)
+	case *OrFilter:
+		return canonicalFilterSet(FilterOr, f.Filters)
+	case *NotFilter:
+		child := CanonicalFilter(f.Filter)
+		if not, ok := child.(*NotFilter); ok {
+			return not.Filter
+		}
+		return Not(child)
+	case *EqualityFilter:
+		return Eq(strings.ToLower(f.Attribute), f.Value)
+	case *SubstringsFilter:
+		var any []string
+		for _, part := range f.Any {
+			if part != "" {
+				any = append(any, part)
+			}
+		}
+		if f.empty() {
+			return Present(strings.ToLower(f.Attribute))
+		}
+		return Substr(strings.ToLower(f.Attribute), f.Initial, any, f.Final)
+	case *GreaterOrEqualFilter:
+		return GE(strings.ToLower(f.Attribute), f.Value)
+	case *LessOrEqualFilter:
+		return LE(strings.ToLower(f.Attribute), f.Value)
+	case *PresentFilter:
+		return Present(strings.ToLower(f.Attribute))
+	case *ApproxFilter:
+		return Approx(strings.ToLower(f.Attribute), f.Value)
+	case *ExtensibleFilter:
+		return Extensible(strings.ToLower(f.MatchingRule), strings.ToLower(f.Attribute), f.Value, f.DNAttributes)
+	}
+	return filter
+}
+
+// canonicalFilterSet returns the canonical form of an AND or OR filter. An empty AND filter is always
+// true and an empty OR filter always false (see https://tools.ietf.org/html/rfc4526), so they are
+// dropped from filters of the same kind and decide filters of the other kind.
+func canonicalFilterSet(tag ber.Tag, filters []Filter) Filter {
+	var children []Filter
+	seen := make(map[string]bool)
+	var add func(filter Filter) bool
+	add = func(filter Filter) bool {
+		var set []Filter
+		isSet, setTag := false, tag
+		switch f := filter.(type) {
+		case *AndFilter:
+			set, isSet, setTag = f.Filters, true, FilterAnd
+		case *OrFilter:
+			set, isSet, setTag = f.Filters, true, FilterOr
+		}
+		if isSet && setTag == tag {
+			for _, child := range set {
+				if !add(child) {
+					return false
+				}
+			}
+			return true
+		}
+		if isSet && len(set) == 0 {
+			return false
+		}
+		if s := filter.String(); !seen[s] {
+			seen[s] = true
+			children = append(children, filter)
+		}
+		return true
+	}
+
+	for _, filter := range filters {
+		if !add(CanonicalFilter(filter)) {
+			// the whole filter is decided by an absolute true or false child
+			if tag == FilterAnd {
+				return Or()
+			}
+			return And()
+		}
+	}
+	if len(children) == 1 {
+		return children[0]
+	}
+	sort.Slice(children, func(i, j int) bool {
+		return children[i].String() < children[j].String()
+	})
+	if tag == FilterAnd {
+		return And(children...)
+	}
+	return Or(children...)
+}
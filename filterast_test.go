@@ -0,0 +1,132 @@
+package ldap
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+)
+
+func TestParseFilter(t *testing.T) {
+	for _, i := range testFilters {
+		if i.expectedErr != "" {
+			continue
+		}
+		compiled, err := CompileFilter(i.filterStr)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", i.filterStr, err)
+			continue
+		}
+
+		filter, err := ParseFilter(i.filterStr)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", i.filterStr, err)
+			continue
+		}
+		if s := filter.String(); s != i.expectedFilter {
+			t.Errorf("%q: got %q, want %q", i.filterStr, s, i.expectedFilter)
+		}
+		if !bytes.Equal(filter.Encode().Bytes(), compiled.Bytes()) {
+			t.Errorf("%q: encoding differs from the compiled filter", i.filterStr)
+		}
+
+		// filters received on the wire are decoded the same way
+		received, err := DecodeFilter(ber.DecodePacket(compiled.Bytes()))
+		if err != nil {
+			t.Errorf("%q: unexpected error decoding: %s", i.filterStr, err)
+		} else if !reflect.DeepEqual(received, filter) {
+			t.Errorf("%q: got %#v from the wire, want %#v", i.filterStr, received, filter)
+		}
+	}
+
+	// substrings filters without substrings are presence filters
+	if filter, err := ParseFilter("(cn=**)"); err != nil || !reflect.DeepEqual(filter, Present("cn")) {
+		t.Errorf("unexpected filter %#v (%v)", filter, err)
+	}
+
+	if _, err := ParseFilter("(cn=foo"); err == nil {
+		t.Error("expected an error for an invalid filter")
+	}
+	if _, err := DecodeFilter(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "cn", "")); err == nil {
+		t.Error("expected an error for an invalid filter packet")
+	}
+}
+
+func TestFilterBuilder(t *testing.T) {
+	testcases := []struct {
+		filter Filter
+		want   string
+	}{
+		{And(Eq("objectClass", "person"), Or(Eq("cn", "a*b"), Eq("cn", `(c)\`)), Not(Present("mail"))),
+			`(&(objectClass=person)(|(cn=a\2ab)(cn=\28c\29\5c))(!(mail=*)))`},
+		{Substr("cn", "Jo", []string{"n", ""}, "s*"), `(cn=Jo*n*s\2a)`},
+		{Substr("cn", "", nil, "son"), `(cn=*son)`},
+		{Substr("cn", "", []string{""}, ""), `(cn=*)`},
+		{GE("uidNumber", "1000"), `(uidNumber>=1000)`},
+		{LE("modifyTimestamp", "20201231235959Z"), `(modifyTimestamp<=20201231235959Z)`},
+		{Approx("sn", "Müller"), `(sn~=M\c3\bcller)`},
+		{Extensible("1.2.840.113556.1.4.803", "userAccountControl", "2", false), `(userAccountControl:1.2.840.113556.1.4.803:=2)`},
+		{Extensible("", "ou", "People", true), `(ou:dn:=People)`},
+		{Extensible("caseExactMatch", "", "x", true), `(:dn:caseExactMatch:=x)`},
+	}
+	for _, tc := range testcases {
+		if s := tc.filter.String(); s != tc.want {
+			t.Errorf("got %q, want %q", s, tc.want)
+			continue
+		}
+		compiled, err := CompileFilter(tc.want)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", tc.want, err)
+			continue
+		}
+		if !bytes.Equal(tc.filter.Encode().Bytes(), compiled.Bytes()) {
+			t.Errorf("%q: encoding differs from the compiled filter", tc.want)
+		}
+		parsed, err := ParseFilter(tc.want)
+		if err != nil || parsed.String() != tc.want {
+			t.Errorf("%q: unexpected parsed filter %v (%v)", tc.want, parsed, err)
+		}
+	}
+}
+
+func TestNormalizeFilter(t *testing.T) {
+	testcases := []struct {
+		filter string
+		want   string
+	}{
+		{`(CN=Foo)`, `(cn=Foo)`},
+		{`(cn=\46\6f\6F)`, `(cn=Foo)`},
+		{`(cn=\2A\28)`, `(cn=\2a\28)`},
+		{`(&(sn=b)(CN=a))`, `(&(cn=a)(sn=b))`},
+		{`(&(cn=a)(&(sn=b)(|(uid=c)(uid=d))))`, `(&(cn=a)(sn=b)(|(uid=c)(uid=d)))`},
+		{`(|(uid=d)(|(uid=c)(UID=d)))`, `(|(uid=c)(uid=d))`},
+		{`(&(cn=a)(CN=a))`, `(cn=a)`},
+		{`(!(!(cn=a)))`, `(cn=a)`},
+		{`(!(&(cn=a)))`, `(!(cn=a))`},
+		{`(&(cn=a)(&))`, `(cn=a)`},
+		{`(&(cn=a)(|))`, `(|)`},
+		{`(|(cn=a)(&))`, `(&)`},
+		{`(&(cn=a)(|(sn=b)(&)))`, `(cn=a)`},
+		{`(CN;Lang-EN=Foo*Bar)`, `(cn;lang-en=Foo*Bar)`},
+		{`(userAccountControl:1.2.840.113556.1.4.803:=2)`, `(useraccountcontrol:1.2.840.113556.1.4.803:=2)`},
+		{`(OU:dn:caseExactMatch:=People)`, `(ou:dn:caseexactmatch:=People)`},
+	}
+	for _, tc := range testcases {
+		got, err := NormalizeFilter(tc.filter)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", tc.filter, err)
+		} else if got != tc.want {
+			t.Errorf("%q: got %q, want %q", tc.filter, got, tc.want)
+		}
+	}
+
+	a, _ := NormalizeFilter(`(&(objectClass=person)(|(mail=*)(uid=j*))(!(cn=x)))`)
+	b, _ := NormalizeFilter(`(&(!(CN=x))(&(|(UID=j*)(mail=*))(objectclass=person)))`)
+	if a != b {
+		t.Errorf("equivalent filters normalized differently: %q and %q", a, b)
+	}
+	if _, err := NormalizeFilter("(cn=a"); err == nil {
+		t.Error("expected an error for an invalid filter")
+	}
+}
@@ -0,0 +1,214 @@
+package ldap
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+)
+
+// LogLevel is the severity of a log record. Its values are those of the corresponding log/slog levels.
+type LogLevel int
+
+// Log levels
+const (
+	LevelDebug LogLevel = -4
+	LevelInfo  LogLevel = 0
+	LevelWarn  LogLevel = 4
+	LevelError LogLevel = 8
+)
+
+// String returns the name of the level, e.g. "DEBUG"
+func (level LogLevel) String() string {
+	switch {
+	case level < LevelInfo:
+		return "DEBUG"
+	case level < LevelWarn:
+		return "INFO"
+	case level < LevelError:
+		return "WARN"
+	default:
+		return "ERROR"
+	}
+}
+
+// Keys of the fields of the log records of a Conn
+const (
+	// LogKeyMessageID is the key of the message ID of the request a record is about
+	LogKeyMessageID = "messageID"
+	// LogKeyOperation is the key of the name of the operation a record is about, e.g. "Search Request"
+	LogKeyOperation = "operation"
+	// LogKeyDuration is the key of the time.Duration since the request was sent
+	LogKeyDuration = "duration"
+	// LogKeyResultCode is the key of the result code of a completed request, see Observer.RequestFinished
+	LogKeyResultCode = "resultCode"
+	// LogKeyError is the key of the error a record reports
+	LogKeyError = "error"
+	// LogKeyPacket is the key of a packet dump, a fmt.Stringer only formatted when the record is logged
+	LogKeyPacket = "packet"
+)
+
+// Logger receives the log records of a Conn. Each method takes a message followed by alternating keys
+// and values, as the methods of the same name of log/slog.Logger, so that a *slog.Logger is a Logger.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+// StdLogger is a Logger writing the records at or above its level to a *log.Logger, formatted as the
+// level and the message followed by key=value pairs
+type StdLogger struct {
+	// Logger receives the records, the standard logger if nil
+	Logger *log.Logger
+	// Level is the minimum level of the records written
+	Level LogLevel
+}
+
+// NewStdLogger returns a StdLogger writing the records at or above the given level to the given
+// logger, or to the standard logger if nil
+func NewStdLogger(logger *log.Logger, level LogLevel) *StdLogger {
+	return &StdLogger{Logger: logger, Level: level}
+}
+
+// Debug writes a debug record
+func (s *StdLogger) Debug(msg string, args ...interface{}) {
+	s.log(LevelDebug, msg, args)
+}
+
+// Info writes an info record
+func (s *StdLogger) Info(msg string, args ...interface{}) {
+	s.log(LevelInfo, msg, args)
+}
+
+// Warn writes a warning record
+func (s *StdLogger) Warn(msg string, args ...interface{}) {
+	s.log(LevelWarn, msg, args)
+}
+
+// Error writes an error record
+func (s *StdLogger) Error(msg string, args ...interface{}) {
+	s.log(LevelError, msg, args)
+}
+
+func (s *StdLogger) log(level LogLevel, msg string, args []interface{}) {
+	if level < s.Level {
+		return
+	}
+	record := formatLogRecord(level, msg, args)
+	if s.Logger == nil {
+		log.Print(record)
+	} else {
+		s.Logger.Print(record)
+	}
+}
+
+// formatLogRecord formats a record as the level and the message followed by key=value pairs, writing
+// multi-line values such as packet dumps on their own lines
+func formatLogRecord(level LogLevel, msg string, args []interface{}) string {
+	var b strings.Builder
+	b.WriteString(level.String())
+	b.WriteByte(' ')
+	b.WriteString(msg)
+	for i := 0; i < len(args); i += 2 {
+		key, value := fmt.Sprint(args[i]), "!MISSING"
+		if i+1 < len(args) {
+			value = fmt.Sprint(args[i+1])
+		}
+		if strings.Contains(value, "\n") {
+			fmt.Fprintf(&b, " %s=\n%s", key, strings.TrimSuffix(value, "\n"))
+		} else if strings.ContainsAny(value, " \"=") || value == "" {
+			fmt.Fprintf(&b, " %s=%q", key, value)
+		} else {
+			fmt.Fprintf(&b, " %s=%s", key, value)
+		}
+	}
+	return b.String()
+}
+
+// loggerHolder holds the Logger of a Conn in an atomic.Value, which requires values of the same type
+type loggerHolder struct {
+	logger Logger
+}
+
+// SetLogger sets the Logger receiving the log records of the connection. By default, or if logger is
+// nil, warnings and errors are written to the standard logger, and debug records only if Debug is
+// enabled. Packet dumps are logged as debug records with their credentials redacted, see SetRedactor.
+func (l *Conn) SetLogger(logger Logger) {
+	l.logger.Store(loggerHolder{logger})
+}
+
+func (l *Conn) getLogger() Logger {
+	holder, _ := l.logger.Load().(loggerHolder)
+	return holder.logger
+}
+
+// log sends a record to the Logger of the connection
+func (l *Conn) log(level LogLevel, msg string, args ...interface{}) {
+	logger := l.getLogger()
+	if logger == nil {
+		if level < LevelWarn && !l.Debug {
+			return
+		}
+		log.Print(formatLogRecord(level, msg, args))
+		return
+	}
+	switch {
+	case level < LevelInfo:
+		logger.Debug(msg, args...)
+	case level < LevelWarn:
+		logger.Info(msg, args...)
+	case level < LevelError:
+		logger.Warn(msg, args...)
+	default:
+		logger.Error(msg, args...)
+	}
+}
+
+// logPacket logs a debug record with a dump of the packet
+func (l *Conn) logPacket(msg string, packet *ber.Packet, args ...interface{}) {
+	if l.getLogger() == nil && !l.Debug {
+		return
+	}
+	l.log(LevelDebug, msg, append(args, LogKeyPacket, packetDump{packet, l.redactor})...)
+}
+
+// logMessage returns the fields identifying the message of the given context
+func logMessage(msgCtx *messageContext) []interface{} {
+	return []interface{}{LogKeyMessageID, msgCtx.id, LogKeyOperation, msgCtx.request.Operation}
+}
+
+// operationName returns the name of the protocol op of the given LDAP message, e.g. "Search Request"
+func operationName(packet *ber.Packet) string {
+	if len(packet.Children) < 2 || packet.Children[1].ClassType != ber.ClassApplication {
+		return ""
+	}
+	return ApplicationMap[uint8(packet.Children[1].Tag)]
+}
+
+// durationSince returns the time elapsed since the given time, rounded for logging
+func durationSince(t time.Time) time.Duration {
+	return time.Since(t).Round(time.Microsecond)
+}
+
+// packetDump formats a packet as ber.PrintPacket with its credentials redacted, when logged as a
+// string or as text, e.g. by JSON handlers
+type packetDump struct {
+	packet   *ber.Packet
+	redactor *Redactor
+}
+
+func (d packetDump) String() string {
+	var buf bytes.Buffer
+	ber.WritePacket(&buf, d.redactor.Redact(d.packet))
+	return buf.String()
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (d packetDump) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
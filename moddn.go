@@ -1,8 +1,6 @@
 package ldap
 
 import (
-	"log"
-
 	ber "github.com/go-asn1-ber/asn1-ber"
 )
 
@@ -35,6 +33,26 @@ func NewModifyDNRequest(dn string, rdn string, delOld bool, newSup string) *Modi
 	}
 }
 
+// NewModifyDNRequestFromDN creates a new request renaming or moving the entry with the given DN to newDN.
+// The new superior is only set if the parent entry changes.
+//
+// A call like
+//   mdnReq := NewModifyDNRequestFromDN(dn, dn.Parent().Child(rdn), true)
+// will setup the request to just rename the entry.
+func NewModifyDNRequestFromDN(dn *DN, newDN *DN, delOld bool) *ModifyDNRequest {
+	req := &ModifyDNRequest{
+		DN:           dn.String(),
+		DeleteOldRDN: delOld,
+	}
+	if rdn := newDN.RDN(); rdn != nil {
+		req.NewRDN = rdn.String()
+	}
+	if parent, newParent := dn.Parent(), newDN.Parent(); newParent != nil && (parent == nil || !parent.Equal(newParent)) {
+		req.NewSuperior = newParent.String()
+	}
+	return req
+}
+
 func (req *ModifyDNRequest) appendTo(envelope *ber.Packet) error {
 	pkt := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ApplicationModifyDNRequest, nil, "Modify DN Request")
 	pkt.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, req.DN, "DN"))
@@ -49,27 +67,60 @@ func (req *ModifyDNRequest) appendTo(envelope *ber.Packet) error {
 	return nil
 }
 
+// ModifyDNResult holds the server's response to a modify DN request
+type ModifyDNResult struct {
+	// MatchedDN is the matchedDN returned by the server, if any
+	MatchedDN string
+	// DiagnosticMessage is the diagnostic message returned by the server, if any
+	DiagnosticMessage string
+	// Referrals are the referral URIs returned with LDAPResultReferral, if any
+	Referrals []string
+	// Controls are the returned controls
+	Controls []Control
+}
+
 // ModifyDN renames the given DN and optionally move to another base (when the "newSup" argument
 // to NewModifyDNRequest() is not "").
 func (l *Conn) ModifyDN(m *ModifyDNRequest) error {
+	_, err := l.ModifyDNWithResult(m)
+	return err
+}
+
+// ModifyDNWithResult performs the ModifyDNRequest and returns the result
+func (l *Conn) ModifyDNWithResult(m *ModifyDNRequest) (*ModifyDNResult, error) {
+	result, err := l.modifyDN(m)
+	if l.chasesReferral(err) {
+		err = l.chaseReferrals(err, 0, map[string]bool{}, func(conn *Conn, u *LDAPURL) error {
+			referred := *m
+			referred.DN = referredDN(m.DN, u)
+			result, err = conn.ModifyDNWithResult(&referred)
+			return err
+		})
+	}
+	return result, err
+}
+
+func (l *Conn) modifyDN(m *ModifyDNRequest) (*ModifyDNResult, error) {
 	msgCtx, err := l.doRequest(m)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer l.finishMessage(msgCtx)
 
 	packet, err := l.readPacket(msgCtx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	result := &ModifyDNResult{}
 	if packet.Children[1].Tag == ApplicationModifyDNResponse {
-		err := GetLDAPError(packet)
+		result.MatchedDN, result.DiagnosticMessage, result.Referrals = getLDAPResult(packet)
+		result.Controls, err = decodeResultControls(packet)
 		if err != nil {
-			return err
+			return result, err
 		}
 	} else {
-		log.Printf("Unexpected Response: %d", packet.Children[1].Tag)
+		l.log(LevelWarn, "unexpected response", LogKeyMessageID, msgCtx.id, LogKeyOperation, operationName(packet))
 	}
-	return nil
+	return result, nil
 }
@@ -0,0 +1,280 @@
+package ldap
+
+import (
+	"bytes"
+	"strings"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+)
+
+// DefaultSensitiveAttributes are the attributes whose values are redacted from packet dumps when
+// Redactor.SensitiveAttributes is nil
+var DefaultSensitiveAttributes = []string{
+	"userPassword", "authPassword", "unicodePwd", "clearTextPassword", "sambaNTPassword", "sambaLMPassword",
+	"ntPwdHistory", "lmPwdHistory", "dBCSPwd", "supplementalCredentials", "krbPrincipalKey", "userPKCS12",
+}
+
+// sensitiveExtendedOperations are the OIDs of the extended operations whose request values are redacted
+var sensitiveExtendedOperations = map[string]bool{
+	passwordModifyOID: true,
+}
+
+// redactedValue replaces the values of redacted packets
+const redactedValue = "*****"
+
+// Redactor masks credentials in the LDAP messages dumped for debugging, i.e. logged by a Conn, see
+// Conn.SetRedactor, and printed by DebugBinaryFile and debugging.PrintPacket. Redacted values are
+// replaced by "*****".
+type Redactor struct {
+	// SensitiveAttributes are the attributes whose values are masked in add, modify and compare
+	// requests, search filters and search result entries, DefaultSensitiveAttributes if nil. Names
+	// are compared case-insensitively and without attribute options.
+	SensitiveAttributes []string
+	// Disabled dumps messages without masking anything, which must only be used for local debugging
+	Disabled bool
+}
+
+// DefaultRedactor is used when no Redactor is set
+var DefaultRedactor = &Redactor{}
+
+// Redact returns a copy of the given LDAP message with its credentials masked, or the message itself
+// if there is nothing to mask. It masks:
+//   - simple bind passwords, NTLM messages and SASL credentials, sent by the client or the server
+//   - the values of password modify requests and responses
+//   - the values of the sensitive attributes
+//
+// The message itself is never modified and the copy shares its unmasked packets.
+func (r *Redactor) Redact(packet *ber.Packet) *ber.Packet {
+	if r == nil {
+		r = DefaultRedactor
+	}
+	if r.Disabled || len(packet.Children) < 2 || packet.Children[1].ClassType != ber.ClassApplication {
+		return packet
+	}
+
+	op := packet.Children[1]
+	var redacted *ber.Packet
+	switch op.Tag {
+	case ApplicationBindRequest:
+		redacted = redactChild(op, 2, redactBindAuthentication)
+	case ApplicationBindResponse:
+		redacted = mapChildren(op, func(child *ber.Packet) *ber.Packet {
+			// serverSaslCreds
+			if child.ClassType == ber.ClassContext && child.Tag == 7 {
+				return maskPacket(child)
+			}
+			return nil
+		})
+	case ApplicationExtendedRequest:
+		if len(op.Children) > 1 && sensitiveExtendedOperations[op.Children[0].Data.String()] {
+			redacted = redactChild(op, 1, maskPacket)
+		}
+	case ApplicationExtendedResponse:
+		redacted = redactExtendedResponse(op)
+	case ApplicationAddRequest, ApplicationSearchResultEntry:
+		redacted = redactChild(op, 1, func(attributes *ber.Packet) *ber.Packet {
+			return mapChildren(attributes, r.redactAttribute)
+		})
+	case ApplicationModifyRequest:
+		redacted = redactChild(op, 1, func(changes *ber.Packet) *ber.Packet {
+			return mapChildren(changes, func(change *ber.Packet) *ber.Packet {
+				return redactChild(change, 1, r.redactAttribute)
+			})
+		})
+	case ApplicationCompareRequest:
+		redacted = redactChild(op, 1, r.redactAttributeValueAssertion)
+	case ApplicationSearchRequest:
+		redacted = redactChild(op, 6, r.redactFilter)
+	}
+	if redacted == nil {
+		return packet
+	}
+	return replaceChild(packet, 1, redacted)
+}
+
+// sensitive returns true if the values of the attribute with the given description must be masked
+func (r *Redactor) sensitive(description *ber.Packet) bool {
+	attributes := r.SensitiveAttributes
+	if attributes == nil {
+		attributes = DefaultSensitiveAttributes
+	}
+	name := attributeDescriptionType(description.Data.String())
+	for _, attribute := range attributes {
+		if strings.EqualFold(attribute, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactAttribute masks the values of a sensitive attribute, i.e. SEQUENCE { type, SET OF value }
+func (r *Redactor) redactAttribute(attribute *ber.Packet) *ber.Packet {
+	if len(attribute.Children) < 2 || !r.sensitive(attribute.Children[0]) {
+		return nil
+	}
+	return redactChild(attribute, 1, func(values *ber.Packet) *ber.Packet {
+		return mapChildren(values, maskPacket)
+	})
+}
+
+// redactAttributeValueAssertion masks the value of an assertion on a sensitive attribute
+func (r *Redactor) redactAttributeValueAssertion(ava *ber.Packet) *ber.Packet {
+	if len(ava.Children) < 2 || !r.sensitive(ava.Children[0]) {
+		return nil
+	}
+	return redactChild(ava, 1, maskPacket)
+}
+
+// redactFilter masks the assertion values of a search filter on sensitive attributes
+func (r *Redactor) redactFilter(filter *ber.Packet) *ber.Packet {
+	if filter.ClassType != ber.ClassContext {
+		return nil
+	}
+	switch filter.Tag {
+	case FilterAnd, FilterOr, FilterNot:
+		return mapChildren(filter, r.redactFilter)
+	case FilterEqualityMatch, FilterGreaterOrEqual, FilterLessOrEqual, FilterApproxMatch:
+		return r.redactAttributeValueAssertion(filter)
+	case FilterSubstrings:
+		if len(filter.Children) < 2 || !r.sensitive(filter.Children[0]) {
+			return nil
+		}
+		return redactChild(filter, 1, func(substrings *ber.Packet) *ber.Packet {
+			return mapChildren(substrings, maskPacket)
+		})
+	case FilterExtensibleMatch:
+		sensitive := false
+		for _, child := range filter.Children {
+			if child.Tag == 2 && r.sensitive(child) {
+				sensitive = true
+			}
+		}
+		if !sensitive {
+			return nil
+		}
+		return mapChildren(filter, func(child *ber.Packet) *ber.Packet {
+			if child.Tag == 3 {
+				return maskPacket(child)
+			}
+			return nil
+		})
+	}
+	return nil
+}
+
+// redactBindAuthentication masks the credentials of a bind request, keeping the SASL mechanism
+func redactBindAuthentication(auth *ber.Packet) *ber.Packet {
+	if auth.ClassType == ber.ClassContext && auth.Tag == 3 {
+		return mapChildren(auth, func(child *ber.Packet) *ber.Packet {
+			if child.Tag == ber.TagOctetString && child.ClassType == ber.ClassUniversal && child != auth.Children[0] {
+				return maskPacket(child)
+			}
+			return nil
+		})
+	}
+	if auth.Data.Len() == 0 {
+		// unauthenticated bind
+		return nil
+	}
+	return maskPacket(auth)
+}
+
+// redactExtendedResponse masks the value of a password modify response, which is identified by its
+// name or, as servers usually omit it, by the generated password its value holds
+func redactExtendedResponse(op *ber.Packet) *ber.Packet {
+	var name string
+	for _, child := range op.Children {
+		if child.ClassType == ber.ClassContext && child.Tag == 10 {
+			name = child.Data.String()
+		}
+	}
+	return mapChildren(op, func(child *ber.Packet) *ber.Packet {
+		if child.ClassType != ber.ClassContext || child.Tag != 11 {
+			return nil
+		}
+		if sensitiveExtendedOperations[name] || isPasswordModifyResponseValue(child.Data.Bytes()) {
+			return maskPacket(child)
+		}
+		return nil
+	})
+}
+
+// isPasswordModifyResponseValue returns true if the value is a PasswdModifyResponseValue, i.e.
+// SEQUENCE { genPasswd [0] OCTET STRING OPTIONAL }, with a generated password
+func isPasswordModifyResponseValue(value []byte) bool {
+	if len(value) == 0 || value[0] != 0x30 {
+		return false
+	}
+	packet, err := ber.DecodePacketErr(value)
+	if err != nil || len(packet.Children) != 1 {
+		return false
+	}
+	return packet.Children[0].ClassType == ber.ClassContext && packet.Children[0].Tag == 0
+}
+
+// redactChild returns a copy of the packet with its i-th child redacted by redact, or nil if the
+// packet has no such child or redact returns nil
+func redactChild(packet *ber.Packet, i int, redact func(*ber.Packet) *ber.Packet) *ber.Packet {
+	if len(packet.Children) <= i {
+		return nil
+	}
+	child := redact(packet.Children[i])
+	if child == nil {
+		return nil
+	}
+	return replaceChild(packet, i, child)
+}
+
+// mapChildren returns a copy of the packet with its children redacted by redact, or nil if redact
+// returns nil for all of them
+func mapChildren(packet *ber.Packet, redact func(*ber.Packet) *ber.Packet) *ber.Packet {
+	var children []*ber.Packet
+	for i, child := range packet.Children {
+		if redacted := redact(child); redacted != nil {
+			if children == nil {
+				children = append([]*ber.Packet(nil), packet.Children...)
+			}
+			children[i] = redacted
+		}
+	}
+	if children == nil {
+		return nil
+	}
+	return withChildren(packet, children)
+}
+
+// replaceChild returns a copy of the packet with its i-th child replaced
+func replaceChild(packet *ber.Packet, i int, child *ber.Packet) *ber.Packet {
+	children := append([]*ber.Packet(nil), packet.Children...)
+	children[i] = child
+	return withChildren(packet, children)
+}
+
+// withChildren returns a copy of the packet with the given children, encoding them as its data
+func withChildren(packet *ber.Packet, children []*ber.Packet) *ber.Packet {
+	copied := *packet
+	copied.Children = children
+	copied.Data = new(bytes.Buffer)
+	for _, child := range children {
+		copied.Data.Write(child.Bytes())
+	}
+	return &copied
+}
+
+// maskPacket returns a copy of the packet with its value replaced by "*****" and without data
+func maskPacket(packet *ber.Packet) *ber.Packet {
+	masked := *packet
+	masked.Value = redactedValue
+	masked.Data = new(bytes.Buffer)
+	masked.Children = nil
+	return &masked
+}
+
+// SetRedactor sets the Redactor masking credentials in the packets logged by the connection, see
+// SetLogger. When nil, which is the default, DefaultRedactor is used. Set a Redactor with Disabled
+// to log packets unmasked for local debugging.
+//
+// SetRedactor must not be called concurrently with operations on the connection.
+func (l *Conn) SetRedactor(redactor *Redactor) {
+	l.redactor = redactor
+}
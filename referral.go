@@ -0,0 +1,222 @@
+package ldap
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DefaultReferralHopLimit is the number of referrals followed in a row when
+// ReferralOptions.HopLimit is not set
+const DefaultReferralHopLimit = 10
+
+// ReferralOptions configures the automatic chasing of referrals, see Conn.SetReferralOptions
+type ReferralOptions struct {
+	// HopLimit is the maximum number of referrals followed in a row for a single
+	// operation. When zero, DefaultReferralHopLimit is used.
+	HopLimit int
+	// Dial returns a connection to the server named in the given referral URL. The
+	// connection is only used for the referred operation and is closed afterwards.
+	// When nil, the server is dialed with DialURL and bound using Bind, and referrals without
+	// host, e.g. "ldap:///dc=example,dc=org", are rejected as they would target the local host.
+	Dial func(referral string) (*Conn, error)
+	// Bind optionally authenticates a connection dialed for the given referral URL
+	// when Dial is nil. Without it, referred operations are performed anonymously.
+	Bind func(conn *Conn, referral string) error
+}
+
+// SetReferralOptions enables the automatic chasing of referrals returned by Search, Add, Del,
+// Modify and ModifyDN (and their WithResult variants) with the given options. Passing nil
+// disables referral chasing, which is the default.
+//
+// When a server answers with LDAPResultReferral the operation is re-issued against the
+// first of the referred servers that can be reached, using the DN of the referral URL
+// if present. Search continuation references are searched with the base DN, scope
+// and filter of their URL (falling back to the original request) and the entries
+// found are merged into the result. Continuation references that cannot be followed
+// are left in SearchResult.Referrals.
+//
+// Following more than HopLimit referrals in a row results in an error with
+// LDAPResultReferralLimitExceeded, referring back to an already visited URL results
+// in an error with LDAPResultClientLoop.
+//
+// SetReferralOptions must not be called concurrently with operations on the connection.
+func (l *Conn) SetReferralOptions(opts *ReferralOptions) {
+	l.referralOptions = opts
+}
+
+func (l *Conn) referralHopLimit() int {
+	if l.referralOptions.HopLimit > 0 {
+		return l.referralOptions.HopLimit
+	}
+	return DefaultReferralHopLimit
+}
+
+// chasesReferral returns whether the given error is a referral which should be followed
+func (l *Conn) chasesReferral(err error) bool {
+	return l.referralOptions != nil && IsErrorWithCode(err, LDAPResultReferral)
+}
+
+// dialReferral returns a connection to the server named in the referral
+func (l *Conn) dialReferral(referral string, u *LDAPURL) (*Conn, error) {
+	opts := l.referralOptions
+	if opts.Dial != nil {
+		conn, err := opts.Dial(referral)
+		if err != nil {
+			return nil, err
+		}
+		// hops are counted by the originating connection
+		conn.referralOptions = nil
+		return conn, nil
+	}
+
+	if u.Host == "" && u.Scheme != "ldapi" {
+		return nil, NewError(LDAPResultParamError, fmt.Errorf("ldap: referral %s has no host", referral))
+	}
+	conn, err := DialURL(u.Address())
+	if err != nil {
+		return nil, err
+	}
+	conn.Debug = l.Debug
+	conn.SetLogger(l.getLogger())
+	if opts.Bind != nil {
+		if err := opts.Bind(conn, referral); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// followReferral performs the referred operation with do against the first server of
+// the referral URLs that can be reached
+func (l *Conn) followReferral(referrals []string, hops int, visited map[string]bool, do func(conn *Conn, u *LDAPURL) error) error {
+	if hops >= l.referralHopLimit() {
+		return NewError(LDAPResultReferralLimitExceeded, fmt.Errorf("ldap: referral hop limit of %d exceeded", l.referralHopLimit()))
+	}
+
+	err := NewError(LDAPResultReferral, errors.New("ldap: no referral to follow"))
+	for _, referral := range referrals {
+		u, parseErr := ParseLDAPURL(referral)
+		if parseErr != nil {
+			l.log(LevelDebug, "ignoring referral", "referral", referral, LogKeyError, parseErr)
+			err = NewError(LDAPResultParamError, parseErr)
+			continue
+		}
+		if visited[u.key()] {
+			err = NewError(LDAPResultClientLoop, fmt.Errorf("ldap: referral loop detected at %s", referral))
+			continue
+		}
+		visited[u.key()] = true
+
+		l.log(LevelDebug, "following referral", "referral", referral)
+		conn, dialErr := l.dialReferral(referral, u)
+		if dialErr != nil {
+			err = dialErr
+			continue
+		}
+		err = do(conn, u)
+		conn.Close()
+		// try the next server only if this one went away
+		if !IsErrorWithCode(err, ErrorNetwork) {
+			return err
+		}
+	}
+	return err
+}
+
+// chaseReferrals follows the referral returned in err by re-issuing the operation with
+// do, until the operation does not result in a referral anymore
+func (l *Conn) chaseReferrals(err error, hops int, visited map[string]bool, do func(conn *Conn, u *LDAPURL) error) error {
+	if !IsErrorWithCode(err, LDAPResultReferral) {
+		return err
+	}
+	return l.followReferral(errorReferrals(err), hops, visited, func(conn *Conn, u *LDAPURL) error {
+		return l.chaseReferrals(do(conn, u), hops+1, visited, do)
+	})
+}
+
+// errorReferrals returns the referrals of the LDAP error wrapped in err
+func errorReferrals(err error) []string {
+	var ldapErr *Error
+	if errors.As(err, &ldapErr) {
+		return ldapErr.Referrals
+	}
+	return nil
+}
+
+// referredDN returns the DN to use for an operation re-issued because of a referral
+func referredDN(dn string, u *LDAPURL) string {
+	if u.DN != nil && len(u.DN.RDNs) > 0 {
+		return u.DN.String()
+	}
+	return dn
+}
+
+// referredSearchRequest returns the search request to issue for a search continuation
+// reference or a referral. The paging control of the original request is not sent to
+// the referred server, which is searched with its own paging instead.
+func referredSearchRequest(req *SearchRequest, u *LDAPURL) (*SearchRequest, uint32) {
+	referred := *req
+	referred.BaseDN = referredDN(req.BaseDN, u)
+	if u.Scope >= 0 {
+		referred.Scope = u.Scope
+	}
+	if u.Filter != "" {
+		referred.Filter = u.Filter
+	}
+
+	var pagingSize uint32
+	referred.Controls = nil
+	for _, control := range req.Controls {
+		if paging, ok := control.(*ControlPaging); ok {
+			pagingSize = paging.PagingSize
+			continue
+		}
+		referred.Controls = append(referred.Controls, control)
+	}
+	return &referred, pagingSize
+}
+
+// chaseSearchReferrals follows the referral returned in err, or the search continuation
+// references of the result
+func (l *Conn) chaseSearchReferrals(req *SearchRequest, result *SearchResult, err error, hops int, visited map[string]bool) (*SearchResult, error) {
+	var referred *SearchResult
+	search := func(conn *Conn, u *LDAPURL) error {
+		referredReq, pagingSize := referredSearchRequest(req, u)
+		var r *SearchResult
+		var err error
+		if pagingSize > 0 {
+			r, err = conn.SearchWithPaging(referredReq, pagingSize)
+		} else {
+			r, err = conn.Search(referredReq)
+		}
+		referred, err = l.chaseSearchReferrals(referredReq, r, err, hops+1, visited)
+		return err
+	}
+
+	if IsErrorWithCode(err, LDAPResultReferral) {
+		err = l.followReferral(errorReferrals(err), hops, visited, search)
+		if referred != nil {
+			result = referred
+		}
+		return result, err
+	}
+	if err != nil || result == nil {
+		return result, err
+	}
+
+	var unresolved []string
+	for _, reference := range result.Referrals {
+		referred = nil
+		if err := l.followReferral([]string{reference}, hops, visited, search); err != nil {
+			l.log(LevelDebug, "could not follow search reference", "reference", reference, LogKeyError, err)
+			unresolved = append(unresolved, reference)
+			continue
+		}
+		result.Entries = append(result.Entries, referred.Entries...)
+		unresolved = append(unresolved, referred.Referrals...)
+	}
+	result.Referrals = make([]string, 0, len(unresolved))
+	result.Referrals = append(result.Referrals, unresolved...)
+	return result, nil
+}
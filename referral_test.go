@@ -0,0 +1,279 @@
+package ldap
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+)
+
+// newTestServerConn returns a started Conn whose requests are answered by the responses
+// returned by handler. The message ID of the responses is set by newTestServerConn.
+func newTestServerConn(t *testing.T, handler func(request *ber.Packet) []*ber.Packet) *Conn {
+	ptc := newPacketTranslatorConn()
+	go func() {
+		for {
+			request, err := ptc.ReceiveRequest()
+			if err != nil {
+				return
+			}
+			for _, response := range handler(request) {
+				packet := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+				packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, request.Children[0].Value, "MessageID"))
+				packet.AppendChild(response)
+				if err := ptc.SendResponse(packet); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	conn := NewConn(ptc, false)
+	conn.Start()
+	return conn
+}
+
+// newTestLDAPResult returns an LDAPResult protocol op of the given application type
+func newTestLDAPResult(application ber.Tag, resultCode int64, referrals ...string) *ber.Packet {
+	response := ber.Encode(ber.ClassApplication, ber.TypeConstructed, application, nil, ApplicationMap[uint8(application)])
+	response.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, resultCode, "resultCode"))
+	response.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "matchedDN"))
+	response.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "diagnosticMessage"))
+	if len(referrals) > 0 {
+		referral := ber.Encode(ber.ClassContext, ber.TypeConstructed, 3, nil, "Referral")
+		for _, uri := range referrals {
+			referral.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, uri, "URI"))
+		}
+		response.AppendChild(referral)
+	}
+	return response
+}
+
+// newTestSearchResultEntry returns a SearchResultEntry protocol op without attributes
+func newTestSearchResultEntry(dn string) *ber.Packet {
+	entry := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ApplicationSearchResultEntry, nil, "Search Result Entry")
+	entry.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, dn, "Object Name"))
+	entry.AppendChild(ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attributes"))
+	return entry
+}
+
+// newTestSearchResultReference returns a SearchResultReference protocol op
+func newTestSearchResultReference(uris ...string) *ber.Packet {
+	reference := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ApplicationSearchResultReference, nil, "Search Result Reference")
+	for _, uri := range uris {
+		reference.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, uri, "URI"))
+	}
+	return reference
+}
+
+func TestSearchChasesContinuationReferences(t *testing.T) {
+	var referredBaseDN string
+	var referredScope int64
+	var mu sync.Mutex
+
+	servers := map[string]func(*ber.Packet) []*ber.Packet{
+		"ldap://b.example.org/ou=b,dc=example,dc=org??one": func(request *ber.Packet) []*ber.Packet {
+			mu.Lock()
+			referredBaseDN = request.Children[1].Children[0].Value.(string)
+			referredScope = request.Children[1].Children[1].Value.(int64)
+			mu.Unlock()
+			return []*ber.Packet{
+				newTestSearchResultEntry("cn=two,ou=b,dc=example,dc=org"),
+				newTestLDAPResult(ApplicationSearchResultDone, LDAPResultSuccess),
+			}
+		},
+		"ldap://c.example.org/ou=c,dc=example,dc=org": func(request *ber.Packet) []*ber.Packet {
+			return []*ber.Packet{newTestLDAPResult(ApplicationSearchResultDone, LDAPResultInsufficientAccessRights)}
+		},
+	}
+
+	conn := newTestServerConn(t, func(request *ber.Packet) []*ber.Packet {
+		return []*ber.Packet{
+			newTestSearchResultEntry("cn=one,dc=example,dc=org"),
+			newTestSearchResultReference("ldap://b.example.org/ou=b,dc=example,dc=org??one"),
+			newTestSearchResultReference("ldap://c.example.org/ou=c,dc=example,dc=org"),
+			newTestLDAPResult(ApplicationSearchResultDone, LDAPResultSuccess),
+		}
+	})
+	defer conn.Close()
+	conn.SetReferralOptions(&ReferralOptions{
+		Dial: func(referral string) (*Conn, error) {
+			return newTestServerConn(t, servers[referral]), nil
+		},
+	})
+
+	result, err := conn.Search(NewSearchRequest("dc=example,dc=org", ScopeWholeSubtree, NeverDerefAliases, 0, 0, false, "(objectClass=*)", nil, nil))
+	if err != nil {
+		t.Fatalf("search failed: %s", err)
+	}
+	if len(result.Entries) != 2 || result.Entries[0].DN != "cn=one,dc=example,dc=org" || result.Entries[1].DN != "cn=two,ou=b,dc=example,dc=org" {
+		t.Errorf("unexpected entries: %v", result.Entries)
+	}
+	if len(result.Referrals) != 1 || result.Referrals[0] != "ldap://c.example.org/ou=c,dc=example,dc=org" {
+		t.Errorf("expected the failed reference to be returned, got: %v", result.Referrals)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if referredBaseDN != "ou=b,dc=example,dc=org" || referredScope != ScopeSingleLevel {
+		t.Errorf("unexpected referred search: base %q, scope %d", referredBaseDN, referredScope)
+	}
+}
+
+func TestModifyChasesReferral(t *testing.T) {
+	var referredDN string
+	conn := newTestServerConn(t, func(request *ber.Packet) []*ber.Packet {
+		return []*ber.Packet{newTestLDAPResult(ApplicationModifyResponse, LDAPResultReferral, "ldap://master.example.org/uid=someone,ou=people,dc=example,dc=org")}
+	})
+	defer conn.Close()
+	conn.SetReferralOptions(&ReferralOptions{
+		Dial: func(referral string) (*Conn, error) {
+			return newTestServerConn(t, func(request *ber.Packet) []*ber.Packet {
+				referredDN = request.Children[1].Children[0].Value.(string)
+				return []*ber.Packet{newTestLDAPResult(ApplicationModifyResponse, LDAPResultSuccess)}
+			}), nil
+		},
+	})
+
+	req := NewModifyRequest("uid=someone,dc=example,dc=org", nil)
+	req.Replace("description", []string{"referred"})
+	if err := conn.Modify(req); err != nil {
+		t.Fatalf("modify failed: %s", err)
+	}
+	if referredDN != "uid=someone,ou=people,dc=example,dc=org" {
+		t.Errorf("unexpected DN in referred modify: %q", referredDN)
+	}
+}
+
+func TestReferralLoopAndHopLimit(t *testing.T) {
+	referTo := func(uri string) *Conn {
+		return newTestServerConn(t, func(request *ber.Packet) []*ber.Packet {
+			return []*ber.Packet{newTestLDAPResult(ApplicationDelResponse, LDAPResultReferral, uri)}
+		})
+	}
+
+	conn := referTo("ldap://a.example.org/cn=loop")
+	defer conn.Close()
+	conn.SetReferralOptions(&ReferralOptions{
+		Dial: func(referral string) (*Conn, error) {
+			return referTo(referral), nil
+		},
+	})
+	err := conn.Del(NewDelRequest("cn=loop", nil))
+	if !IsErrorWithCode(err, LDAPResultClientLoop) {
+		t.Errorf("expected a client loop error, got: %v", err)
+	}
+
+	hops := 0
+	conn.SetReferralOptions(&ReferralOptions{
+		HopLimit: 3,
+		Dial: func(referral string) (*Conn, error) {
+			hops++
+			return referTo(referral + "x"), nil
+		},
+	})
+	err = conn.Del(NewDelRequest("cn=loop", nil))
+	if !IsErrorWithCode(err, LDAPResultReferralLimitExceeded) {
+		t.Errorf("expected a referral limit error, got: %v", err)
+	}
+	if hops != 3 {
+		t.Errorf("expected 3 referrals to be followed, got %d", hops)
+	}
+}
+
+func TestReferralWithoutHost(t *testing.T) {
+	conn := newTestServerConn(t, func(request *ber.Packet) []*ber.Packet {
+		return []*ber.Packet{newTestLDAPResult(ApplicationDelResponse, LDAPResultReferral, "ldap:///cn=a,dc=example,dc=org")}
+	})
+	defer conn.Close()
+	bound := false
+	conn.SetReferralOptions(&ReferralOptions{
+		Bind: func(conn *Conn, referral string) error {
+			bound = true
+			return nil
+		},
+	})
+
+	err := conn.Del(NewDelRequest("cn=a,dc=example,dc=org", nil))
+	if !IsErrorWithCode(err, LDAPResultParamError) {
+		t.Errorf("expected a parameter error, got: %v", err)
+	}
+	if bound {
+		t.Error("expected no bind to a referred server without host")
+	}
+}
+
+func TestLDAPIReferral(t *testing.T) {
+	dir, err := ioutil.TempDir("", "referral")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	listener, err := net.Listen("unix", filepath.Join(dir, "ldapi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	// the referred server answers a single delete request
+	referredDN := make(chan string, 1)
+	go func() {
+		c, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		request, err := ber.ReadPacket(c)
+		if err != nil {
+			return
+		}
+		referredDN <- request.Children[1].Data.String()
+		packet := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+		packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, request.Children[0].Value, "MessageID"))
+		packet.AppendChild(newTestLDAPResult(ApplicationDelResponse, LDAPResultSuccess))
+		c.Write(packet.Bytes())
+		ber.ReadPacket(c)
+	}()
+
+	referral := (&LDAPURL{Scheme: "ldapi", Host: listener.Addr().String(), Scope: -1}).String() + "/cn=b,dc=example,dc=org"
+	conn := newTestServerConn(t, func(request *ber.Packet) []*ber.Packet {
+		return []*ber.Packet{newTestLDAPResult(ApplicationDelResponse, LDAPResultReferral, referral)}
+	})
+	defer conn.Close()
+	conn.SetReferralOptions(&ReferralOptions{})
+
+	if err := conn.Del(NewDelRequest("cn=a,dc=example,dc=org", nil)); err != nil {
+		t.Fatalf("delete failed: %s", err)
+	}
+	if dn := <-referredDN; dn != "cn=b,dc=example,dc=org" {
+		t.Errorf("unexpected DN in referred delete: %q", dn)
+	}
+}
+
+func TestWrappedReferralError(t *testing.T) {
+	conn := newTestServerConn(t, func(request *ber.Packet) []*ber.Packet {
+		return nil
+	})
+	defer conn.Close()
+	conn.SetReferralOptions(&ReferralOptions{
+		Dial: func(referral string) (*Conn, error) {
+			return newTestServerConn(t, func(request *ber.Packet) []*ber.Packet {
+				return []*ber.Packet{newTestLDAPResult(ApplicationDelResponse, LDAPResultSuccess)}
+			}), nil
+		},
+	})
+
+	// referrals are read from LDAP errors wrapped in other errors
+	referralErr := &Error{ResultCode: LDAPResultReferral, Err: errors.New("referral"), Referrals: []string{"ldap://other.example.org/cn=a,dc=example,dc=org"}}
+	err := conn.chaseReferrals(fmt.Errorf("wrapped: %w", referralErr), 0, map[string]bool{}, func(conn *Conn, u *LDAPURL) error {
+		return conn.Del(NewDelRequest(referredDN("cn=a,dc=example,dc=org", u), nil))
+	})
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
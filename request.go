@@ -28,26 +28,16 @@ func (l *Conn) doRequest(req request) (*messageContext, error) {
 		return nil, err
 	}
 
-	if l.Debug {
-		l.Debug.PrintPacket(packet)
-	}
-
-	msgCtx, err := l.sendMessage(packet)
-	if err != nil {
-		return nil, err
-	}
-	l.Debug.Printf("%d: returning", msgCtx.id)
-	return msgCtx, nil
+	return l.sendMessage(packet)
 }
 
 func (l *Conn) readPacket(msgCtx *messageContext) (*ber.Packet, error) {
-	l.Debug.Printf("%d: waiting for response", msgCtx.id)
+	l.log(LevelDebug, "waiting for response", logMessage(msgCtx)...)
 	packetResponse, ok := <-msgCtx.responses
 	if !ok {
 		return nil, NewError(ErrorNetwork, errRespChanClosed)
 	}
 	packet, err := packetResponse.ReadPacket()
-	l.Debug.Printf("%d: got response %p", msgCtx.id, packet)
 	if err != nil {
 		return nil, err
 	}
@@ -56,11 +46,6 @@ func (l *Conn) readPacket(msgCtx *messageContext) (*ber.Packet, error) {
 		return nil, NewError(ErrorNetwork, errCouldNotRetMsg)
 	}
 
-	if l.Debug {
-		if err = addLDAPDescriptions(packet); err != nil {
-			return nil, err
-		}
-		l.Debug.PrintPacket(packet)
-	}
+	l.logPacket("got response", packet, logMessage(msgCtx)...)
 	return packet, nil
 }
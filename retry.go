@@ -0,0 +1,294 @@
+package ldap
+
+import (
+	"crypto/tls"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Default values of the RetryPolicy fields left unset
+const (
+	DefaultRetryMaxAttempts    = 3
+	DefaultRetryInitialBackoff = 100 * time.Millisecond
+	DefaultRetryMaxBackoff     = 5 * time.Second
+	DefaultRetryMultiplier     = 2
+	DefaultRetryJitter         = 0.2
+)
+
+// DefaultRetryWriteCodes are the result codes for which write operations are retried by default. Servers
+// return them without performing the operation, so retrying it cannot apply it twice.
+var DefaultRetryWriteCodes = []uint16{LDAPResultBusy, LDAPResultUnavailable}
+
+// RetryPolicy controls how a RetryClient retries operations failing with transient errors. Zero values
+// are replaced by the DefaultRetry* values.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts of an operation, including the first one
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay before a retry
+	MaxBackoff time.Duration
+	// Multiplier is the factor by which the delay grows after each retry
+	Multiplier float64
+	// Jitter randomizes each delay by up to this fraction of it in either direction, between 0 and 1.
+	// Set it to a negative value to disable jitter.
+	Jitter float64
+	// RetryWriteCodes are the result codes for which write operations (Add, Del, Modify, ModifyDN and
+	// PasswordModify) are retried, DefaultRetryWriteCodes if nil. Unlike reads, writes are not retried
+	// for other transient errors such as network errors, as the server may have performed them already.
+	RetryWriteCodes []uint16
+	// Reconnect, if set, returns a new bound client replacing the wrapped one after a network error,
+	// before the operation is retried. Without it, operations failing with network errors are not
+	// retried, as the connection is closed.
+	Reconnect func() (Client, error)
+	// OnAttempt, if set, is called after each attempt of an operation with the name of the operation,
+	// e.g. "Search", the number of the attempt starting at 1, its error and the delay before the next
+	// attempt, which is 0 if the operation is not retried
+	OnAttempt func(operation string, attempt int, err error, delay time.Duration)
+}
+
+// RetryClient is a Client retrying operations failing with transient errors, see IsTransient, as
+// configured by its RetryPolicy. Reads, binds and Compare are retried for all transient errors, writes
+// only for the RetryWriteCodes. Start, StartTLS, Close and SetTimeout are not retried.
+type RetryClient struct {
+	policy RetryPolicy
+
+	mutex  sync.Mutex
+	client Client
+	// sleep waits between attempts and is replaced by tests
+	sleep func(time.Duration)
+}
+
+var _ Client = &RetryClient{}
+
+// NewRetryClient returns a RetryClient wrapping the given client. A nil policy uses the defaults.
+func NewRetryClient(client Client, policy *RetryPolicy) *RetryClient {
+	c := &RetryClient{client: client, sleep: time.Sleep}
+	if policy != nil {
+		c.policy = *policy
+	}
+	if c.policy.MaxAttempts <= 0 {
+		c.policy.MaxAttempts = DefaultRetryMaxAttempts
+	}
+	if c.policy.InitialBackoff <= 0 {
+		c.policy.InitialBackoff = DefaultRetryInitialBackoff
+	}
+	if c.policy.MaxBackoff <= 0 {
+		c.policy.MaxBackoff = DefaultRetryMaxBackoff
+	}
+	if c.policy.Multiplier <= 0 {
+		c.policy.Multiplier = DefaultRetryMultiplier
+	}
+	if c.policy.Jitter == 0 {
+		c.policy.Jitter = DefaultRetryJitter
+	} else if c.policy.Jitter < 0 {
+		c.policy.Jitter = 0
+	} else if c.policy.Jitter > 1 {
+		c.policy.Jitter = 1
+	}
+	if c.policy.RetryWriteCodes == nil {
+		c.policy.RetryWriteCodes = DefaultRetryWriteCodes
+	}
+	return c
+}
+
+// Client returns the wrapped client, which changes when reconnecting
+func (c *RetryClient) Client() Client {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.client
+}
+
+// retry calls op with the wrapped client until it succeeds, fails with an error not to retry or the
+// maximum number of attempts is reached, and returns its last error
+func (c *RetryClient) retry(operation string, write bool, op func(Client) error) error {
+	backoff := c.policy.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		client := c.Client()
+		err := op(client)
+
+		retry := err != nil && attempt < c.policy.MaxAttempts && c.retryable(err, write)
+		var delay time.Duration
+		if retry {
+			delay = c.jitter(backoff)
+			backoff = time.Duration(math.Min(float64(backoff)*c.policy.Multiplier, float64(c.policy.MaxBackoff)))
+		}
+		if c.policy.OnAttempt != nil {
+			c.policy.OnAttempt(operation, attempt, err, delay)
+		}
+		if !retry {
+			return err
+		}
+
+		c.sleep(delay)
+		if IsErrorWithCode(err, ErrorNetwork) {
+			if err := c.reconnect(client); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// retryable returns true if an operation failing with the given error may be retried
+func (c *RetryClient) retryable(err error, write bool) bool {
+	if IsErrorWithCode(err, ErrorNetwork) && c.policy.Reconnect == nil {
+		return false
+	}
+	if !write {
+		return IsTransient(err)
+	}
+	return IsErrorAnyOf(err, c.policy.RetryWriteCodes...)
+}
+
+// reconnect replaces the given failed client with a new one, unless another operation already did
+func (c *RetryClient) reconnect(failed Client) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.client != failed {
+		return nil
+	}
+	client, err := c.policy.Reconnect()
+	if err != nil {
+		return err
+	}
+	failed.Close()
+	c.client = client
+	return nil
+}
+
+// jitter randomizes the given delay by up to the jitter fraction of it
+func (c *RetryClient) jitter(delay time.Duration) time.Duration {
+	if c.policy.Jitter == 0 {
+		return delay
+	}
+	return time.Duration(float64(delay) * (1 + c.policy.Jitter*(2*rand.Float64()-1)))
+}
+
+// Start starts the wrapped client
+func (c *RetryClient) Start() {
+	c.Client().Start()
+}
+
+// StartTLS sends the StartTLS request with the wrapped client, without retrying it
+func (c *RetryClient) StartTLS(config *tls.Config) error {
+	return c.Client().StartTLS(config)
+}
+
+// Close closes the wrapped client
+func (c *RetryClient) Close() {
+	c.Client().Close()
+}
+
+// SetTimeout sets the timeout of the wrapped client
+func (c *RetryClient) SetTimeout(timeout time.Duration) {
+	c.Client().SetTimeout(timeout)
+}
+
+// Bind performs a bind with the given username and password, retrying it on transient errors
+func (c *RetryClient) Bind(username, password string) error {
+	return c.retry("Bind", false, func(client Client) error {
+		return client.Bind(username, password)
+	})
+}
+
+// UnauthenticatedBind performs an unauthenticated bind, retrying it on transient errors
+func (c *RetryClient) UnauthenticatedBind(username string) error {
+	return c.retry("UnauthenticatedBind", false, func(client Client) error {
+		return client.UnauthenticatedBind(username)
+	})
+}
+
+// SimpleBind performs the simple bind operation, retrying it on transient errors
+func (c *RetryClient) SimpleBind(simpleBindRequest *SimpleBindRequest) (*SimpleBindResult, error) {
+	var result *SimpleBindResult
+	err := c.retry("SimpleBind", false, func(client Client) (err error) {
+		result, err = client.SimpleBind(simpleBindRequest)
+		return err
+	})
+	return result, err
+}
+
+// ExternalBind performs a SASL EXTERNAL bind, retrying it on transient errors
+func (c *RetryClient) ExternalBind() error {
+	return c.retry("ExternalBind", false, func(client Client) error {
+		return client.ExternalBind()
+	})
+}
+
+// Add performs the add operation, retrying it for the RetryWriteCodes
+func (c *RetryClient) Add(addRequest *AddRequest) error {
+	return c.retry("Add", true, func(client Client) error {
+		return client.Add(addRequest)
+	})
+}
+
+// Del performs the delete operation, retrying it for the RetryWriteCodes
+func (c *RetryClient) Del(delRequest *DelRequest) error {
+	return c.retry("Del", true, func(client Client) error {
+		return client.Del(delRequest)
+	})
+}
+
+// Modify performs the modify operation, retrying it for the RetryWriteCodes
+func (c *RetryClient) Modify(modifyRequest *ModifyRequest) error {
+	return c.retry("Modify", true, func(client Client) error {
+		return client.Modify(modifyRequest)
+	})
+}
+
+// ModifyDN performs the modify DN operation, retrying it for the RetryWriteCodes
+func (c *RetryClient) ModifyDN(modifyDNRequest *ModifyDNRequest) error {
+	return c.retry("ModifyDN", true, func(client Client) error {
+		return client.ModifyDN(modifyDNRequest)
+	})
+}
+
+// Compare checks if the attribute of the DN has the given value, retrying it on transient errors
+func (c *RetryClient) Compare(dn, attribute, value string) (bool, error) {
+	var matched bool
+	err := c.retry("Compare", false, func(client Client) (err error) {
+		matched, err = client.Compare(dn, attribute, value)
+		return err
+	})
+	return matched, err
+}
+
+// PasswordModify performs the password modify operation, retrying it for the RetryWriteCodes
+func (c *RetryClient) PasswordModify(passwordModifyRequest *PasswordModifyRequest) (*PasswordModifyResult, error) {
+	var result *PasswordModifyResult
+	err := c.retry("PasswordModify", true, func(client Client) (err error) {
+		result, err = client.PasswordModify(passwordModifyRequest)
+		return err
+	})
+	return result, err
+}
+
+// Search performs the search, retrying it on transient errors
+func (c *RetryClient) Search(searchRequest *SearchRequest) (*SearchResult, error) {
+	var result *SearchResult
+	err := c.retry("Search", false, func(client Client) (err error) {
+		result, err = client.Search(searchRequest)
+		return err
+	})
+	return result, err
+}
+
+// SearchWithPaging performs the paged search, retrying it from the first page on transient errors
+func (c *RetryClient) SearchWithPaging(searchRequest *SearchRequest, pagingSize uint32) (*SearchResult, error) {
+	// a failed attempt leaves the cookie of the last page in the paging control of the request
+	var cookie []byte
+	if control, ok := FindControl(searchRequest.Controls, ControlTypePaging).(*ControlPaging); ok {
+		cookie = control.Cookie
+	}
+	var result *SearchResult
+	err := c.retry("SearchWithPaging", false, func(client Client) (err error) {
+		if control, ok := FindControl(searchRequest.Controls, ControlTypePaging).(*ControlPaging); ok {
+			control.SetCookie(cookie)
+		}
+		result, err = client.SearchWithPaging(searchRequest, pagingSize)
+		return err
+	})
+	return result, err
+}
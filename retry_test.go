@@ -0,0 +1,114 @@
+package ldap
+
+import (
+	"testing"
+	"time"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+)
+
+func TestRetryClient(t *testing.T) {
+	testcases := []struct {
+		name         string
+		resultCodes  []int64
+		write        bool
+		wantAttempts int
+		wantCode     int64
+	}{
+		{"search succeeds", []int64{LDAPResultSuccess}, false, 1, LDAPResultSuccess},
+		{"search busy", []int64{LDAPResultBusy, LDAPResultUnavailable, LDAPResultSuccess}, false, 3, LDAPResultSuccess},
+		{"search server down", []int64{LDAPResultServerDown, LDAPResultSuccess}, false, 2, LDAPResultSuccess},
+		{"search gives up", []int64{LDAPResultBusy, LDAPResultBusy, LDAPResultBusy, LDAPResultSuccess}, false, 3, LDAPResultBusy},
+		{"search not found", []int64{LDAPResultNoSuchObject, LDAPResultSuccess}, false, 1, LDAPResultNoSuchObject},
+		{"modify busy", []int64{LDAPResultBusy, LDAPResultSuccess}, true, 2, LDAPResultSuccess},
+		{"modify server down", []int64{LDAPResultServerDown, LDAPResultSuccess}, true, 1, LDAPResultServerDown},
+		{"modify timeout", []int64{LDAPResultTimeout, LDAPResultSuccess}, true, 1, LDAPResultTimeout},
+	}
+	for _, tc := range testcases {
+		requests := 0
+		conn := newTestServerConn(t, func(request *ber.Packet) []*ber.Packet {
+			code := tc.resultCodes[requests]
+			requests++
+			if tc.write {
+				return []*ber.Packet{newTestLDAPResult(ApplicationModifyResponse, code)}
+			}
+			return []*ber.Packet{newTestLDAPResult(ApplicationSearchResultDone, code)}
+		})
+
+		var attempts, retries int
+		var delays []time.Duration
+		client := NewRetryClient(conn, &RetryPolicy{
+			InitialBackoff: 10 * time.Millisecond,
+			MaxBackoff:     15 * time.Millisecond,
+			OnAttempt: func(operation string, attempt int, err error, delay time.Duration) {
+				attempts = attempt
+				if delay > 0 {
+					retries++
+				}
+			},
+		})
+		client.sleep = func(delay time.Duration) { delays = append(delays, delay) }
+
+		var err error
+		if tc.write {
+			err = client.Modify(NewModifyRequest("cn=a,dc=example,dc=org", nil))
+		} else {
+			_, err = client.Search(NewSearchRequest("dc=example,dc=org", ScopeWholeSubtree, NeverDerefAliases, 0, 0, false, "(cn=a)", nil, nil))
+		}
+		conn.Close()
+
+		if tc.wantCode == LDAPResultSuccess {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %s", tc.name, err)
+			}
+		} else if !IsErrorWithCode(err, uint16(tc.wantCode)) {
+			t.Errorf("%s: got error %v, want code %d", tc.name, err, tc.wantCode)
+		}
+		if requests != tc.wantAttempts || attempts != tc.wantAttempts || retries != tc.wantAttempts-1 {
+			t.Errorf("%s: got %d requests and %d attempts with %d retries, want %d attempts", tc.name, requests, attempts, retries, tc.wantAttempts)
+		}
+		for i, delay := range delays {
+			want := 10 * time.Millisecond
+			if i > 0 {
+				want = 15 * time.Millisecond
+			}
+			if delay < want*8/10 || delay > want*12/10 {
+				t.Errorf("%s: delay %d is %s, want %s with jitter", tc.name, i, delay, want)
+			}
+		}
+	}
+}
+
+func TestRetryClientReconnect(t *testing.T) {
+	closed := newTestServerConn(t, func(request *ber.Packet) []*ber.Packet { return nil })
+	closed.Close()
+
+	reconnects := 0
+	client := NewRetryClient(closed, &RetryPolicy{
+		Reconnect: func() (Client, error) {
+			reconnects++
+			return newTestServerConn(t, func(request *ber.Packet) []*ber.Packet {
+				return []*ber.Packet{newTestLDAPResult(ApplicationCompareResponse, LDAPResultCompareTrue)}
+			}), nil
+		},
+	})
+	client.sleep = func(time.Duration) {}
+	defer client.Close()
+
+	matched, err := client.Compare("cn=a,dc=example,dc=org", "sn", "Smith")
+	if err != nil || !matched {
+		t.Fatalf("got %t, %v", matched, err)
+	}
+	if reconnects != 1 || client.Client() == Client(closed) {
+		t.Errorf("expected the client to reconnect once, got %d reconnects", reconnects)
+	}
+
+	// writes are not retried after network errors, as they may have been performed
+	client = NewRetryClient(closed, &RetryPolicy{Reconnect: func() (Client, error) {
+		t.Error("unexpected reconnect")
+		return closed, nil
+	}})
+	if err := client.Del(NewDelRequest("cn=a,dc=example,dc=org", nil)); !IsErrorWithCode(err, ErrorNetwork) {
+		t.Errorf("got %v, want a network error", err)
+	}
+}
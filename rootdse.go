@@ -0,0 +1,132 @@
+package ldap
+
+import (
+	"errors"
+	"strings"
+)
+
+// rootDSEAttributes are the attributes requested when reading the root DSE. Most
+// of them are operational attributes, so they are requested explicitly in addition
+// to "*" and "+" for servers which only return them by name.
+var rootDSEAttributes = []string{
+	"*",
+	"+",
+	"namingContexts",
+	"defaultNamingContext",
+	"subschemaSubentry",
+	"altServer",
+	"supportedControl",
+	"supportedExtension",
+	"supportedFeatures",
+	"supportedLDAPVersion",
+	"supportedSASLMechanisms",
+	"vendorName",
+	"vendorVersion",
+}
+
+// RootDSE represents the root DSE of a server as described in https://tools.ietf.org/html/rfc4512#section-5.1
+type RootDSE struct {
+	// NamingContexts are the naming contexts held by the server
+	NamingContexts []string
+	// DefaultNamingContext is the default naming context announced by Active Directory and
+	// some other servers, or "" if not present
+	DefaultNamingContext string
+	// SubschemaSubentry is the DN of the subschema entry of the root DSE
+	SubschemaSubentry string
+	// AltServers are the URLs of alternative servers which may be contacted when this one is unavailable
+	AltServers []string
+	// SupportedControls are the OIDs of the supported controls
+	SupportedControls []string
+	// SupportedExtensions are the OIDs of the supported extended operations
+	SupportedExtensions []string
+	// SupportedFeatures are the OIDs of the supported elective features
+	SupportedFeatures []string
+	// SupportedLDAPVersions are the supported protocol versions
+	SupportedLDAPVersions []string
+	// SupportedSASLMechanisms are the names of the supported SASL mechanisms
+	SupportedSASLMechanisms []string
+	// VendorName is the name of the server vendor, or "" if not present
+	VendorName string
+	// VendorVersion is the version of the server, or "" if not present
+	VendorVersion string
+	// Entry is the root DSE as returned by the server, giving access to vendor specific attributes
+	Entry *Entry
+}
+
+// NewRootDSE returns the root DSE described by the given entry
+func NewRootDSE(entry *Entry) *RootDSE {
+	return &RootDSE{
+		NamingContexts:          entry.GetEqualFoldAttributeValues("namingContexts"),
+		DefaultNamingContext:    entry.GetEqualFoldAttributeValue("defaultNamingContext"),
+		SubschemaSubentry:       entry.GetEqualFoldAttributeValue("subschemaSubentry"),
+		AltServers:              entry.GetEqualFoldAttributeValues("altServer"),
+		SupportedControls:       entry.GetEqualFoldAttributeValues("supportedControl"),
+		SupportedExtensions:     entry.GetEqualFoldAttributeValues("supportedExtension"),
+		SupportedFeatures:       entry.GetEqualFoldAttributeValues("supportedFeatures"),
+		SupportedLDAPVersions:   entry.GetEqualFoldAttributeValues("supportedLDAPVersion"),
+		SupportedSASLMechanisms: entry.GetEqualFoldAttributeValues("supportedSASLMechanisms"),
+		VendorName:              entry.GetEqualFoldAttributeValue("vendorName"),
+		VendorVersion:           entry.GetEqualFoldAttributeValue("vendorVersion"),
+		Entry:                   entry,
+	}
+}
+
+// SupportsControl returns whether the server announces support for the control with the given OID
+func (r *RootDSE) SupportsControl(oid string) bool {
+	return containsString(r.SupportedControls, oid)
+}
+
+// SupportsExtension returns whether the server announces support for the extended operation with the given OID
+func (r *RootDSE) SupportsExtension(oid string) bool {
+	return containsString(r.SupportedExtensions, oid)
+}
+
+// SupportsFeature returns whether the server announces support for the feature with the given OID
+func (r *RootDSE) SupportsFeature(oid string) bool {
+	return containsString(r.SupportedFeatures, oid)
+}
+
+// SupportsSASLMechanism returns whether the server announces support for the given SASL mechanism.
+// Mechanism names are compared case-insensitively.
+func (r *RootDSE) SupportsSASLMechanism(mechanism string) bool {
+	for _, m := range r.SupportedSASLMechanisms {
+		if strings.EqualFold(m, mechanism) {
+			return true
+		}
+	}
+	return false
+}
+
+// PreferredSASLMechanism returns the first of the given SASL mechanisms, in order of preference,
+// which is supported by the server, or "" if none of them is
+func (r *RootDSE) PreferredSASLMechanism(mechanisms ...string) string {
+	for _, mechanism := range mechanisms {
+		if r.SupportsSASLMechanism(mechanism) {
+			return mechanism
+		}
+	}
+	return ""
+}
+
+// RootDSE reads the root DSE of the server. Servers may announce different capabilities once the
+// connection is encrypted or bound, so it should be read again after StartTLS or Bind.
+func (l *Conn) RootDSE() (*RootDSE, error) {
+	searchRequest := NewSearchRequest("", ScopeBaseObject, NeverDerefAliases, 0, 0, false, "(objectClass=*)", rootDSEAttributes, nil)
+	result, err := l.search(searchRequest)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Entries) != 1 {
+		return nil, NewError(ErrorUnexpectedResponse, errors.New("ldap: root DSE not returned by the server"))
+	}
+	return NewRootDSE(result.Entries[0]), nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,775 @@
+package ldap
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ObjectClassKind is the kind of an object class, see https://tools.ietf.org/html/rfc4512#section-2.4
+type ObjectClassKind int
+
+// Object class kinds
+const (
+	ObjectClassStructural ObjectClassKind = 0
+	ObjectClassAbstract   ObjectClassKind = 1
+	ObjectClassAuxiliary  ObjectClassKind = 2
+)
+
+// ObjectClassKindMap contains human readable descriptions of object class kinds
+var ObjectClassKindMap = map[ObjectClassKind]string{
+	ObjectClassStructural: "STRUCTURAL",
+	ObjectClassAbstract:   "ABSTRACT",
+	ObjectClassAuxiliary:  "AUXILIARY",
+}
+
+// Attribute type usages, see https://tools.ietf.org/html/rfc4512#section-4.1.2
+const (
+	AttributeUsageUserApplications     = "userApplications"
+	AttributeUsageDirectoryOperation   = "directoryOperation"
+	AttributeUsageDistributedOperation = "distributedOperation"
+	AttributeUsageDSAOperation         = "dSAOperation"
+)
+
+// AttributeType represents an attribute type description from https://tools.ietf.org/html/rfc4512#section-4.1.2
+type AttributeType struct {
+	// OID is the numeric object identifier of the attribute type
+	OID string
+	// Names are the short names of the attribute type, if any
+	Names []string
+	// Description is the description of the attribute type
+	Description string
+	// Obsolete is set if the attribute type is not active
+	Obsolete bool
+	// Superior is the name or OID of the attribute type this one is derived from, or ""
+	Superior string
+	// Equality is the equality matching rule, or "" if not defined by the attribute type itself
+	Equality string
+	// Ordering is the ordering matching rule, or "" if not defined by the attribute type itself
+	Ordering string
+	// Substring is the substrings matching rule, or "" if not defined by the attribute type itself
+	Substring string
+	// Syntax is the OID of the value syntax, or "" if not defined by the attribute type itself
+	Syntax string
+	// SyntaxLength is the suggested maximum length of values, or 0 if not specified
+	SyntaxLength int
+	// SingleValue is set if the attribute may only have a single value
+	SingleValue bool
+	// Collective is set for collective attributes
+	Collective bool
+	// NoUserModification is set if the attribute cannot be modified by clients
+	NoUserModification bool
+	// Usage is the application of the attribute type, one of the AttributeUsage* constants
+	Usage string
+	// Extensions are the extensions of the description, e.g. "X-ORIGIN", by name
+	Extensions map[string][]string
+}
+
+// ObjectClass represents an object class description from https://tools.ietf.org/html/rfc4512#section-4.1.1
+type ObjectClass struct {
+	// OID is the numeric object identifier of the object class
+	OID string
+	// Names are the short names of the object class, if any
+	Names []string
+	// Description is the description of the object class
+	Description string
+	// Obsolete is set if the object class is not active
+	Obsolete bool
+	// Superiors are the names or OIDs of the object classes this one is derived from
+	Superiors []string
+	// Kind is the kind of the object class
+	Kind ObjectClassKind
+	// Must are the names or OIDs of the required attribute types
+	Must []string
+	// May are the names or OIDs of the allowed attribute types
+	May []string
+	// Extensions are the extensions of the description, e.g. "X-ORIGIN", by name
+	Extensions map[string][]string
+}
+
+// MatchingRule represents a matching rule description from https://tools.ietf.org/html/rfc4512#section-4.1.3
+type MatchingRule struct {
+	// OID is the numeric object identifier of the matching rule
+	OID string
+	// Names are the short names of the matching rule, if any
+	Names []string
+	// Description is the description of the matching rule
+	Description string
+	// Obsolete is set if the matching rule is not active
+	Obsolete bool
+	// Syntax is the OID of the assertion syntax
+	Syntax string
+	// Extensions are the extensions of the description by name
+	Extensions map[string][]string
+}
+
+// LDAPSyntax represents a syntax description from https://tools.ietf.org/html/rfc4512#section-4.1.5
+type LDAPSyntax struct {
+	// OID is the numeric object identifier of the syntax
+	OID string
+	// Description is the description of the syntax
+	Description string
+	// Extensions are the extensions of the description, e.g. "X-NOT-HUMAN-READABLE", by name
+	Extensions map[string][]string
+}
+
+// DITContentRule represents a DIT content rule description from https://tools.ietf.org/html/rfc4512#section-4.1.6
+type DITContentRule struct {
+	// OID is the numeric object identifier of the structural object class the rule applies to
+	OID string
+	// Names are the short names of the rule, if any
+	Names []string
+	// Description is the description of the rule
+	Description string
+	// Obsolete is set if the rule is not active
+	Obsolete bool
+	// Aux are the names or OIDs of the auxiliary object classes allowed
+	Aux []string
+	// Must are the names or OIDs of the additionally required attribute types
+	Must []string
+	// May are the names or OIDs of the additionally allowed attribute types
+	May []string
+	// Not are the names or OIDs of the precluded attribute types
+	Not []string
+	// Extensions are the extensions of the description by name
+	Extensions map[string][]string
+}
+
+// NameForm represents a name form description from https://tools.ietf.org/html/rfc4512#section-4.1.7.2
+type NameForm struct {
+	// OID is the numeric object identifier of the name form
+	OID string
+	// Names are the short names of the name form, if any
+	Names []string
+	// Description is the description of the name form
+	Description string
+	// Obsolete is set if the name form is not active
+	Obsolete bool
+	// ObjectClass is the name or OID of the structural object class the name form applies to
+	ObjectClass string
+	// Must are the names or OIDs of the attribute types required in the RDN
+	Must []string
+	// May are the names or OIDs of the attribute types allowed in the RDN
+	May []string
+	// Extensions are the extensions of the description by name
+	Extensions map[string][]string
+}
+
+// schemaDescription is a parsed description of the RFC 4512 grammar, holding the values of its fields by keyword
+type schemaDescription struct {
+	oid    string
+	fields map[string][]string
+	// extensions are the fields starting with "X-"
+	extensions map[string][]string
+}
+
+// schemaFlags are the keywords of the RFC 4512 descriptions which have no value
+var schemaFlags = map[string]bool{
+	"OBSOLETE":             true,
+	"SINGLE-VALUE":         true,
+	"COLLECTIVE":           true,
+	"NO-USER-MODIFICATION": true,
+	"ABSTRACT":             true,
+	"STRUCTURAL":           true,
+	"AUXILIARY":            true,
+}
+
+// tokenizeSchemaDescription splits a description into parentheses, "$" separators, quoted strings (unescaped
+// and prefixed with a single quote to tell them apart) and bare words
+func tokenizeSchemaDescription(str string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(str); {
+		switch c := str[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(' || c == ')' || c == '$':
+			tokens = append(tokens, string(c))
+			i++
+		case c == '\'':
+			end := strings.IndexByte(str[i+1:], '\'')
+			if end < 0 {
+				return nil, errors.New("ldap: unterminated quoted string in schema description")
+			}
+			value, err := unescapeQDString(str[i+1 : i+1+end])
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, "'"+value)
+			i += end + 2
+		default:
+			start := i
+			for i < len(str) && strings.IndexByte(" \t\n\r()$'", str[i]) < 0 {
+				i++
+			}
+			tokens = append(tokens, str[start:i])
+		}
+	}
+	return tokens, nil
+}
+
+// unescapeQDString unescapes the "\27" and "\5C" sequences of a qdstring
+func unescapeQDString(str string) (string, error) {
+	if strings.IndexByte(str, '\\') < 0 {
+		return str, nil
+	}
+	var buf strings.Builder
+	for i := 0; i < len(str); i++ {
+		if str[i] != '\\' {
+			buf.WriteByte(str[i])
+			continue
+		}
+		if i+3 > len(str) {
+			return "", errors.New("ldap: invalid escape sequence in schema description")
+		}
+		switch strings.ToUpper(str[i+1 : i+3]) {
+		case "27":
+			buf.WriteByte('\'')
+		case "5C":
+			buf.WriteByte('\\')
+		default:
+			return "", fmt.Errorf("ldap: invalid escape sequence %q in schema description", str[i:i+3])
+		}
+		i += 2
+	}
+	return buf.String(), nil
+}
+
+// parseSchemaDescription parses a description of https://tools.ietf.org/html/rfc4512#section-4.1
+func parseSchemaDescription(str string) (*schemaDescription, error) {
+	tokens, err := tokenizeSchemaDescription(str)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) < 3 || tokens[0] != "(" || tokens[len(tokens)-1] != ")" {
+		return nil, fmt.Errorf("ldap: schema description %q is not enclosed in parentheses", str)
+	}
+	tokens = tokens[1 : len(tokens)-1]
+	if isSchemaSyntaxToken(tokens[0]) || strings.HasPrefix(tokens[0], "'") {
+		return nil, fmt.Errorf("ldap: schema description %q does not start with an OID", str)
+	}
+
+	desc := &schemaDescription{
+		oid:        tokens[0],
+		fields:     make(map[string][]string),
+		extensions: make(map[string][]string),
+	}
+	for i := 1; i < len(tokens); {
+		keyword := tokens[i]
+		if isSchemaSyntaxToken(keyword) || strings.HasPrefix(keyword, "'") {
+			return nil, fmt.Errorf("ldap: unexpected %q in schema description %q", keyword, str)
+		}
+		keyword = strings.ToUpper(keyword)
+		i++
+
+		var values []string
+		switch {
+		case schemaFlags[keyword]:
+		case i >= len(tokens) && isSchemaKeyword(keyword):
+			return nil, fmt.Errorf("ldap: missing value for %s in schema description %q", keyword, str)
+		case i >= len(tokens):
+		case tokens[i] == "(":
+			// a list of oids separated by "$", or of qdescrs / qdstrings separated by spaces
+			for i++; i < len(tokens) && tokens[i] != ")"; i++ {
+				if tokens[i] == "$" {
+					continue
+				}
+				if tokens[i] == "(" {
+					return nil, fmt.Errorf("ldap: unexpected nested list in schema description %q", str)
+				}
+				values = append(values, strings.TrimPrefix(tokens[i], "'"))
+			}
+			if i == len(tokens) {
+				return nil, fmt.Errorf("ldap: unterminated list in schema description %q", str)
+			}
+			i++
+		case isSchemaSyntaxToken(tokens[i]):
+			return nil, fmt.Errorf("ldap: unexpected %q in schema description %q", tokens[i], str)
+		case isSchemaKeyword(keyword) || strings.HasPrefix(tokens[i], "'"):
+			values = []string{strings.TrimPrefix(tokens[i], "'")}
+			i++
+		default:
+			// unknown keywords without a quoted or list value are assumed to be flags
+		}
+
+		if strings.HasPrefix(keyword, "X-") {
+			desc.extensions[keyword] = values
+		} else {
+			if _, ok := desc.fields[keyword]; ok {
+				return nil, fmt.Errorf("ldap: duplicate %s in schema description %q", keyword, str)
+			}
+			desc.fields[keyword] = values
+		}
+	}
+	return desc, nil
+}
+
+// isSchemaKeyword returns whether the keyword is one of the RFC 4512 description grammar which have a value
+func isSchemaKeyword(keyword string) bool {
+	switch keyword {
+	case "NAME", "DESC", "SUP", "EQUALITY", "ORDERING", "SUBSTR", "SYNTAX", "USAGE", "MUST", "MAY", "AUX", "NOT", "OC":
+		return true
+	}
+	return false
+}
+
+func isSchemaSyntaxToken(token string) bool {
+	return token == "(" || token == ")" || token == "$"
+}
+
+func (d *schemaDescription) has(keyword string) bool {
+	_, ok := d.fields[keyword]
+	return ok
+}
+
+func (d *schemaDescription) values(keyword string) []string {
+	return d.fields[keyword]
+}
+
+func (d *schemaDescription) value(keyword string) string {
+	if values := d.fields[keyword]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// ParseAttributeType parses an AttributeTypeDescription of https://tools.ietf.org/html/rfc4512#section-4.1.2
+func ParseAttributeType(str string) (*AttributeType, error) {
+	desc, err := parseSchemaDescription(str)
+	if err != nil {
+		return nil, err
+	}
+	at := &AttributeType{
+		OID:                desc.oid,
+		Names:              desc.values("NAME"),
+		Description:        desc.value("DESC"),
+		Obsolete:           desc.has("OBSOLETE"),
+		Superior:           desc.value("SUP"),
+		Equality:           desc.value("EQUALITY"),
+		Ordering:           desc.value("ORDERING"),
+		Substring:          desc.value("SUBSTR"),
+		Syntax:             desc.value("SYNTAX"),
+		SingleValue:        desc.has("SINGLE-VALUE"),
+		Collective:         desc.has("COLLECTIVE"),
+		NoUserModification: desc.has("NO-USER-MODIFICATION"),
+		Usage:              desc.value("USAGE"),
+		Extensions:         desc.extensions,
+	}
+	if i := strings.IndexByte(at.Syntax, '{'); i >= 0 {
+		if !strings.HasSuffix(at.Syntax, "}") {
+			return nil, fmt.Errorf("ldap: invalid syntax length in attribute type %q", str)
+		}
+		length, err := strconv.Atoi(at.Syntax[i+1 : len(at.Syntax)-1])
+		if err != nil {
+			return nil, fmt.Errorf("ldap: invalid syntax length in attribute type %q: %s", str, err)
+		}
+		at.Syntax, at.SyntaxLength = at.Syntax[:i], length
+	}
+	switch at.Usage {
+	case "":
+		at.Usage = AttributeUsageUserApplications
+	case AttributeUsageUserApplications, AttributeUsageDirectoryOperation, AttributeUsageDistributedOperation, AttributeUsageDSAOperation:
+	default:
+		return nil, fmt.Errorf("ldap: invalid usage %q in attribute type %q", at.Usage, str)
+	}
+	if at.Superior == "" && at.Syntax == "" {
+		return nil, fmt.Errorf("ldap: attribute type %q has neither a superior type nor a syntax", str)
+	}
+	return at, nil
+}
+
+// ParseObjectClass parses an ObjectClassDescription of https://tools.ietf.org/html/rfc4512#section-4.1.1
+func ParseObjectClass(str string) (*ObjectClass, error) {
+	desc, err := parseSchemaDescription(str)
+	if err != nil {
+		return nil, err
+	}
+	oc := &ObjectClass{
+		OID:         desc.oid,
+		Names:       desc.values("NAME"),
+		Description: desc.value("DESC"),
+		Obsolete:    desc.has("OBSOLETE"),
+		Superiors:   desc.values("SUP"),
+		Must:        desc.values("MUST"),
+		May:         desc.values("MAY"),
+		Extensions:  desc.extensions,
+	}
+	kinds := 0
+	for kind, keyword := range ObjectClassKindMap {
+		if desc.has(keyword) {
+			oc.Kind = kind
+			kinds++
+		}
+	}
+	if kinds > 1 {
+		return nil, fmt.Errorf("ldap: object class %q has more than one kind", str)
+	}
+	return oc, nil
+}
+
+// ParseMatchingRule parses a MatchingRuleDescription of https://tools.ietf.org/html/rfc4512#section-4.1.3
+func ParseMatchingRule(str string) (*MatchingRule, error) {
+	desc, err := parseSchemaDescription(str)
+	if err != nil {
+		return nil, err
+	}
+	if !desc.has("SYNTAX") {
+		return nil, fmt.Errorf("ldap: matching rule %q has no syntax", str)
+	}
+	return &MatchingRule{
+		OID:         desc.oid,
+		Names:       desc.values("NAME"),
+		Description: desc.value("DESC"),
+		Obsolete:    desc.has("OBSOLETE"),
+		Syntax:      desc.value("SYNTAX"),
+		Extensions:  desc.extensions,
+	}, nil
+}
+
+// ParseLDAPSyntax parses a SyntaxDescription of https://tools.ietf.org/html/rfc4512#section-4.1.5
+func ParseLDAPSyntax(str string) (*LDAPSyntax, error) {
+	desc, err := parseSchemaDescription(str)
+	if err != nil {
+		return nil, err
+	}
+	return &LDAPSyntax{
+		OID:         desc.oid,
+		Description: desc.value("DESC"),
+		Extensions:  desc.extensions,
+	}, nil
+}
+
+// ParseDITContentRule parses a DITContentRuleDescription of https://tools.ietf.org/html/rfc4512#section-4.1.6
+func ParseDITContentRule(str string) (*DITContentRule, error) {
+	desc, err := parseSchemaDescription(str)
+	if err != nil {
+		return nil, err
+	}
+	return &DITContentRule{
+		OID:         desc.oid,
+		Names:       desc.values("NAME"),
+		Description: desc.value("DESC"),
+		Obsolete:    desc.has("OBSOLETE"),
+		Aux:         desc.values("AUX"),
+		Must:        desc.values("MUST"),
+		May:         desc.values("MAY"),
+		Not:         desc.values("NOT"),
+		Extensions:  desc.extensions,
+	}, nil
+}
+
+// ParseNameForm parses a NameFormDescription of https://tools.ietf.org/html/rfc4512#section-4.1.7.2
+func ParseNameForm(str string) (*NameForm, error) {
+	desc, err := parseSchemaDescription(str)
+	if err != nil {
+		return nil, err
+	}
+	if !desc.has("OC") || !desc.has("MUST") {
+		return nil, fmt.Errorf("ldap: name form %q requires an object class and attribute types", str)
+	}
+	return &NameForm{
+		OID:         desc.oid,
+		Names:       desc.values("NAME"),
+		Description: desc.value("DESC"),
+		Obsolete:    desc.has("OBSOLETE"),
+		ObjectClass: desc.value("OC"),
+		Must:        desc.values("MUST"),
+		May:         desc.values("MAY"),
+		Extensions:  desc.extensions,
+	}, nil
+}
+
+// schemaAttributes are the attributes of a subschema entry holding the schema definitions
+var schemaAttributes = []string{"attributeTypes", "objectClasses", "matchingRules", "ldapSyntaxes", "dITContentRules", "nameForms"}
+
+// Schema represents the definitions of a subschema entry, see https://tools.ietf.org/html/rfc4512#section-4.2.
+// The lookup methods use the definitions the schema was created with, so the slices should not be modified.
+type Schema struct {
+	AttributeTypes  []*AttributeType
+	ObjectClasses   []*ObjectClass
+	MatchingRules   []*MatchingRule
+	LDAPSyntaxes    []*LDAPSyntax
+	DITContentRules []*DITContentRule
+	NameForms       []*NameForm
+	// Errors are the errors parsing the definitions which were skipped, as some servers publish
+	// definitions not conforming to RFC 4512
+	Errors []error
+
+	// the definitions by OID and lower case name
+	attributeTypes  map[string]*AttributeType
+	objectClasses   map[string]*ObjectClass
+	matchingRules   map[string]*MatchingRule
+	ldapSyntaxes    map[string]*LDAPSyntax
+	ditContentRules map[string]*DITContentRule
+	nameForms       map[string]*NameForm
+}
+
+// NewSchema returns the schema defined by the attributeTypes, objectClasses, matchingRules, ldapSyntaxes,
+// dITContentRules and nameForms attributes of the given subschema entry. The entry can be created with
+// NewEntry to work with a schema offline. Definitions which cannot be parsed are skipped and reported
+// in Schema.Errors.
+func NewSchema(entry *Entry) *Schema {
+	s := &Schema{
+		attributeTypes:  make(map[string]*AttributeType),
+		objectClasses:   make(map[string]*ObjectClass),
+		matchingRules:   make(map[string]*MatchingRule),
+		ldapSyntaxes:    make(map[string]*LDAPSyntax),
+		ditContentRules: make(map[string]*DITContentRule),
+		nameForms:       make(map[string]*NameForm),
+	}
+	for _, value := range entry.GetEqualFoldAttributeValues("attributeTypes") {
+		at, err := ParseAttributeType(value)
+		if err != nil {
+			s.Errors = append(s.Errors, err)
+			continue
+		}
+		s.AttributeTypes = append(s.AttributeTypes, at)
+		for _, key := range schemaKeys(at.OID, at.Names) {
+			s.attributeTypes[key] = at
+		}
+	}
+	for _, value := range entry.GetEqualFoldAttributeValues("objectClasses") {
+		oc, err := ParseObjectClass(value)
+		if err != nil {
+			s.Errors = append(s.Errors, err)
+			continue
+		}
+		s.ObjectClasses = append(s.ObjectClasses, oc)
+		for _, key := range schemaKeys(oc.OID, oc.Names) {
+			s.objectClasses[key] = oc
+		}
+	}
+	for _, value := range entry.GetEqualFoldAttributeValues("matchingRules") {
+		mr, err := ParseMatchingRule(value)
+		if err != nil {
+			s.Errors = append(s.Errors, err)
+			continue
+		}
+		s.MatchingRules = append(s.MatchingRules, mr)
+		for _, key := range schemaKeys(mr.OID, mr.Names) {
+			s.matchingRules[key] = mr
+		}
+	}
+	for _, value := range entry.GetEqualFoldAttributeValues("ldapSyntaxes") {
+		syntax, err := ParseLDAPSyntax(value)
+		if err != nil {
+			s.Errors = append(s.Errors, err)
+			continue
+		}
+		s.LDAPSyntaxes = append(s.LDAPSyntaxes, syntax)
+		for _, key := range schemaKeys(syntax.OID, nil) {
+			s.ldapSyntaxes[key] = syntax
+		}
+	}
+	for _, value := range entry.GetEqualFoldAttributeValues("dITContentRules") {
+		rule, err := ParseDITContentRule(value)
+		if err != nil {
+			s.Errors = append(s.Errors, err)
+			continue
+		}
+		s.DITContentRules = append(s.DITContentRules, rule)
+		for _, key := range schemaKeys(rule.OID, rule.Names) {
+			s.ditContentRules[key] = rule
+		}
+	}
+	for _, value := range entry.GetEqualFoldAttributeValues("nameForms") {
+		nf, err := ParseNameForm(value)
+		if err != nil {
+			s.Errors = append(s.Errors, err)
+			continue
+		}
+		s.NameForms = append(s.NameForms, nf)
+		for _, key := range schemaKeys(nf.OID, nf.Names) {
+			s.nameForms[key] = nf
+		}
+	}
+	return s
+}
+
+// schemaKeys returns the keys of a definition in the lookup maps of a Schema
+func schemaKeys(oid string, names []string) []string {
+	keys := []string{oid}
+	for _, name := range names {
+		keys = append(keys, strings.ToLower(name))
+	}
+	return keys
+}
+
+// schemaKey returns the key of a name or OID in the lookup maps of a Schema
+func schemaKey(nameOrOID string) string {
+	if len(nameOrOID) > 0 && nameOrOID[0] >= '0' && nameOrOID[0] <= '9' {
+		return nameOrOID
+	}
+	return strings.ToLower(nameOrOID)
+}
+
+// AttributeType returns the attribute type with the given name (compared case-insensitively) or OID, or nil
+func (s *Schema) AttributeType(nameOrOID string) *AttributeType {
+	return s.attributeTypes[schemaKey(nameOrOID)]
+}
+
+// ObjectClass returns the object class with the given name (compared case-insensitively) or OID, or nil
+func (s *Schema) ObjectClass(nameOrOID string) *ObjectClass {
+	return s.objectClasses[schemaKey(nameOrOID)]
+}
+
+// MatchingRule returns the matching rule with the given name (compared case-insensitively) or OID, or nil
+func (s *Schema) MatchingRule(nameOrOID string) *MatchingRule {
+	return s.matchingRules[schemaKey(nameOrOID)]
+}
+
+// LDAPSyntax returns the syntax with the given OID, or nil
+func (s *Schema) LDAPSyntax(oid string) *LDAPSyntax {
+	return s.ldapSyntaxes[oid]
+}
+
+// DITContentRule returns the DIT content rule of the structural object class with the given name
+// (compared case-insensitively) or OID, or nil
+func (s *Schema) DITContentRule(nameOrOID string) *DITContentRule {
+	if rule, ok := s.ditContentRules[schemaKey(nameOrOID)]; ok {
+		return rule
+	}
+	// rules are identified by the OID of their object class, but not necessarily named after it
+	if oc := s.ObjectClass(nameOrOID); oc != nil {
+		return s.ditContentRules[oc.OID]
+	}
+	return nil
+}
+
+// NameForm returns the name form with the given name (compared case-insensitively) or OID, or nil
+func (s *Schema) NameForm(nameOrOID string) *NameForm {
+	return s.nameForms[schemaKey(nameOrOID)]
+}
+
+// AttributeTypeChain returns the attribute type with the given name or OID followed by its superior types,
+// closest first. Superior types missing from the schema end the chain.
+func (s *Schema) AttributeTypeChain(nameOrOID string) []*AttributeType {
+	var chain []*AttributeType
+	seen := make(map[*AttributeType]bool)
+	for at := s.AttributeType(nameOrOID); at != nil && !seen[at]; at = s.AttributeType(at.Superior) {
+		seen[at] = true
+		chain = append(chain, at)
+		if at.Superior == "" {
+			break
+		}
+	}
+	return chain
+}
+
+// AttributeSyntax returns the syntax OID of the attribute type with the given name or OID, inherited
+// from its superior types if needed, or "" if unknown
+func (s *Schema) AttributeSyntax(nameOrOID string) string {
+	for _, at := range s.AttributeTypeChain(nameOrOID) {
+		if at.Syntax != "" {
+			return at.Syntax
+		}
+	}
+	return ""
+}
+
+// AttributeEquality returns the equality matching rule of the attribute type with the given name or OID,
+// inherited from its superior types if needed, or "" if unknown
+func (s *Schema) AttributeEquality(nameOrOID string) string {
+	for _, at := range s.AttributeTypeChain(nameOrOID) {
+		if at.Equality != "" {
+			return at.Equality
+		}
+	}
+	return ""
+}
+
+// ObjectClassChain returns the object class with the given name or OID followed by all its superior
+// classes, without duplicates. Superior classes missing from the schema are skipped.
+func (s *Schema) ObjectClassChain(nameOrOID string) []*ObjectClass {
+	oc := s.ObjectClass(nameOrOID)
+	if oc == nil {
+		return nil
+	}
+	chain := []*ObjectClass{oc}
+	seen := map[*ObjectClass]bool{oc: true}
+	for i := 0; i < len(chain); i++ {
+		for _, sup := range chain[i].Superiors {
+			if sup := s.ObjectClass(sup); sup != nil && !seen[sup] {
+				seen[sup] = true
+				chain = append(chain, sup)
+			}
+		}
+	}
+	return chain
+}
+
+// RequiredAttributes returns the names or OIDs of the attribute types required by the given object
+// classes, including their superior classes and the DIT content rules of the structural ones, as
+// written in the schema and without duplicates
+func (s *Schema) RequiredAttributes(objectClasses ...string) []string {
+	must, _ := s.objectClassAttributes(objectClasses)
+	return must
+}
+
+// AllowedAttributes returns the names or OIDs of the attribute types allowed, but not required, by the
+// given object classes, including their superior classes and the DIT content rules of the structural
+// ones, as written in the schema and without duplicates
+func (s *Schema) AllowedAttributes(objectClasses ...string) []string {
+	_, may := s.objectClassAttributes(objectClasses)
+	return may
+}
+
+func (s *Schema) objectClassAttributes(objectClasses []string) (must, may []string) {
+	seen := make(map[string]bool)
+	add := func(attrs []string, to *[]string) {
+		for _, attr := range attrs {
+			key := s.attributeKey(attr)
+			if !seen[key] {
+				seen[key] = true
+				*to = append(*to, attr)
+			}
+		}
+	}
+
+	var mayLists [][]string
+	for _, name := range objectClasses {
+		for _, oc := range s.ObjectClassChain(name) {
+			add(oc.Must, &must)
+			mayLists = append(mayLists, oc.May)
+		}
+		if oc := s.ObjectClass(name); oc != nil && oc.Kind == ObjectClassStructural {
+			if rule := s.DITContentRule(oc.OID); rule != nil && !rule.Obsolete {
+				add(rule.Must, &must)
+				mayLists = append(mayLists, rule.May)
+			}
+		}
+	}
+	// required attributes take precedence over allowed ones
+	for _, attrs := range mayLists {
+		add(attrs, &may)
+	}
+	return must, may
+}
+
+// attributeKey returns a key identifying the attribute type with the given name or OID, so that
+// different names of the same attribute type compare equal
+func (s *Schema) attributeKey(nameOrOID string) string {
+	if at := s.AttributeType(nameOrOID); at != nil {
+		return at.OID
+	}
+	return schemaKey(nameOrOID)
+}
+
+// Schema reads the subschema entry named by the subschemaSubentry attribute of the root DSE, falling
+// back to "cn=Subschema" if the server does not announce one
+func (l *Conn) Schema() (*Schema, error) {
+	dn := "cn=Subschema"
+	if rootDSE, err := l.RootDSE(); err == nil && rootDSE.SubschemaSubentry != "" {
+		dn = rootDSE.SubschemaSubentry
+	}
+
+	searchRequest := NewSearchRequest(dn, ScopeBaseObject, NeverDerefAliases, 0, 0, false, "(objectClass=subschema)", schemaAttributes, nil)
+	result, err := l.Search(searchRequest)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Entries) != 1 {
+		return nil, NewError(ErrorUnexpectedResponse, fmt.Errorf("ldap: subschema entry %q not returned by the server", dn))
+	}
+	return NewSchema(result.Entries[0]), nil
+}
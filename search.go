@@ -311,7 +311,6 @@ func (l *Conn) SearchWithPaging(searchRequest *SearchRequest, pagingSize uint32)
 	searchResult := new(SearchResult)
 	for {
 		result, err := l.Search(searchRequest)
-		l.Debug.Printf("Looking for Paging Control...")
 		if err != nil {
 			return searchResult, err
 		}
@@ -329,25 +328,24 @@ func (l *Conn) SearchWithPaging(searchRequest *SearchRequest, pagingSize uint32)
 			searchResult.Controls = append(searchResult.Controls, control)
 		}
 
-		l.Debug.Printf("Looking for Paging Control...")
 		pagingResult := FindControl(result.Controls, ControlTypePaging)
 		if pagingResult == nil {
 			pagingControl = nil
-			l.Debug.Printf("Could not find paging control.  Breaking...")
+			l.log(LevelDebug, "could not find paging control, stopping paging")
 			break
 		}
 
 		cookie := pagingResult.(*ControlPaging).Cookie
 		if len(cookie) == 0 {
 			pagingControl = nil
-			l.Debug.Printf("Could not find cookie.  Breaking...")
+			l.log(LevelDebug, "could not find paging cookie, stopping paging")
 			break
 		}
 		pagingControl.SetCookie(cookie)
 	}
 
 	if pagingControl != nil {
-		l.Debug.Printf("Abandoning Paging...")
+		l.log(LevelDebug, "abandoning paging")
 		pagingControl.PagingSize = 0
 		l.Search(searchRequest)
 	}
@@ -357,6 +355,14 @@ func (l *Conn) SearchWithPaging(searchRequest *SearchRequest, pagingSize uint32)
 
 // Search performs the given search request
 func (l *Conn) Search(searchRequest *SearchRequest) (*SearchResult, error) {
+	result, err := l.search(searchRequest)
+	if l.referralOptions == nil {
+		return result, err
+	}
+	return l.chaseSearchReferrals(searchRequest, result, err, 0, map[string]bool{})
+}
+
+func (l *Conn) search(searchRequest *SearchRequest) (*SearchResult, error) {
 	msgCtx, err := l.doRequest(searchRequest)
 	if err != nil {
 		return nil, err
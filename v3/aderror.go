@@ -0,0 +1,129 @@
+package ldap
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Errors reported by Active Directory, identified by the data code or the Win32 error code of its
+// diagnostic messages, see https://ldapwiki.com/wiki/Common%20Active%20Directory%20Bind%20Errors.
+// An *Error is one of them for errors.Is if its ADDetails identify it.
+var (
+	// ErrADUserNotFound is returned when binding as an unknown user (data 525)
+	ErrADUserNotFound = errors.New("ldap: Active Directory user not found")
+	// ErrADInvalidCredentials is returned for a wrong password (data 52e), including the old password
+	// of a password change (error 00000056)
+	ErrADInvalidCredentials = errors.New("ldap: invalid Active Directory credentials")
+	// ErrADInvalidLogonHours is returned when binding outside of the permitted logon hours (data 530)
+	ErrADInvalidLogonHours = errors.New("ldap: Active Directory logon not permitted at this time")
+	// ErrADInvalidWorkstation is returned when binding from a workstation not permitted (data 531)
+	ErrADInvalidWorkstation = errors.New("ldap: Active Directory logon not permitted from this workstation")
+	// ErrADPasswordExpired is returned if the password has expired (data 532)
+	ErrADPasswordExpired = errors.New("ldap: Active Directory password expired")
+	// ErrADAccountDisabled is returned if the account is disabled (data 533)
+	ErrADAccountDisabled = errors.New("ldap: Active Directory account disabled")
+	// ErrADAccountExpired is returned if the account has expired (data 701)
+	ErrADAccountExpired = errors.New("ldap: Active Directory account expired")
+	// ErrADPasswordMustChange is returned if the password must be changed before binding (data 773)
+	ErrADPasswordMustChange = errors.New("ldap: Active Directory password must be changed")
+	// ErrADAccountLockedOut is returned if the account is locked out (data 775)
+	ErrADAccountLockedOut = errors.New("ldap: Active Directory account locked out")
+	// ErrADPasswordRestriction is returned if a new password does not satisfy the password policy,
+	// e.g. its length, complexity or history requirements (error 0000052D)
+	ErrADPasswordRestriction = errors.New("ldap: Active Directory password restriction")
+)
+
+// adDataErrors are the errors identified by the data code of an Active Directory diagnostic message
+var adDataErrors = map[uint32]error{
+	0x525: ErrADUserNotFound,
+	0x52e: ErrADInvalidCredentials,
+	0x530: ErrADInvalidLogonHours,
+	0x531: ErrADInvalidWorkstation,
+	0x532: ErrADPasswordExpired,
+	0x533: ErrADAccountDisabled,
+	0x701: ErrADAccountExpired,
+	0x773: ErrADPasswordMustChange,
+	0x775: ErrADAccountLockedOut,
+}
+
+// adWin32Errors are the errors identified by the Win32 error code of an Active Directory diagnostic
+// message, if its data code identifies none
+var adWin32Errors = map[uint32]error{
+	0x56:  ErrADInvalidCredentials,
+	0x52d: ErrADPasswordRestriction,
+}
+
+// ADErrorDetails holds the details of an Active Directory diagnostic message, e.g.
+// "80090308: LdapErr: DSID-0C09044E, comment: AcceptSecurityContext error, data 52e, v4563"
+type ADErrorDetails struct {
+	// Win32Error is the Win32 error code starting the message, e.g. 0x80090308
+	Win32Error uint32
+	// Kind is the kind of error following the Win32 error code, e.g. "LdapErr" or "SvcErr"
+	Kind string
+	// DSID identifies where the error occurred within Active Directory, e.g. "0C09044E"
+	DSID string
+	// Comment is the comment of the message, if any, e.g. "AcceptSecurityContext error"
+	Comment string
+	// Problem is the problem code of the message, if any, e.g. 5003 for WILL_NOT_PERFORM
+	Problem int
+	// Data is the data code of the message, if any, e.g. 0x52e for invalid credentials
+	Data uint32
+}
+
+// ParseADErrorDetails parses an Active Directory diagnostic message, returning nil if it does not
+// start with a Win32 error code
+func ParseADErrorDetails(message string) *ADErrorDetails {
+	i := strings.IndexByte(message, ':')
+	if i != 8 {
+		return nil
+	}
+	win32Error, err := strconv.ParseUint(message[:i], 16, 32)
+	if err != nil {
+		return nil
+	}
+	details := &ADErrorDetails{Win32Error: uint32(win32Error)}
+
+	rest := strings.TrimLeft(message[i+1:], " ")
+	if j := strings.IndexByte(rest, ':'); j >= 0 {
+		details.Kind = rest[:j]
+	}
+	if field := adMessageField(message, "DSID-"); field != "" {
+		details.DSID = field
+	}
+	if j := strings.Index(message, "comment: "); j >= 0 {
+		comment := message[j+len("comment: "):]
+		if k := strings.Index(comment, ", "); k >= 0 {
+			comment = comment[:k]
+		}
+		details.Comment = strings.TrimSpace(comment)
+	}
+	if problem, err := strconv.Atoi(adMessageField(message, "problem ")); err == nil {
+		details.Problem = problem
+	}
+	if data, err := strconv.ParseUint(adMessageField(message, "data "), 16, 32); err == nil {
+		details.Data = uint32(data)
+	}
+	return details
+}
+
+// adMessageField returns the word following the first occurrence of prefix in the message, or ""
+func adMessageField(message, prefix string) string {
+	i := strings.Index(message, prefix)
+	if i < 0 {
+		return ""
+	}
+	field := message[i+len(prefix):]
+	if j := strings.IndexAny(field, ", \t\n"); j >= 0 {
+		field = field[:j]
+	}
+	return field
+}
+
+// Err returns the ErrAD* error identified by the details, or nil
+func (d *ADErrorDetails) Err() error {
+	if err, ok := adDataErrors[d.Data]; ok {
+		return err
+	}
+	return adWin32Errors[d.Win32Error]
+}
@@ -0,0 +1,76 @@
+package ldap
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+)
+
+func TestADPassword(t *testing.T) {
+	testcases := []struct {
+		resultCode int64
+		message    string
+		want       error
+	}{
+		{LDAPResultSuccess, "", nil},
+		{LDAPResultConstraintViolation, "00000056: AtrErr: DSID-03190F80, #1:\n\t0: 00000056: DSID-03190F80, problem 1005 (CONSTRAINT_ATT_TYPE), data 0, Att 9005a (unicodePwd)\n", ErrADInvalidCredentials},
+		{LDAPResultUnwillingToPerform, "0000052D: SvcErr: DSID-031A12D2, problem 5003 (WILL_NOT_PERFORM), data 0\n", ErrADPasswordRestriction},
+		{LDAPResultInvalidCredentials, "80090308: LdapErr: DSID-0C09044E, comment: AcceptSecurityContext error, data 775, v4563", ErrADAccountLockedOut},
+		{LDAPResultInvalidCredentials, "80090308: LdapErr: DSID-0C09044E, comment: AcceptSecurityContext error, data 773, v4563", ErrADPasswordMustChange},
+		{LDAPResultInvalidCredentials, "80090308: LdapErr: DSID-0C09044E, comment: AcceptSecurityContext error, data 532, v4563", ErrADPasswordExpired},
+		{LDAPResultUnwillingToPerform, "00002077: SvcErr: DSID-03190E44, problem 5003 (WILL_NOT_PERFORM), data 0\n", nil},
+	}
+	for _, tc := range testcases {
+		var changes []*ber.Packet
+		conn := newTestServerConn(t, func(request *ber.Packet) []*ber.Packet {
+			changes = request.Children[1].Children[1].Children
+			return []*ber.Packet{newTestLDAPResultWithMessage(ApplicationModifyResponse, tc.resultCode, tc.message)}
+		})
+
+		err := conn.ChangeADPassword("cn=a,dc=example,dc=org", "old", "new")
+		if len(changes) != 2 || changes[0].Children[0].Value != int64(DeleteAttribute) || changes[1].Children[0].Value != int64(AddAttribute) ||
+			!bytes.Equal(changes[1].Children[1].Children[1].Children[0].Data.Bytes(), EncodeUnicodePwd("new")) {
+			t.Errorf("unexpected password change request %v", changes)
+		}
+		checkADPasswordError(t, err, tc.resultCode, tc.want)
+
+		err = conn.ResetADPassword("cn=a,dc=example,dc=org", "new", true)
+		if len(changes) != 2 || changes[0].Children[0].Value != int64(ReplaceAttribute) || changes[1].Children[1].Children[0].Value != "pwdLastSet" {
+			t.Errorf("unexpected password reset request %v", changes)
+		}
+		checkADPasswordError(t, err, tc.resultCode, tc.want)
+		conn.Close()
+	}
+}
+
+func checkADPasswordError(t *testing.T, err error, resultCode int64, want error) {
+	if resultCode == LDAPResultSuccess {
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+		return
+	}
+	ldapErr, ok := err.(*Error)
+	if !ok || int64(ldapErr.ResultCode) != resultCode {
+		t.Errorf("unexpected error %v", err)
+		return
+	}
+	if want == nil {
+		if ldapErr.ADDetails == nil || ldapErr.ADDetails.Err() != nil {
+			t.Errorf("unexpected error %v (%+v)", err, ldapErr.ADDetails)
+		}
+	} else if !errors.Is(err, want) {
+		t.Errorf("got %v, want %v", err, want)
+	}
+}
+
+// newTestLDAPResultWithMessage returns an LDAPResult protocol op with the given diagnostic message
+func newTestLDAPResultWithMessage(application ber.Tag, resultCode int64, message string) *ber.Packet {
+	response := ber.Encode(ber.ClassApplication, ber.TypeConstructed, application, nil, ApplicationMap[uint8(application)])
+	response.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, resultCode, "resultCode"))
+	response.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "matchedDN"))
+	response.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, message, "diagnosticMessage"))
+	return response
+}
@@ -73,21 +73,8 @@ func (l *Conn) SimpleBind(simpleBindRequest *SimpleBindRequest) (*SimpleBindResu
 		return nil, err
 	}
 
-	result := &SimpleBindResult{
-		Controls: make([]Control, 0),
-	}
-
-	if len(packet.Children) == 3 {
-		for _, child := range packet.Children[2].Children {
-			decodedChild, decodeErr := DecodeControl(child)
-			if decodeErr != nil {
-				return nil, fmt.Errorf("failed to decode child control: %s", decodeErr)
-			}
-			result.Controls = append(result.Controls, decodedChild)
-		}
-	}
-
-	err = GetLDAPError(packet)
+	result := &SimpleBindResult{}
+	result.Controls, err = decodeResultControls(packet)
 	return result, err
 }
 
@@ -180,13 +167,6 @@ func (l *Conn) DigestMD5Bind(digestMD5BindRequest *DigestMD5BindRequest) (*Diges
 	if err != nil {
 		return nil, err
 	}
-	l.Debug.Printf("%d: got response %p", msgCtx.id, packet)
-	if l.Debug {
-		if err = addLDAPDescriptions(packet); err != nil {
-			return nil, err
-		}
-		ber.PrintPacket(packet)
-	}
 
 	result := &DigestMD5BindResult{
 		Controls: make([]Control, 0),
@@ -245,10 +225,10 @@ func (l *Conn) DigestMD5Bind(digestMD5BindRequest *DigestMD5BindRequest) (*Diges
 			return nil, NewError(ErrorNetwork, errors.New("ldap: response channel closed"))
 		}
 		packet, err = packetResponse.ReadPacket()
-		l.Debug.Printf("%d: got response %p", msgCtx.id, packet)
 		if err != nil {
 			return nil, fmt.Errorf("read packet: %s", err)
 		}
+		l.logPacket("got response", packet, logMessage(msgCtx)...)
 	}
 
 	err = GetLDAPError(packet)
@@ -468,13 +448,6 @@ func (l *Conn) NTLMChallengeBind(ntlmBindRequest *NTLMBindRequest) (*NTLMBindRes
 	if err != nil {
 		return nil, err
 	}
-	l.Debug.Printf("%d: got response %p", msgCtx.id, packet)
-	if l.Debug {
-		if err = addLDAPDescriptions(packet); err != nil {
-			return nil, err
-		}
-		ber.PrintPacket(packet)
-	}
 	result := &NTLMBindResult{
 		Controls: make([]Control, 0),
 	}
@@ -489,7 +462,7 @@ func (l *Conn) NTLMChallengeBind(ntlmBindRequest *NTLMBindRequest) (*NTLMBindRes
 			if !bytes.Equal(ntlmsspChallenge[:7], []byte("NTLMSSP")) {
 				return result, GetLDAPError(packet)
 			}
-			l.Debug.Printf("%d: found ntlmssp challenge", msgCtx.id)
+			l.log(LevelDebug, "found NTLMSSP challenge", logMessage(msgCtx)...)
 		}
 	}
 	if ntlmsspChallenge != nil {
@@ -528,10 +501,10 @@ func (l *Conn) NTLMChallengeBind(ntlmBindRequest *NTLMBindRequest) (*NTLMBindRes
 			return nil, NewError(ErrorNetwork, errors.New("ldap: response channel closed"))
 		}
 		packet, err = packetResponse.ReadPacket()
-		l.Debug.Printf("%d: got response %p", msgCtx.id, packet)
 		if err != nil {
 			return nil, fmt.Errorf("read packet: %s", err)
 		}
+		l.logPacket("got response", packet, logMessage(msgCtx)...)
 
 	}
 
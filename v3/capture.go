@@ -0,0 +1,366 @@
+package ldap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+)
+
+// CaptureDirection is the direction of a captured LDAP message
+type CaptureDirection byte
+
+// Capture directions
+const (
+	// CaptureSent marks the messages sent by the client, i.e. requests
+	CaptureSent CaptureDirection = '>'
+	// CaptureReceived marks the messages received by the client, i.e. responses
+	CaptureReceived CaptureDirection = '<'
+)
+
+// String returns a description of the direction
+func (d CaptureDirection) String() string {
+	switch d {
+	case CaptureSent:
+		return "client -> server"
+	case CaptureReceived:
+		return "server -> client"
+	}
+	return fmt.Sprintf("CaptureDirection(%d)", byte(d))
+}
+
+// CaptureRecord is an LDAP message captured by a CaptureConn
+type CaptureRecord struct {
+	// Direction is the direction of the message
+	Direction CaptureDirection
+	// Time is the time the message was sent or received
+	Time time.Time
+	// MessageID is the message ID of the message
+	MessageID int64
+	// Packet is the BER encoding of the message
+	Packet []byte
+}
+
+// captureRecordHeaderSize is the size of the header of a record in a capture, made of the direction,
+// the time in nanoseconds since the Unix epoch and the size of the message
+const captureRecordHeaderSize = 1 + 8 + 4
+
+// maxCapturedPacketSize bounds the size of the messages read from a capture
+const maxCapturedPacketSize = 64 << 20
+
+// CaptureConn is a net.Conn recording the LDAP messages read from and written to the connection it
+// wraps to a capture, for ReadCaptureRecord, PrintCapture and ReplayServer. Messages must not be
+// encrypted by the wrapped connection, so a CaptureConn must wrap a TLS connection rather than being
+// wrapped by one, and StartTLS fails on connections using a CaptureConn. The capture holds the
+// messages as sent, credentials included, so it must be protected accordingly.
+type CaptureConn struct {
+	net.Conn
+
+	mutex    sync.Mutex
+	w        io.Writer
+	err      error
+	sent     bytes.Buffer
+	received bytes.Buffer
+}
+
+// NewCaptureConn returns a CaptureConn wrapping conn and writing the capture to w, e.g. a file
+func NewCaptureConn(conn net.Conn, w io.Writer) *CaptureConn {
+	return &CaptureConn{Conn: conn, w: w}
+}
+
+// DialWithCapture records the LDAP messages of the connection to w, see CaptureConn
+func DialWithCapture(w io.Writer) DialOpt {
+	return func(dc *DialContext) {
+		dc.capture = w
+	}
+}
+
+// Read reads from the connection, capturing the messages received
+func (c *CaptureConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if n > 0 {
+		c.capture(CaptureReceived, &c.received, b[:n])
+	}
+	return n, err
+}
+
+// Write writes to the connection, capturing the messages sent. They are captured before being written,
+// as the responses could otherwise be read and captured first.
+func (c *CaptureConn) Write(b []byte) (int, error) {
+	c.capture(CaptureSent, &c.sent, b)
+	return c.Conn.Write(b)
+}
+
+// Err returns the first error writing the capture, after which messages are not captured anymore
+func (c *CaptureConn) Err() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.err
+}
+
+// capture appends the given bytes to the buffer of their direction and writes the complete messages
+// it holds to the capture
+func (c *CaptureConn) capture(direction CaptureDirection, buf *bytes.Buffer, b []byte) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.err != nil {
+		return
+	}
+	buf.Write(b)
+	for {
+		size, ok := berElementSize(buf.Bytes())
+		if !ok || size > buf.Len() {
+			return
+		}
+		if err := writeCaptureRecord(c.w, direction, time.Now(), buf.Next(size)); err != nil {
+			c.err = err
+			return
+		}
+	}
+}
+
+func writeCaptureRecord(w io.Writer, direction CaptureDirection, t time.Time, packet []byte) error {
+	header := make([]byte, captureRecordHeaderSize)
+	header[0] = byte(direction)
+	binary.BigEndian.PutUint64(header[1:], uint64(t.UnixNano()))
+	binary.BigEndian.PutUint32(header[9:], uint32(len(packet)))
+	if _, err := w.Write(append(header, packet...)); err != nil {
+		return NewError(ErrorDebugging, err)
+	}
+	return nil
+}
+
+// berElementSize returns the size of the BER element starting the given bytes, and false if its
+// header is not complete yet
+func berElementSize(b []byte) (int, bool) {
+	if len(b) < 2 {
+		return 0, false
+	}
+	i := 1
+	if b[0]&0x1f == 0x1f {
+		// high tag number
+		for ; i < len(b) && b[i]&0x80 != 0; i++ {
+		}
+		i++
+	}
+	if i >= len(b) {
+		return 0, false
+	}
+	length := int(b[i])
+	i++
+	if length&0x80 != 0 {
+		n := length & 0x7f
+		if len(b) < i+n {
+			return 0, false
+		}
+		length = 0
+		for _, c := range b[i : i+n] {
+			length = length<<8 | int(c)
+		}
+		i += n
+	}
+	return i + length, true
+}
+
+// ReadCaptureRecord reads the next record of a capture written by a CaptureConn. It returns io.EOF
+// at the end of the capture.
+func ReadCaptureRecord(r io.Reader) (*CaptureRecord, error) {
+	header := make([]byte, captureRecordHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		if err == io.EOF {
+			return nil, err
+		}
+		return nil, NewError(ErrorDebugging, fmt.Errorf("ldap: cannot read capture record: %s", err))
+	}
+	direction := CaptureDirection(header[0])
+	if direction != CaptureSent && direction != CaptureReceived {
+		return nil, NewError(ErrorDebugging, fmt.Errorf("ldap: invalid capture record direction %d", header[0]))
+	}
+	size := binary.BigEndian.Uint32(header[9:])
+	if size > maxCapturedPacketSize {
+		return nil, NewError(ErrorDebugging, fmt.Errorf("ldap: capture record of %d bytes too large", size))
+	}
+	record := &CaptureRecord{
+		Direction: direction,
+		Time:      time.Unix(0, int64(binary.BigEndian.Uint64(header[1:]))),
+		Packet:    make([]byte, size),
+	}
+	if _, err := io.ReadFull(r, record.Packet); err != nil {
+		return nil, NewError(ErrorDebugging, fmt.Errorf("ldap: cannot read capture record: %s", err))
+	}
+
+	packet, err := record.decode()
+	if err != nil {
+		return nil, err
+	}
+	record.MessageID = packet.Children[0].Value.(int64)
+	return record, nil
+}
+
+// ReadCapture reads all the records of a capture written by a CaptureConn
+func ReadCapture(r io.Reader) ([]*CaptureRecord, error) {
+	var records []*CaptureRecord
+	for {
+		record, err := ReadCaptureRecord(r)
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return records, err
+		}
+		records = append(records, record)
+	}
+}
+
+// decode decodes the LDAP message of the record
+func (r *CaptureRecord) decode() (*ber.Packet, error) {
+	packet, err := ber.DecodePacketErr(r.Packet)
+	if err != nil {
+		return nil, NewError(ErrorDebugging, fmt.Errorf("ldap: cannot decode captured message: %s", err))
+	}
+	if len(packet.Children) < 2 {
+		return nil, NewError(ErrorDebugging, errors.New("ldap: captured message is not an LDAP message"))
+	}
+	if _, ok := packet.Children[0].Value.(int64); !ok {
+		return nil, NewError(ErrorDebugging, errors.New("ldap: captured message has no message ID"))
+	}
+	return packet, nil
+}
+
+// PrintCapture writes the messages of a capture written by a CaptureConn to w, as DebugBinaryFile
+// and with their credentials masked by the given Redactor, or by DefaultRedactor if nil
+func PrintCapture(w io.Writer, r io.Reader, redactor *Redactor) error {
+	var start time.Time
+	for i := 1; ; i++ {
+		record, err := ReadCaptureRecord(r)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if start.IsZero() {
+			start = record.Time
+		}
+
+		packet, _ := record.decode()
+		if err := addLDAPDescriptions(packet); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "#%d %s +%s %s messageID=%d\n", i, record.Time.Format(time.RFC3339Nano), record.Time.Sub(start),
+			record.Direction, record.MessageID)
+		ber.WritePacket(w, redactor.Redact(packet))
+		fmt.Fprintln(w)
+	}
+}
+
+// ReplayServer answers the requests of connections with the responses of a capture, e.g. to reproduce
+// a session in a regression test. Each request must match the next request of the capture, after
+// which the responses following it in the capture are sent, with the message ID of the request.
+// Sessions with concurrent requests can only be replayed if they are sent in the same order.
+type ReplayServer struct {
+	// Strict requires requests to be identical to the captured ones, except for their message ID.
+	// Otherwise only their protocol op must match.
+	Strict bool
+
+	records []*CaptureRecord
+	mutex   sync.Mutex
+	err     error
+}
+
+// NewReplayServer returns a ReplayServer replaying the given capture records
+func NewReplayServer(records []*CaptureRecord) *ReplayServer {
+	return &ReplayServer{records: records}
+}
+
+// Dial returns a started Conn replaying the capture from its beginning
+func (s *ReplayServer) Dial() *Conn {
+	client, server := net.Pipe()
+	go s.serve(server)
+	conn := NewConn(client, false)
+	conn.Start()
+	return conn
+}
+
+// Err returns the first request which did not match the capture, after which the connection was closed
+func (s *ReplayServer) Err() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.err
+}
+
+func (s *ReplayServer) fail(conn net.Conn, err error) {
+	s.mutex.Lock()
+	if s.err == nil {
+		s.err = err
+	}
+	s.mutex.Unlock()
+	conn.Close()
+}
+
+func (s *ReplayServer) serve(conn net.Conn) {
+	defer conn.Close()
+	next := 0
+	for {
+		request, err := ber.ReadPacket(conn)
+		if err != nil {
+			return
+		}
+		for next < len(s.records) && s.records[next].Direction != CaptureSent {
+			next++
+		}
+		if next == len(s.records) {
+			s.fail(conn, NewError(ErrorDebugging, errors.New("ldap: request after the end of the capture")))
+			return
+		}
+		captured, err := s.records[next].decode()
+		if err != nil {
+			s.fail(conn, err)
+			return
+		}
+		if err := s.match(request, captured); err != nil {
+			s.fail(conn, NewError(ErrorDebugging, fmt.Errorf("ldap: request %d does not match the capture: %s", next+1, err)))
+			return
+		}
+
+		for next++; next < len(s.records) && s.records[next].Direction == CaptureReceived; next++ {
+			response, err := s.records[next].decode()
+			if err != nil {
+				s.fail(conn, err)
+				return
+			}
+			messageID := response.Children[0].Value.(int64)
+			if messageID == captured.Children[0].Value.(int64) {
+				messageID = request.Children[0].Value.(int64)
+			}
+			if _, err := conn.Write(withMessageID(response, messageID).Bytes()); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// match returns an error if the request does not match the captured one
+func (s *ReplayServer) match(request, captured *ber.Packet) error {
+	if len(request.Children) < 2 {
+		return errors.New("not an LDAP message")
+	}
+	if request.Children[1].ClassType != captured.Children[1].ClassType || request.Children[1].Tag != captured.Children[1].Tag {
+		return fmt.Errorf("got %s, want %s", operationName(request), operationName(captured))
+	}
+	if s.Strict && !bytes.Equal(withMessageID(request, 0).Bytes(), withMessageID(captured, 0).Bytes()) {
+		return fmt.Errorf("%s differs", operationName(request))
+	}
+	return nil
+}
+
+// withMessageID returns a copy of the LDAP message with the given message ID
+func withMessageID(packet *ber.Packet, messageID int64) *ber.Packet {
+	return replaceChild(packet, 0, ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, messageID, "MessageID"))
+}
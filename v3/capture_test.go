@@ -0,0 +1,165 @@
+package ldap
+
+import (
+	"bytes"
+	"crypto/tls"
+	"net"
+	"strings"
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+)
+
+// newTestCaptureConn returns a started Conn capturing its messages to capture, whose requests are
+// answered by the responses returned by handler
+func newTestCaptureConn(t *testing.T, capture *bytes.Buffer, handler func(request *ber.Packet) []*ber.Packet) *Conn {
+	client, server := net.Pipe()
+	go func() {
+		defer server.Close()
+		for {
+			request, err := ber.ReadPacket(server)
+			if err != nil {
+				return
+			}
+			for _, response := range handler(request) {
+				packet := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+				packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, request.Children[0].Value, "MessageID"))
+				packet.AppendChild(response)
+				// split the response to check that messages are reassembled
+				b := packet.Bytes()
+				if _, err := server.Write(b[:3]); err != nil {
+					return
+				}
+				if _, err := server.Write(b[3:]); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	conn := NewConn(NewCaptureConn(client, capture), false)
+	conn.Start()
+	return conn
+}
+
+func TestCaptureAndReplay(t *testing.T) {
+	var capture bytes.Buffer
+	conn := newTestCaptureConn(t, &capture, func(request *ber.Packet) []*ber.Packet {
+		switch request.Children[1].Tag {
+		case ApplicationBindRequest:
+			return []*ber.Packet{newTestLDAPResult(ApplicationBindResponse, LDAPResultSuccess)}
+		case ApplicationSearchRequest:
+			return []*ber.Packet{
+				newTestSearchResultEntryWithAttributes("cn=a,dc=example,dc=org", map[string][]string{"sn": {"Smith"}}),
+				newTestLDAPResult(ApplicationSearchResultDone, LDAPResultSuccess),
+			}
+		default:
+			return []*ber.Packet{newTestLDAPResult(ApplicationDelResponse, LDAPResultNoSuchObject)}
+		}
+	})
+	search := NewSearchRequest("dc=example,dc=org", ScopeWholeSubtree, NeverDerefAliases, 0, 0, false, "(sn=Smith)", nil, nil)
+	session := func(conn *Conn) {
+		if err := conn.Bind("cn=admin,dc=example,dc=org", "s3cr3t"); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		result, err := conn.Search(search)
+		if err != nil || len(result.Entries) != 1 || result.Entries[0].GetAttributeValue("sn") != "Smith" {
+			t.Fatalf("unexpected search result %v, %v", result, err)
+		}
+		if err := conn.Del(NewDelRequest("cn=b,dc=example,dc=org", nil)); !IsErrorWithCode(err, LDAPResultNoSuchObject) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	session(conn)
+	conn.Close()
+
+	records, err := ReadCapture(bytes.NewReader(capture.Bytes()))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	want := []struct {
+		direction CaptureDirection
+		messageID int64
+	}{
+		{CaptureSent, 1}, {CaptureReceived, 1},
+		{CaptureSent, 2}, {CaptureReceived, 2}, {CaptureReceived, 2},
+		{CaptureSent, 3}, {CaptureReceived, 3},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("got %d records, want %d", len(records), len(want))
+	}
+	for i, record := range records {
+		if record.Direction != want[i].direction || record.MessageID != want[i].messageID || record.Time.IsZero() {
+			t.Errorf("record %d: got %s %d at %s, want %s %d", i, record.Direction, record.MessageID, record.Time, want[i].direction, want[i].messageID)
+		}
+	}
+
+	var printed bytes.Buffer
+	if err := PrintCapture(&printed, bytes.NewReader(capture.Bytes()), nil); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for _, s := range []string{"#1 ", "client -> server messageID=1", "Bind Request", "Search Result Entry", "#7 ", "Del Response"} {
+		if !strings.Contains(printed.String(), s) {
+			t.Errorf("expected %q in the printed capture:\n%s", s, printed.String())
+		}
+	}
+	if strings.Contains(printed.String(), "s3cr3t") {
+		t.Errorf("expected the password to be redacted:\n%s", printed.String())
+	}
+
+	// the replayed session gets the same responses, even with other message IDs
+	server := NewReplayServer(records)
+	server.Strict = true
+	conn = server.Dial()
+	<-conn.chanMessageID
+	session(conn)
+	conn.Close()
+	if err := server.Err(); err != nil {
+		t.Errorf("unexpected replay error: %s", err)
+	}
+
+	// a diverging session fails
+	conn = server.Dial()
+	if err := conn.Bind("cn=admin,dc=example,dc=org", "other"); err == nil {
+		t.Error("expected an error for a request not matching the capture")
+	}
+	conn.Close()
+	if err := server.Err(); err == nil || !strings.Contains(err.Error(), "Bind Request differs") {
+		t.Errorf("unexpected replay error: %v", err)
+	}
+}
+
+func TestCaptureStartTLS(t *testing.T) {
+	var capture bytes.Buffer
+	conn := newTestCaptureConn(t, &capture, func(request *ber.Packet) []*ber.Packet {
+		t.Errorf("unexpected request %v", request)
+		return nil
+	})
+	defer conn.Close()
+
+	if err := conn.StartTLS(&tls.Config{InsecureSkipVerify: true}); err == nil {
+		t.Error("expected an error starting TLS on a captured connection")
+	}
+	if capture.Len() != 0 {
+		t.Errorf("unexpected capture %q", capture.String())
+	}
+}
+
+func TestBERElementSize(t *testing.T) {
+	testcases := []struct {
+		b    []byte
+		size int
+		ok   bool
+	}{
+		{[]byte{0x30}, 0, false},
+		{[]byte{0x30, 0x03, 0x02}, 5, true},
+		{[]byte{0x30, 0x82, 0x01}, 0, false},
+		{[]byte{0x30, 0x82, 0x01, 0x00}, 260, true},
+		{[]byte{0x1f, 0x81, 0x01, 0x02}, 6, true},
+	}
+	for _, tc := range testcases {
+		if size, ok := berElementSize(tc.b); size != tc.size || ok != tc.ok {
+			t.Errorf("%x: got %d, %t, want %d, %t", tc.b, size, ok, tc.size, tc.ok)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+func runAdd(args []string) error {
+	return runChange("add", changeTypeAdd, args)
+}
+
+func runModify(args []string) error {
+	return runChange("modify", changeTypeModify, args)
+}
+
+func runDelete(args []string) error {
+	return runChange("delete", changeTypeDelete, args)
+}
+
+// runChange applies the records of an LDIF file, those without a changetype being of the given
+// default change type. The delete command also deletes the entries whose DNs are given as arguments.
+func runChange(name, defaultChangeType string, args []string) error {
+	var opts connOptions
+	usage := "[flags]"
+	if defaultChangeType == changeTypeDelete {
+		usage = "[flags] [dn...]"
+	}
+	fs := newFlagSet(name, usage, &opts)
+	file := fs.String("f", "", "LDIF file of the changes, standard input if empty")
+	continueOnError := fs.Bool("c", false, "continue after errors, exiting with the last one")
+	addRecords := false
+	if defaultChangeType == changeTypeModify {
+		fs.BoolVar(&addRecords, "a", false, "add the records without changetype rather than modifying them")
+	}
+	fs.Parse(args)
+	if defaultChangeType != changeTypeDelete && fs.NArg() > 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if addRecords {
+		defaultChangeType = changeTypeAdd
+	}
+
+	conn, err := opts.connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var lastErr error
+	apply := func(record *ldifRecord) error {
+		if err := applyRecord(conn, record); err != nil {
+			if record.Line > 0 {
+				err = fmt.Errorf("line %d: %s", record.Line, err)
+			}
+			if !*continueOnError {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "ldaptool: %s\n", err)
+			lastErr = err
+		}
+		return nil
+	}
+
+	if fs.NArg() > 0 {
+		for _, dn := range fs.Args() {
+			if err := apply(&ldifRecord{Request: ldap.NewDelRequest(dn, nil)}); err != nil {
+				return err
+			}
+		}
+		return lastErr
+	}
+
+	var r io.Reader = os.Stdin
+	if *file != "" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	reader := newLDIFReader(r, defaultChangeType)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return lastErr
+		}
+		if err != nil {
+			return err
+		}
+		if err := apply(record); err != nil {
+			return err
+		}
+	}
+}
+
+func applyRecord(conn *ldap.Conn, record *ldifRecord) error {
+	switch req := record.Request.(type) {
+	case *ldap.AddRequest:
+		fmt.Printf("adding new entry %q\n", req.DN)
+		return conn.Add(req)
+	case *ldap.DelRequest:
+		fmt.Printf("deleting entry %q\n", req.DN)
+		return conn.Del(req)
+	case *ldap.ModifyRequest:
+		fmt.Printf("modifying entry %q\n", req.DN)
+		return conn.Modify(req)
+	case *ldap.ModifyDNRequest:
+		fmt.Printf("modifying rdn of entry %q\n", req.DN)
+		return conn.ModifyDN(req)
+	}
+	return fmt.Errorf("unexpected request %T", record.Request)
+}
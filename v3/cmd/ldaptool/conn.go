@@ -0,0 +1,141 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// connOptions are the flags shared by all the commands to connect and bind to the server
+type connOptions struct {
+	uri      string
+	startTLS bool
+	insecure bool
+	caFile   string
+	certFile string
+	keyFile  string
+	timeout  time.Duration
+	debug    bool
+
+	mechanism    string
+	bindDN       string
+	password     string
+	passwordFile string
+	username     string
+	domain       string
+	hash         string
+}
+
+// register registers the connection flags in the flag set of a command
+func (o *connOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.uri, "H", "ldap://localhost", "LDAP URI of the server: ldap://, ldaps:// or ldapi://")
+	fs.BoolVar(&o.startTLS, "Z", false, "issue a StartTLS request, failing if it is not successful")
+	fs.BoolVar(&o.insecure, "insecure", false, "do not verify the certificate of the server")
+	fs.StringVar(&o.caFile, "cacert", "", "PEM file of the CA certificates verifying the server")
+	fs.StringVar(&o.certFile, "cert", "", "PEM file of the client certificate, e.g. for a SASL EXTERNAL bind")
+	fs.StringVar(&o.keyFile, "key", "", "PEM file of the client private key")
+	fs.DurationVar(&o.timeout, "timeout", 30*time.Second, "timeout of the connection and of each request")
+	fs.BoolVar(&o.debug, "d", false, "log the LDAP messages to standard error, credentials masked")
+
+	fs.StringVar(&o.mechanism, "Y", "SIMPLE", "bind mechanism: SIMPLE, EXTERNAL, NTLM or DIGEST-MD5")
+	fs.StringVar(&o.bindDN, "D", "", "bind DN for a SIMPLE bind, anonymous if empty")
+	fs.StringVar(&o.password, "w", "", "bind password")
+	fs.StringVar(&o.passwordFile, "y", "", "file holding the bind password")
+	fs.StringVar(&o.username, "U", "", "user name for NTLM and DIGEST-MD5 binds")
+	fs.StringVar(&o.domain, "domain", "", "domain for NTLM binds")
+	fs.StringVar(&o.hash, "nthash", "", "NT hash of the password for NTLM binds, instead of the password")
+}
+
+// connect dials the server, issues a StartTLS request if required and binds
+func (o *connOptions) connect() (*ldap.Conn, error) {
+	u, err := url.Parse(o.uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URI %q: %s", o.uri, err)
+	}
+	config, err := o.tlsConfig(u.Hostname())
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := ldap.DialURL(o.uri, ldap.DialWithDialer(&net.Dialer{Timeout: o.timeout}), ldap.DialWithTLSConfig(config))
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(o.timeout)
+	if o.debug {
+		conn.SetLogger(ldap.NewStdLogger(log.New(os.Stderr, "", log.LstdFlags), ldap.LevelDebug))
+	}
+	if o.startTLS {
+		if err := conn.StartTLS(config); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if err := o.bind(conn, u.Hostname()); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (o *connOptions) tlsConfig(serverName string) (*tls.Config, error) {
+	config := &tls.Config{ServerName: serverName, InsecureSkipVerify: o.insecure}
+	if o.caFile != "" {
+		pem, err := ioutil.ReadFile(o.caFile)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in %s", o.caFile)
+		}
+	}
+	if o.certFile != "" || o.keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(o.certFile, o.keyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+func (o *connOptions) bind(conn *ldap.Conn, host string) error {
+	password := o.password
+	if o.passwordFile != "" {
+		b, err := ioutil.ReadFile(o.passwordFile)
+		if err != nil {
+			return err
+		}
+		password = strings.TrimRight(string(b), "\r\n")
+	}
+
+	switch strings.ToUpper(o.mechanism) {
+	case "SIMPLE":
+		if o.bindDN == "" && password == "" {
+			return nil
+		}
+		return conn.Bind(o.bindDN, password)
+	case "EXTERNAL":
+		return conn.ExternalBind()
+	case "NTLM":
+		if o.hash != "" {
+			return conn.NTLMBindWithHash(o.domain, o.username, o.hash)
+		}
+		return conn.NTLMBind(o.domain, o.username, password)
+	case "DIGEST-MD5":
+		return conn.MD5Bind(host, o.username, password)
+	}
+	return errors.New("unsupported bind mechanism " + o.mechanism)
+}
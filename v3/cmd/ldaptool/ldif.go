@@ -0,0 +1,329 @@
+package main
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// Change types of LDIF change records
+const (
+	changeTypeAdd    = "add"
+	changeTypeDelete = "delete"
+	changeTypeModify = "modify"
+	changeTypeModRDN = "modrdn"
+	changeTypeModDN  = "moddn"
+)
+
+// ldifRecord is a record of an LDIF file, with the request it describes
+type ldifRecord struct {
+	// Line is the line the record starts at
+	Line int
+	// Request is an *ldap.AddRequest, *ldap.DelRequest, *ldap.ModifyRequest or *ldap.ModifyDNRequest
+	Request interface{}
+}
+
+// ldifLine is an unfolded line of an LDIF file
+type ldifLine struct {
+	number int
+	text   string
+}
+
+// ldifReader reads the records of an LDIF file, see https://tools.ietf.org/html/rfc2849. Records
+// without a changetype are content records, read with the default change type.
+type ldifReader struct {
+	scanner           *bufio.Scanner
+	defaultChangeType string
+	number            int
+	first             bool
+}
+
+func newLDIFReader(r io.Reader, defaultChangeType string) *ldifReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16<<20)
+	return &ldifReader{scanner: scanner, defaultChangeType: defaultChangeType, first: true}
+}
+
+// Read returns the next record, or io.EOF at the end of the file
+func (r *ldifReader) Read() (*ldifRecord, error) {
+	lines, err := r.readLines()
+	if err != nil {
+		return nil, err
+	}
+	if r.first {
+		r.first = false
+		if len(lines) > 0 && strings.HasPrefix(lines[0].text, "version:") {
+			if strings.TrimSpace(strings.TrimPrefix(lines[0].text, "version:")) != "1" {
+				return nil, fmt.Errorf("line %d: unsupported LDIF version", lines[0].number)
+			}
+			lines = lines[1:]
+			if len(lines) == 0 {
+				return r.Read()
+			}
+		}
+	}
+	return r.parseRecord(lines)
+}
+
+// readLines returns the unfolded lines of the next record, without comments
+func (r *ldifReader) readLines() ([]ldifLine, error) {
+	var lines []ldifLine
+	comment := false
+	for {
+		text, ok := r.scan()
+		if !ok {
+			break
+		}
+		switch {
+		case strings.HasPrefix(text, " "):
+			if comment {
+				continue
+			}
+			if len(lines) == 0 {
+				return nil, fmt.Errorf("line %d: unexpected continuation line", r.number)
+			}
+			lines[len(lines)-1].text += text[1:]
+		case strings.HasPrefix(text, "#"):
+			comment = true
+		case text == "":
+			comment = false
+			if len(lines) > 0 {
+				return lines, nil
+			}
+		default:
+			comment = false
+			lines = append(lines, ldifLine{r.number, text})
+		}
+	}
+	if err := r.scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, io.EOF
+	}
+	return lines, nil
+}
+
+func (r *ldifReader) scan() (string, bool) {
+	if !r.scanner.Scan() {
+		return "", false
+	}
+	r.number++
+	return strings.TrimSuffix(r.scanner.Text(), "\r"), true
+}
+
+func (r *ldifReader) parseRecord(lines []ldifLine) (*ldifRecord, error) {
+	record := &ldifRecord{Line: lines[0].number}
+	name, value, err := parseLDIFLine(lines[0])
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(name, "dn") {
+		return nil, fmt.Errorf("line %d: record does not start with a dn", lines[0].number)
+	}
+	dn := string(value)
+	lines = lines[1:]
+
+	var controls []ldap.Control
+	for len(lines) > 0 && strings.HasPrefix(strings.ToLower(lines[0].text), "control:") {
+		control, err := parseLDIFControl(lines[0])
+		if err != nil {
+			return nil, err
+		}
+		controls = append(controls, control)
+		lines = lines[1:]
+	}
+
+	changeType := r.defaultChangeType
+	if len(lines) > 0 && strings.HasPrefix(strings.ToLower(lines[0].text), "changetype:") {
+		_, value, err := parseLDIFLine(lines[0])
+		if err != nil {
+			return nil, err
+		}
+		changeType = strings.ToLower(string(value))
+		lines = lines[1:]
+	}
+
+	switch changeType {
+	case changeTypeAdd:
+		record.Request, err = parseLDIFAdd(dn, controls, lines)
+	case changeTypeDelete:
+		if len(lines) > 0 {
+			return nil, fmt.Errorf("line %d: unexpected line in a delete record", lines[0].number)
+		}
+		record.Request = ldap.NewDelRequest(dn, controls)
+	case changeTypeModify:
+		record.Request, err = parseLDIFModify(dn, controls, lines)
+	case changeTypeModRDN, changeTypeModDN:
+		if len(controls) > 0 {
+			return nil, fmt.Errorf("line %d: controls are not supported for modrdn records", record.Line)
+		}
+		record.Request, err = parseLDIFModDN(dn, lines)
+	default:
+		return nil, fmt.Errorf("line %d: unknown changetype %q", record.Line, changeType)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+func parseLDIFAdd(dn string, controls []ldap.Control, lines []ldifLine) (*ldap.AddRequest, error) {
+	var names []string
+	values := make(map[string][][]byte)
+	for _, line := range lines {
+		name, value, err := parseLDIFLine(line)
+		if err != nil {
+			return nil, err
+		}
+		key := strings.ToLower(name)
+		if _, ok := values[key]; !ok {
+			names = append(names, name)
+		}
+		values[key] = append(values[key], value)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("entry %q has no attributes", dn)
+	}
+	req := ldap.NewAddRequest(dn, controls)
+	for _, name := range names {
+		req.AttributeBytes(name, values[strings.ToLower(name)])
+	}
+	return req, nil
+}
+
+func parseLDIFModify(dn string, controls []ldap.Control, lines []ldifLine) (*ldap.ModifyRequest, error) {
+	req := ldap.NewModifyRequest(dn, controls)
+	for len(lines) > 0 {
+		operation, attribute, err := parseLDIFLine(lines[0])
+		if err != nil {
+			return nil, err
+		}
+		start := lines[0].number
+		lines = lines[1:]
+
+		var values [][]byte
+		for len(lines) > 0 && lines[0].text != "-" {
+			name, value, err := parseLDIFLine(lines[0])
+			if err != nil {
+				return nil, err
+			}
+			if !strings.EqualFold(name, string(attribute)) {
+				return nil, fmt.Errorf("line %d: got attribute %q, want %q", lines[0].number, name, attribute)
+			}
+			values = append(values, value)
+			lines = lines[1:]
+		}
+		if len(lines) > 0 {
+			lines = lines[1:]
+		}
+
+		switch strings.ToLower(operation) {
+		case "add":
+			req.AddBytes(string(attribute), values)
+		case "delete":
+			req.DeleteBytes(string(attribute), values)
+		case "replace":
+			req.ReplaceBytes(string(attribute), values)
+		case "increment":
+			if len(values) != 1 {
+				return nil, fmt.Errorf("line %d: increment requires a single value", start)
+			}
+			req.Increment(string(attribute), string(values[0]))
+		default:
+			return nil, fmt.Errorf("line %d: unknown modify operation %q", start, operation)
+		}
+	}
+	if len(req.Changes) == 0 {
+		return nil, fmt.Errorf("modify record of %q has no changes", dn)
+	}
+	return req, nil
+}
+
+func parseLDIFModDN(dn string, lines []ldifLine) (*ldap.ModifyDNRequest, error) {
+	req := ldap.NewModifyDNRequest(dn, "", false, "")
+	for _, line := range lines {
+		name, value, err := parseLDIFLine(line)
+		if err != nil {
+			return nil, err
+		}
+		switch strings.ToLower(name) {
+		case "newrdn":
+			req.NewRDN = string(value)
+		case "deleteoldrdn":
+			switch string(value) {
+			case "0":
+				req.DeleteOldRDN = false
+			case "1":
+				req.DeleteOldRDN = true
+			default:
+				return nil, fmt.Errorf("line %d: deleteoldrdn must be 0 or 1", line.number)
+			}
+		case "newsuperior":
+			req.NewSuperior = string(value)
+		default:
+			return nil, fmt.Errorf("line %d: unexpected line %q in a modrdn record", line.number, name)
+		}
+	}
+	if req.NewRDN == "" {
+		return nil, fmt.Errorf("modrdn record of %q has no newrdn", dn)
+	}
+	return req, nil
+}
+
+// parseLDIFControl parses a "control: oid [criticality] [: value | :: base64 value]" line
+func parseLDIFControl(line ldifLine) (ldap.Control, error) {
+	spec := strings.TrimSpace(line.text[len("control:"):])
+	var value []byte
+	if i := strings.Index(spec, ":"); i >= 0 {
+		_, v, err := parseLDIFLine(ldifLine{line.number, "value" + spec[i:]})
+		if err != nil {
+			return nil, err
+		}
+		spec, value = strings.TrimSpace(spec[:i]), v
+	}
+	fields := strings.Fields(spec)
+	criticality := false
+	switch {
+	case len(fields) == 2 && fields[1] == "true":
+		criticality = true
+	case len(fields) == 2 && fields[1] == "false", len(fields) == 1:
+	default:
+		return nil, fmt.Errorf("line %d: invalid control", line.number)
+	}
+	return ldap.NewControlString(fields[0], criticality, string(value)), nil
+}
+
+// parseLDIFLine parses a "name: value", "name:: base64 value" or "name:< URL" line
+func parseLDIFLine(line ldifLine) (string, []byte, error) {
+	i := strings.Index(line.text, ":")
+	if i <= 0 {
+		return "", nil, fmt.Errorf("line %d: missing attribute name", line.number)
+	}
+	name, value := line.text[:i], line.text[i+1:]
+	switch {
+	case strings.HasPrefix(value, ":"):
+		b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value[1:]))
+		if err != nil {
+			return "", nil, fmt.Errorf("line %d: invalid base64 value: %s", line.number, err)
+		}
+		return name, b, nil
+	case strings.HasPrefix(value, "<"):
+		u, err := url.Parse(strings.TrimSpace(value[1:]))
+		if err != nil || u.Scheme != "file" {
+			return "", nil, fmt.Errorf("line %d: only file:// URLs are supported", line.number)
+		}
+		b, err := ioutil.ReadFile(u.Path)
+		if err != nil {
+			return "", nil, fmt.Errorf("line %d: %s", line.number, err)
+		}
+		return name, b, nil
+	}
+	return name, []byte(strings.TrimLeft(value, " ")), nil
+}
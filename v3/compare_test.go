@@ -0,0 +1,28 @@
+package ldap
+
+import (
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+)
+
+func TestCompareAssertionValue(t *testing.T) {
+	conn := newTestServerConn(t, func(request *ber.Packet) []*ber.Packet {
+		resultCode := int64(LDAPResultCompareFalse)
+		if ava := request.Children[1].Children[1]; ava.Children[1].Value == "Smith" {
+			resultCode = LDAPResultCompareTrue
+		}
+		return []*ber.Packet{newTestLDAPResult(ApplicationCompareResponse, resultCode)}
+	})
+	defer conn.Close()
+
+	for value, want := range map[string]bool{"Smith": true, "Jones": false} {
+		matched, err := conn.Compare("cn=a,dc=example,dc=org", "sn", value)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if matched != want {
+			t.Errorf("%s: got %t, want %t", value, matched, want)
+		}
+	}
+}
@@ -4,7 +4,7 @@ import (
 	"crypto/tls"
 	"errors"
 	"fmt"
-	"log"
+	"io"
 	"net"
 	"net/url"
 	"sync"
@@ -52,6 +52,11 @@ func (pr *PacketResponse) ReadPacket() (*ber.Packet, error) {
 
 type messageContext struct {
 	id int64
+	// request describes the request for logging and the Observer
+	request *RequestInfo
+	// resultCode is the result code of the last response to the request, or noResultCode. It is
+	// accessed atomically.
+	resultCode int32
 	// close(done) should only be called from finishMessage()
 	done chan struct{}
 	// close(responses) should only be called from processMessages(), and only sent to from sendResponse()
@@ -75,6 +80,8 @@ type messagePacket struct {
 	MessageID int64
 	Packet    *ber.Packet
 	Context   *messageContext
+	// Size is the size of a response in bytes
+	Size int
 }
 
 type sendMessageFlags uint
@@ -94,6 +101,9 @@ type Conn struct {
 	closeErr            atomic.Value
 	isStartingTLS       bool
 	Debug               debugging
+	logger              atomic.Value
+	observer            atomic.Value
+	redactor            *Redactor
 	chanConfirm         chan struct{}
 	messageContexts     map[int64]*messageContext
 	chanMessage         chan *messagePacket
@@ -101,6 +111,7 @@ type Conn struct {
 	wgClose             sync.WaitGroup
 	outstandingRequests uint
 	messageMutex        sync.Mutex
+	referralOptions     *ReferralOptions
 }
 
 var _ Client = &Conn{}
@@ -131,8 +142,9 @@ func DialWithTLSConfig(tc *tls.Config) DialOpt {
 
 // DialContext contains necessary parameters to dial the given ldap URL.
 type DialContext struct {
-	d  *net.Dialer
-	tc *tls.Config
+	d       *net.Dialer
+	tc      *tls.Config
+	capture io.Writer
 }
 
 func (dc *DialContext) dial(u *url.URL) (net.Conn, error) {
@@ -213,6 +225,9 @@ func DialURL(addr string, opts ...DialOpt) (*Conn, error) {
 	if err != nil {
 		return nil, NewError(ErrorNetwork, err)
 	}
+	if dc.capture != nil {
+		c = NewCaptureConn(c, dc.capture)
+	}
 
 	conn := NewConn(c, u.Scheme == "ldaps")
 	conn.Start()
@@ -255,14 +270,14 @@ func (l *Conn) Close() {
 	defer l.messageMutex.Unlock()
 
 	if l.setClosing() {
-		l.Debug.Printf("Sending quit message and waiting for confirmation")
+		l.log(LevelDebug, "sending quit message and waiting for confirmation")
 		l.chanMessage <- &messagePacket{Op: MessageQuit}
 		<-l.chanConfirm
 		close(l.chanMessage)
 
-		l.Debug.Printf("Closing network connection")
+		l.log(LevelDebug, "closing network connection")
 		if err := l.conn.Close(); err != nil {
-			log.Println(err)
+			l.log(LevelError, "error closing network connection", LogKeyError, err)
 		}
 
 		l.wgClose.Done()
@@ -290,13 +305,16 @@ func (l *Conn) StartTLS(config *tls.Config) error {
 	if l.isTLS {
 		return NewError(ErrorNetwork, errors.New("ldap: already encrypted"))
 	}
+	if _, ok := l.conn.(*CaptureConn); ok {
+		// the capture would hold the encrypted messages
+		return NewError(ErrorNetwork, errors.New("ldap: cannot start TLS on a captured connection"))
+	}
 
 	packet := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Request")
 	packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, l.nextMessageID(), "MessageID"))
 	request := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ApplicationExtendedRequest, nil, "Start TLS")
 	request.AppendChild(ber.NewString(ber.ClassContext, ber.TypePrimitive, 0, "1.3.6.1.4.1.1466.20037", "TLS Extended Command"))
 	packet.AppendChild(request)
-	l.Debug.PrintPacket(packet)
 
 	msgCtx, err := l.sendMessageWithFlags(packet, startTLS)
 	if err != nil {
@@ -304,25 +322,17 @@ func (l *Conn) StartTLS(config *tls.Config) error {
 	}
 	defer l.finishMessage(msgCtx)
 
-	l.Debug.Printf("%d: waiting for response", msgCtx.id)
+	l.log(LevelDebug, "waiting for response", logMessage(msgCtx)...)
 
 	packetResponse, ok := <-msgCtx.responses
 	if !ok {
 		return NewError(ErrorNetwork, errors.New("ldap: response channel closed"))
 	}
 	packet, err = packetResponse.ReadPacket()
-	l.Debug.Printf("%d: got response %p", msgCtx.id, packet)
 	if err != nil {
 		return err
 	}
-
-	if l.Debug {
-		if err := addLDAPDescriptions(packet); err != nil {
-			l.Close()
-			return err
-		}
-		l.Debug.PrintPacket(packet)
-	}
+	l.logPacket("got response", packet, logMessage(msgCtx)...)
 
 	if err := GetLDAPError(packet); err == nil {
 		conn := tls.Client(l.conn, config)
@@ -346,7 +356,11 @@ func (l *Conn) StartTLS(config *tls.Config) error {
 // The return values are their zero values if StartTLS did
 // not succeed.
 func (l *Conn) TLSConnectionState() (state tls.ConnectionState, ok bool) {
-	tc, ok := l.conn.(*tls.Conn)
+	conn := l.conn
+	if c, ok := conn.(*CaptureConn); ok {
+		conn = c.Conn
+	}
+	tc, ok := conn.(*tls.Conn)
 	if !ok {
 		return
 	}
@@ -362,7 +376,6 @@ func (l *Conn) sendMessageWithFlags(packet *ber.Packet, flags sendMessageFlags)
 		return nil, NewError(ErrorNetwork, errors.New("ldap: connection closed"))
 	}
 	l.messageMutex.Lock()
-	l.Debug.Printf("flags&startTLS = %d", flags&startTLS)
 	if l.isStartingTLS {
 		l.messageMutex.Unlock()
 		return nil, NewError(ErrorNetwork, errors.New("ldap: connection is in startls phase"))
@@ -375,8 +388,12 @@ func (l *Conn) sendMessageWithFlags(packet *ber.Packet, flags sendMessageFlags)
 		l.isStartingTLS = true
 	}
 	l.outstandingRequests++
+	outstandingRequests := l.outstandingRequests
 
 	l.messageMutex.Unlock()
+	if observer := l.getObserver(); observer != nil {
+		observer.OutstandingRequests(int(outstandingRequests))
+	}
 
 	responses := make(chan *PacketResponse)
 	messageID := packet.Children[0].Value.(int64)
@@ -385,11 +402,18 @@ func (l *Conn) sendMessageWithFlags(packet *ber.Packet, flags sendMessageFlags)
 		MessageID: messageID,
 		Packet:    packet,
 		Context: &messageContext{
-			id:        messageID,
-			done:      make(chan struct{}),
-			responses: responses,
+			id: messageID,
+			request: &RequestInfo{
+				MessageID: messageID,
+				Operation: operationName(packet),
+				Sent:      time.Now(),
+			},
+			resultCode: noResultCode,
+			done:       make(chan struct{}),
+			responses:  responses,
 		},
 	}
+	l.logPacket("sending request", packet, logMessage(message.Context)...)
 	if !l.sendProcessMessage(message) {
 		if l.IsClosing() {
 			return nil, NewError(ErrorNetwork, errors.New("ldap: connection closed"))
@@ -401,6 +425,12 @@ func (l *Conn) sendMessageWithFlags(packet *ber.Packet, flags sendMessageFlags)
 
 func (l *Conn) finishMessage(msgCtx *messageContext) {
 	close(msgCtx.done)
+	resultCode, duration := msgCtx.getResultCode(), time.Since(msgCtx.request.Sent)
+	l.log(LevelDebug, "finished request", append(logMessage(msgCtx), LogKeyResultCode, resultCode, LogKeyDuration, duration.Round(time.Microsecond))...)
+	observer := l.getObserver()
+	if observer != nil {
+		observer.RequestFinished(msgCtx.request, resultCode, duration)
+	}
 
 	if l.IsClosing() {
 		return
@@ -408,10 +438,14 @@ func (l *Conn) finishMessage(msgCtx *messageContext) {
 
 	l.messageMutex.Lock()
 	l.outstandingRequests--
+	outstandingRequests := l.outstandingRequests
 	if l.isStartingTLS {
 		l.isStartingTLS = false
 	}
 	l.messageMutex.Unlock()
+	if observer != nil {
+		observer.OutstandingRequests(int(outstandingRequests))
+	}
 
 	message := &messagePacket{
 		Op:        MessageFinish,
@@ -433,7 +467,7 @@ func (l *Conn) sendProcessMessage(message *messagePacket) bool {
 func (l *Conn) processMessages() {
 	defer func() {
 		if err := recover(); err != nil {
-			log.Printf("ldap: recovered panic in processMessages: %v", err)
+			l.log(LevelError, "recovered panic in processMessages", LogKeyError, err)
 		}
 		for messageID, msgCtx := range l.messageContexts {
 			// If we are closing due to an error, inform anyone who
@@ -441,7 +475,7 @@ func (l *Conn) processMessages() {
 			if l.IsClosing() && l.closeErr.Load() != nil {
 				msgCtx.sendResponse(&PacketResponse{Error: l.closeErr.Load().(error)})
 			}
-			l.Debug.Printf("Closing channel for MessageID %d", messageID)
+			l.log(LevelDebug, "closing channel", logMessage(msgCtx)...)
 			close(msgCtx.responses)
 			delete(l.messageContexts, messageID)
 		}
@@ -457,16 +491,16 @@ func (l *Conn) processMessages() {
 		case message := <-l.chanMessage:
 			switch message.Op {
 			case MessageQuit:
-				l.Debug.Printf("Shutting down - quit message received")
+				l.log(LevelDebug, "shutting down, quit message received")
 				return
 			case MessageRequest:
 				// Add to message list and write to network
-				l.Debug.Printf("Sending message %d", message.MessageID)
+				l.log(LevelDebug, "sending message", logMessage(message.Context)...)
 
 				buf := message.Packet.Bytes()
 				_, err := l.conn.Write(buf)
 				if err != nil {
-					l.Debug.Printf("Error Sending Message: %s", err.Error())
+					l.log(LevelDebug, "error sending message", append(logMessage(message.Context), LogKeyError, err)...)
 					message.Context.sendResponse(&PacketResponse{Error: fmt.Errorf("unable to send request: %s", err)})
 					close(message.Context.responses)
 					break
@@ -475,6 +509,9 @@ func (l *Conn) processMessages() {
 				// Only add to messageContexts if we were able to
 				// successfully write the message.
 				l.messageContexts[message.MessageID] = message.Context
+				if observer := l.getObserver(); observer != nil {
+					observer.RequestSent(message.Context.request, len(buf))
+				}
 
 				// Add timeout if defined
 				requestTimeout := time.Duration(atomic.LoadInt64(&l.requestTimeout))
@@ -482,7 +519,7 @@ func (l *Conn) processMessages() {
 					go func() {
 						defer func() {
 							if err := recover(); err != nil {
-								log.Printf("ldap: recovered panic in RequestTimeout: %v", err)
+								l.log(LevelError, "recovered panic in RequestTimeout", LogKeyError, err)
 							}
 						}()
 						time.Sleep(requestTimeout)
@@ -494,24 +531,30 @@ func (l *Conn) processMessages() {
 					}()
 				}
 			case MessageResponse:
-				l.Debug.Printf("Receiving message %d", message.MessageID)
 				if msgCtx, ok := l.messageContexts[message.MessageID]; ok {
+					l.log(LevelDebug, "receiving message", append(logMessage(msgCtx), LogKeyDuration, durationSince(msgCtx.request.Sent))...)
+					if resultCode, ok := responseResultCode(message.Packet); ok {
+						msgCtx.setResultCode(resultCode)
+					}
+					if observer := l.getObserver(); observer != nil {
+						observer.ResponseReceived(msgCtx.request, operationName(message.Packet), message.Size)
+					}
 					msgCtx.sendResponse(&PacketResponse{message.Packet, nil})
 				} else {
-					log.Printf("Received unexpected message %d, %v", message.MessageID, l.IsClosing())
-					l.Debug.PrintPacket(message.Packet)
+					l.log(LevelWarn, "received unexpected message", LogKeyMessageID, message.MessageID, "closing", l.IsClosing())
+					l.logPacket("unexpected message", message.Packet, LogKeyMessageID, message.MessageID)
 				}
 			case MessageTimeout:
 				// Handle the timeout by closing the channel
 				// All reads will return immediately
 				if msgCtx, ok := l.messageContexts[message.MessageID]; ok {
-					l.Debug.Printf("Receiving message timeout for %d", message.MessageID)
+					l.log(LevelDebug, "request timed out", append(logMessage(msgCtx), LogKeyDuration, durationSince(msgCtx.request.Sent))...)
+					msgCtx.setResultCode(LDAPResultTimeout)
 					msgCtx.sendResponse(&PacketResponse{message.Packet, errors.New("ldap: connection timed out")})
 					delete(l.messageContexts, message.MessageID)
 					close(msgCtx.responses)
 				}
 			case MessageFinish:
-				l.Debug.Printf("Finished message %d", message.MessageID)
 				if msgCtx, ok := l.messageContexts[message.MessageID]; ok {
 					delete(l.messageContexts, message.MessageID)
 					close(msgCtx.responses)
@@ -525,7 +568,7 @@ func (l *Conn) reader() {
 	cleanstop := false
 	defer func() {
 		if err := recover(); err != nil {
-			log.Printf("ldap: recovered panic in reader: %v", err)
+			l.log(LevelError, "recovered panic in reader", LogKeyError, err)
 		}
 		if !cleanstop {
 			l.Close()
@@ -534,23 +577,24 @@ func (l *Conn) reader() {
 
 	for {
 		if cleanstop {
-			l.Debug.Printf("reader clean stopping (without closing the connection)")
+			l.log(LevelDebug, "reader clean stopping (without closing the connection)")
 			return
 		}
-		packet, err := ber.ReadPacket(l.conn)
+		reader := &countingReader{Reader: l.conn}
+		packet, err := ber.ReadPacket(reader)
 		if err != nil {
 			// A read error is expected here if we are closing the connection...
 			if !l.IsClosing() {
 				l.closeErr.Store(fmt.Errorf("unable to read LDAP response packet: %s", err))
-				l.Debug.Printf("reader error: %s", err)
+				l.log(LevelDebug, "reader error", LogKeyError, err)
 			}
 			return
 		}
 		if err := addLDAPDescriptions(packet); err != nil {
-			l.Debug.Printf("descriptions error: %s", err)
+			l.log(LevelDebug, "descriptions error", LogKeyError, err)
 		}
 		if len(packet.Children) == 0 {
-			l.Debug.Printf("Received bad ldap packet")
+			l.log(LevelDebug, "received bad LDAP packet")
 			continue
 		}
 		l.messageMutex.Lock()
@@ -562,6 +606,7 @@ func (l *Conn) reader() {
 			Op:        MessageResponse,
 			MessageID: packet.Children[0].Value.(int64),
 			Packet:    packet,
+			Size:      reader.count,
 		}
 		if !l.sendProcessMessage(message) {
 			return
@@ -9,42 +9,42 @@ func TestSuccessfulDNParsing(t *testing.T) {
 	testcases := map[string]DN{
 		"": {[]*RelativeDN{}},
 		"cn=Jim\\2C \\22Hasse Hö\\22 Hansson!,dc=dummy,dc=com": {[]*RelativeDN{
-			{[]*AttributeTypeAndValue{{"cn", "Jim, \"Hasse Hö\" Hansson!"}}},
-			{[]*AttributeTypeAndValue{{"dc", "dummy"}}},
-			{[]*AttributeTypeAndValue{{"dc", "com"}}}}},
+			{[]*AttributeTypeAndValue{{Type: "cn", Value: "Jim, \"Hasse Hö\" Hansson!"}}},
+			{[]*AttributeTypeAndValue{{Type: "dc", Value: "dummy"}}},
+			{[]*AttributeTypeAndValue{{Type: "dc", Value: "com"}}}}},
 		"UID=jsmith,DC=example,DC=net": {[]*RelativeDN{
-			{[]*AttributeTypeAndValue{{"UID", "jsmith"}}},
-			{[]*AttributeTypeAndValue{{"DC", "example"}}},
-			{[]*AttributeTypeAndValue{{"DC", "net"}}}}},
+			{[]*AttributeTypeAndValue{{Type: "UID", Value: "jsmith"}}},
+			{[]*AttributeTypeAndValue{{Type: "DC", Value: "example"}}},
+			{[]*AttributeTypeAndValue{{Type: "DC", Value: "net"}}}}},
 		"OU=Sales+CN=J. Smith,DC=example,DC=net": {[]*RelativeDN{
 			{[]*AttributeTypeAndValue{
-				{"OU", "Sales"},
-				{"CN", "J. Smith"}}},
-			{[]*AttributeTypeAndValue{{"DC", "example"}}},
-			{[]*AttributeTypeAndValue{{"DC", "net"}}}}},
+				{Type: "OU", Value: "Sales"},
+				{Type: "CN", Value: "J. Smith"}}},
+			{[]*AttributeTypeAndValue{{Type: "DC", Value: "example"}}},
+			{[]*AttributeTypeAndValue{{Type: "DC", Value: "net"}}}}},
 		"1.3.6.1.4.1.1466.0=#04024869": {[]*RelativeDN{
-			{[]*AttributeTypeAndValue{{"1.3.6.1.4.1.1466.0", "Hi"}}}}},
+			{[]*AttributeTypeAndValue{{Type: "1.3.6.1.4.1.1466.0", Value: "Hi", rawBER: []byte{0x04, 0x02, 0x48, 0x69}}}}}},
 		"1.3.6.1.4.1.1466.0=#04024869,DC=net": {[]*RelativeDN{
-			{[]*AttributeTypeAndValue{{"1.3.6.1.4.1.1466.0", "Hi"}}},
-			{[]*AttributeTypeAndValue{{"DC", "net"}}}}},
+			{[]*AttributeTypeAndValue{{Type: "1.3.6.1.4.1.1466.0", Value: "Hi", rawBER: []byte{0x04, 0x02, 0x48, 0x69}}}},
+			{[]*AttributeTypeAndValue{{Type: "DC", Value: "net"}}}}},
 		"CN=Lu\\C4\\8Di\\C4\\87": {[]*RelativeDN{
-			{[]*AttributeTypeAndValue{{"CN", "Lučić"}}}}},
+			{[]*AttributeTypeAndValue{{Type: "CN", Value: "Lučić"}}}}},
 		"  CN  =  Lu\\C4\\8Di\\C4\\87  ": {[]*RelativeDN{
-			{[]*AttributeTypeAndValue{{"CN", "Lučić"}}}}},
+			{[]*AttributeTypeAndValue{{Type: "CN", Value: "Lučić"}}}}},
 		`   A   =   1   ,   B   =   2   `: {[]*RelativeDN{
-			{[]*AttributeTypeAndValue{{"A", "1"}}},
-			{[]*AttributeTypeAndValue{{"B", "2"}}}}},
+			{[]*AttributeTypeAndValue{{Type: "A", Value: "1"}}},
+			{[]*AttributeTypeAndValue{{Type: "B", Value: "2"}}}}},
 		`   A   =   1   +   B   =   2   `: {[]*RelativeDN{
 			{[]*AttributeTypeAndValue{
-				{"A", "1"},
-				{"B", "2"}}}}},
+				{Type: "A", Value: "1"},
+				{Type: "B", Value: "2"}}}}},
 		`   \ \ A\ \    =   \ \ 1\ \    ,   \ \ B\ \    =   \ \ 2\ \    `: {[]*RelativeDN{
-			{[]*AttributeTypeAndValue{{"  A  ", "  1  "}}},
-			{[]*AttributeTypeAndValue{{"  B  ", "  2  "}}}}},
+			{[]*AttributeTypeAndValue{{Type: "  A  ", Value: "  1  "}}},
+			{[]*AttributeTypeAndValue{{Type: "  B  ", Value: "  2  "}}}}},
 		`   \ \ A\ \    =   \ \ 1\ \    +   \ \ B\ \    =   \ \ 2\ \    `: {[]*RelativeDN{
 			{[]*AttributeTypeAndValue{
-				{"  A  ", "  1  "},
-				{"  B  ", "  2  "}}}}},
+				{Type: "  A  ", Value: "  1  "},
+				{Type: "  B  ", Value: "  2  "}}}}},
 	}
 
 	for test, answer := range testcases {
@@ -207,3 +207,156 @@ func TestDNAncestor(t *testing.T) {
 		}
 	}
 }
+
+func TestDNString(t *testing.T) {
+	testcases := map[string]string{
+		"": "",
+		"cn=Jim\\2C \\22Hasse Hö\\22 Hansson!,dc=dummy,dc=com": `cn=Jim\, \"Hasse Hö\" Hansson!,dc=dummy,dc=com`,
+		"OU=Sales+CN=J. Smith,DC=example,DC=net":               "CN=J. Smith+OU=Sales,DC=example,DC=net",
+		"1.3.6.1.4.1.1466.0=#04024869,DC=net":                  "1.3.6.1.4.1.1466.0=#04024869,DC=net",
+		"1.3.6.1.4.1.1466.0=#0402486A":                         "1.3.6.1.4.1.1466.0=#0402486a",
+		"uid=b+uid=a+CN=c+cn=B,dc=x":                           "cn=B+CN=c+uid=a+uid=b,dc=x",
+		"cn=\\ lead\\,trail\\ ,dc=x":                           `cn=\ lead\,trail\ ,dc=x`,
+		`cn=\#hash\=\+\;\<\>\\`:                                `cn=\#hash\=\+\;\<\>\\`,
+		"  uid = jsmith , dc = example ":                       "uid=jsmith,dc=example",
+	}
+	for input, want := range testcases {
+		dn, err := ParseDN(input)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", input, err)
+			continue
+		}
+		if got := dn.String(); got != want {
+			t.Errorf("%q: got %q, want %q", input, got, want)
+		}
+		reparsed, err := ParseDN(dn.String())
+		if err != nil || !reparsed.Equal(dn) {
+			t.Errorf("%q: %q does not parse to the same DN (%v)", input, dn, err)
+		}
+	}
+
+	if s := EscapeDNValue("#a, b=c "); s != `\#a\, b\=c\ ` {
+		t.Errorf("unexpected escaped value %q", s)
+	}
+
+	dn, _ := ParseDN("1.3.6.1.4.1.1466.0=#04024869+cn=Hi")
+	if attrs := dn.RDNs[0].Attributes; !attrs[0].HexEncoded() || attrs[0].Value != "Hi" || attrs[1].HexEncoded() {
+		t.Errorf("unexpected attributes %v and %v", attrs[0], attrs[1])
+	}
+	// the encoding of a changed value is not used
+	dn.RDNs[0].Attributes[0].Value = "Ho"
+	if s := dn.String(); s != "1.3.6.1.4.1.1466.0=Ho+cn=Hi" || dn.RDNs[0].Attributes[0].HexEncoded() {
+		t.Errorf("unexpected DN %q", s)
+	}
+	dn.RDNs[0].Attributes[0].SetValue("Hi")
+	if s := dn.String(); s != "1.3.6.1.4.1.1466.0=Hi+cn=Hi" {
+		t.Errorf("unexpected DN %q", s)
+	}
+}
+
+func TestDNManipulation(t *testing.T) {
+	dn, _ := ParseDN("uid=jsmith,ou=people,dc=example,dc=org")
+	base, _ := ParseDN("dc=example,dc=org")
+
+	if s := dn.RDN().String(); s != "uid=jsmith" {
+		t.Errorf("unexpected RDN %q", s)
+	}
+	parent := dn.Parent()
+	if s := parent.String(); s != "ou=people,dc=example,dc=org" {
+		t.Errorf("unexpected parent %q", s)
+	}
+	child := parent.Child(&RelativeDN{Attributes: []*AttributeTypeAndValue{{Type: "uid", Value: "jdoe"}}})
+	if s := child.String(); s != "uid=jdoe,ou=people,dc=example,dc=org" {
+		t.Errorf("unexpected child %q", s)
+	}
+	if s := dn.String(); s != "uid=jsmith,ou=people,dc=example,dc=org" {
+		t.Errorf("DN modified to %q", s)
+	}
+	root := &DN{}
+	if root.RDN() != nil || root.Parent() != nil {
+		t.Error("expected no RDN and parent for the empty DN")
+	}
+	if s := root.Child(dn.RDN()).String(); s != "uid=jsmith" {
+		t.Errorf("unexpected child of the empty DN %q", s)
+	}
+
+	newBase, _ := ParseDN("o=acme")
+	rebased, err := dn.Rebase(base, newBase)
+	if err != nil || rebased.String() != "uid=jsmith,ou=people,o=acme" {
+		t.Errorf("unexpected rebased DN %v (%v)", rebased, err)
+	}
+	if rebased, err := base.Rebase(base, newBase); err != nil || rebased.String() != "o=acme" {
+		t.Errorf("unexpected rebased DN %v (%v)", rebased, err)
+	}
+	if _, err := newBase.Rebase(base, newBase); err == nil {
+		t.Error("expected an error rebasing a DN outside of the old base")
+	}
+
+	builder := NewDNBuilder().RDN("cn", "Smith, John").Attribute("uid", "jsmith").RDN("ou", "People").Base(base)
+	if s := builder.String(); s != `cn=Smith\, John+uid=jsmith,ou=People,dc=example,dc=org` {
+		t.Errorf("unexpected built DN %q", s)
+	}
+	built := NewDNBuilder().Base(base).Attribute("o", "acme").DN()
+	if s := built.String(); s != "dc=example,dc=org+o=acme" || base.String() != "dc=example,dc=org" {
+		t.Errorf("unexpected built DN %q, base %q", s, base)
+	}
+}
+
+func TestNewModifyDNRequestFromDN(t *testing.T) {
+	dn, _ := ParseDN("uid=user,ou=people,dc=example,dc=org")
+	users, _ := ParseDN("ou=users,dc=example,dc=org")
+	rdn := &RelativeDN{Attributes: []*AttributeTypeAndValue{{Type: "uid", Value: "new"}}}
+
+	testcases := []struct {
+		newDN *DN
+		want  ModifyDNRequest
+	}{
+		{dn.Parent().Child(rdn), ModifyDNRequest{DN: dn.String(), NewRDN: "uid=new", DeleteOldRDN: true}},
+		{users.Child(rdn), ModifyDNRequest{DN: dn.String(), NewRDN: "uid=new", DeleteOldRDN: true, NewSuperior: "ou=users,dc=example,dc=org"}},
+		{users.Child(dn.RDN()), ModifyDNRequest{DN: dn.String(), NewRDN: "uid=user", DeleteOldRDN: true, NewSuperior: "ou=users,dc=example,dc=org"}},
+	}
+	for _, tc := range testcases {
+		if req := NewModifyDNRequestFromDN(dn, tc.newDN, true); !reflect.DeepEqual(*req, tc.want) {
+			t.Errorf("%s: got %+v, want %+v", tc.newDN, *req, tc.want)
+		}
+	}
+}
+
+func TestDNNormalize(t *testing.T) {
+	testcases := []struct {
+		A     string
+		B     string
+		Equal bool
+	}{
+		{"CN=Alice,DC=Example", "cn=alice,dc=example", true},
+		{"commonName=Alice  Smith , 2.5.4.11=People", "cn=alice smith,ou=people", true},
+		{"OID.0.9.2342.19200300.100.1.1=jdoe", "uid=JDOE", true},
+		{"uid=jdoe+ou=People", "OU=people+UID=jdoe", true},
+		{"cn=#04024869", "cn=hi", true},
+		{"uidNumber=0100", "uidNumber=100", true},
+		{"cn=alice,dc=example", "cn=bob,dc=example", false},
+		{"cn=alice", "sn=alice", false},
+		{"cn=alice+sn=a", "cn=alice", false},
+	}
+	for _, tc := range testcases {
+		a, _ := ParseDN(tc.A)
+		b, _ := ParseDN(tc.B)
+		if equal := a.EqualFold(b); equal != tc.Equal {
+			t.Errorf("%q and %q: got %t, want %t", tc.A, tc.B, equal, tc.Equal)
+		}
+		if equal := a.Normalize(nil).String() == b.Normalize(nil).String(); equal != tc.Equal {
+			t.Errorf("%q and %q: normalized to %q and %q", tc.A, tc.B, a.Normalize(nil), b.Normalize(nil))
+		}
+	}
+
+	if dn, err := NormalizeDN("CN=Alice+UID=a , OU=People", nil); err != nil || dn != "cn=alice+uid=a,ou=people" {
+		t.Errorf("unexpected normalized DN %q (%v)", dn, err)
+	}
+	schema := newTestSchema(t)
+	if dn, err := NormalizeDN("commonName=Alice,2.5.4.41=X,uidNumber=01,employeeNumber=A", schema); err != nil || dn != "cn=alice,name=x,uidnumber=1,employeenumber=a" {
+		t.Errorf("unexpected normalized DN %q (%v)", dn, err)
+	}
+	if _, err := NormalizeDN("cn", nil); err == nil {
+		t.Error("expected an error for an invalid DN")
+	}
+}
@@ -1,7 +1,9 @@
 package ldap
 
 import (
+	"errors"
 	"fmt"
+	"net"
 
 	ber "github.com/go-asn1-ber/asn1-ber"
 )
@@ -176,6 +178,97 @@ var LDAPResultCodeMap = map[uint16]string{
 	ErrorEmptyPassword:      "Empty password not allowed by the client",
 }
 
+// resultCodeError is the type of the sentinel errors for result codes
+type resultCodeError uint16
+
+func (c resultCodeError) Error() string {
+	return fmt.Sprintf("LDAP Result Code %d %q", uint16(c), LDAPResultCodeMap[uint16(c)])
+}
+
+// Sentinel errors for the result codes, so that errors.Is(err, ErrNoSuchObject) is true for an *Error
+// with LDAPResultNoSuchObject
+var (
+	ErrOperationsError                    = resultCodeError(LDAPResultOperationsError)
+	ErrProtocolError                      = resultCodeError(LDAPResultProtocolError)
+	ErrTimeLimitExceeded                  = resultCodeError(LDAPResultTimeLimitExceeded)
+	ErrSizeLimitExceeded                  = resultCodeError(LDAPResultSizeLimitExceeded)
+	ErrCompareFalse                       = resultCodeError(LDAPResultCompareFalse)
+	ErrCompareTrue                        = resultCodeError(LDAPResultCompareTrue)
+	ErrAuthMethodNotSupported             = resultCodeError(LDAPResultAuthMethodNotSupported)
+	ErrStrongAuthRequired                 = resultCodeError(LDAPResultStrongAuthRequired)
+	ErrReferral                           = resultCodeError(LDAPResultReferral)
+	ErrAdminLimitExceeded                 = resultCodeError(LDAPResultAdminLimitExceeded)
+	ErrUnavailableCriticalExtension       = resultCodeError(LDAPResultUnavailableCriticalExtension)
+	ErrConfidentialityRequired            = resultCodeError(LDAPResultConfidentialityRequired)
+	ErrSaslBindInProgress                 = resultCodeError(LDAPResultSaslBindInProgress)
+	ErrNoSuchAttribute                    = resultCodeError(LDAPResultNoSuchAttribute)
+	ErrUndefinedAttributeType             = resultCodeError(LDAPResultUndefinedAttributeType)
+	ErrInappropriateMatching              = resultCodeError(LDAPResultInappropriateMatching)
+	ErrConstraintViolation                = resultCodeError(LDAPResultConstraintViolation)
+	ErrAttributeOrValueExists             = resultCodeError(LDAPResultAttributeOrValueExists)
+	ErrInvalidAttributeSyntax             = resultCodeError(LDAPResultInvalidAttributeSyntax)
+	ErrNoSuchObject                       = resultCodeError(LDAPResultNoSuchObject)
+	ErrAliasProblem                       = resultCodeError(LDAPResultAliasProblem)
+	ErrInvalidDNSyntax                    = resultCodeError(LDAPResultInvalidDNSyntax)
+	ErrIsLeaf                             = resultCodeError(LDAPResultIsLeaf)
+	ErrAliasDereferencingProblem          = resultCodeError(LDAPResultAliasDereferencingProblem)
+	ErrInappropriateAuthentication        = resultCodeError(LDAPResultInappropriateAuthentication)
+	ErrInvalidCredentials                 = resultCodeError(LDAPResultInvalidCredentials)
+	ErrInsufficientAccessRights           = resultCodeError(LDAPResultInsufficientAccessRights)
+	ErrBusy                               = resultCodeError(LDAPResultBusy)
+	ErrUnavailable                        = resultCodeError(LDAPResultUnavailable)
+	ErrUnwillingToPerform                 = resultCodeError(LDAPResultUnwillingToPerform)
+	ErrLoopDetect                         = resultCodeError(LDAPResultLoopDetect)
+	ErrSortControlMissing                 = resultCodeError(LDAPResultSortControlMissing)
+	ErrOffsetRangeError                   = resultCodeError(LDAPResultOffsetRangeError)
+	ErrNamingViolation                    = resultCodeError(LDAPResultNamingViolation)
+	ErrObjectClassViolation               = resultCodeError(LDAPResultObjectClassViolation)
+	ErrNotAllowedOnNonLeaf                = resultCodeError(LDAPResultNotAllowedOnNonLeaf)
+	ErrNotAllowedOnRDN                    = resultCodeError(LDAPResultNotAllowedOnRDN)
+	ErrEntryAlreadyExists                 = resultCodeError(LDAPResultEntryAlreadyExists)
+	ErrObjectClassModsProhibited          = resultCodeError(LDAPResultObjectClassModsProhibited)
+	ErrResultsTooLarge                    = resultCodeError(LDAPResultResultsTooLarge)
+	ErrAffectsMultipleDSAs                = resultCodeError(LDAPResultAffectsMultipleDSAs)
+	ErrVirtualListViewErrorOrControlError = resultCodeError(LDAPResultVirtualListViewErrorOrControlError)
+	ErrOther                              = resultCodeError(LDAPResultOther)
+	ErrServerDown                         = resultCodeError(LDAPResultServerDown)
+	ErrLocalError                         = resultCodeError(LDAPResultLocalError)
+	ErrEncodingError                      = resultCodeError(LDAPResultEncodingError)
+	ErrDecodingError                      = resultCodeError(LDAPResultDecodingError)
+	ErrTimeout                            = resultCodeError(LDAPResultTimeout)
+	ErrAuthUnknown                        = resultCodeError(LDAPResultAuthUnknown)
+	ErrFilterError                        = resultCodeError(LDAPResultFilterError)
+	ErrUserCanceled                       = resultCodeError(LDAPResultUserCanceled)
+	ErrParamError                         = resultCodeError(LDAPResultParamError)
+	ErrNoMemory                           = resultCodeError(LDAPResultNoMemory)
+	ErrConnectError                       = resultCodeError(LDAPResultConnectError)
+	ErrNotSupported                       = resultCodeError(LDAPResultNotSupported)
+	ErrControlNotFound                    = resultCodeError(LDAPResultControlNotFound)
+	ErrNoResultsReturned                  = resultCodeError(LDAPResultNoResultsReturned)
+	ErrMoreResultsToReturn                = resultCodeError(LDAPResultMoreResultsToReturn)
+	ErrClientLoop                         = resultCodeError(LDAPResultClientLoop)
+	ErrReferralLimitExceeded              = resultCodeError(LDAPResultReferralLimitExceeded)
+	ErrInvalidResponse                    = resultCodeError(LDAPResultInvalidResponse)
+	ErrAmbiguousResponse                  = resultCodeError(LDAPResultAmbiguousResponse)
+	ErrTLSNotSupported                    = resultCodeError(LDAPResultTLSNotSupported)
+	ErrIntermediateResponse               = resultCodeError(LDAPResultIntermediateResponse)
+	ErrUnknownType                        = resultCodeError(LDAPResultUnknownType)
+	ErrCanceled                           = resultCodeError(LDAPResultCanceled)
+	ErrNoSuchOperation                    = resultCodeError(LDAPResultNoSuchOperation)
+	ErrTooLate                            = resultCodeError(LDAPResultTooLate)
+	ErrCannotCancel                       = resultCodeError(LDAPResultCannotCancel)
+	ErrAssertionFailed                    = resultCodeError(LDAPResultAssertionFailed)
+	ErrAuthorizationDenied                = resultCodeError(LDAPResultAuthorizationDenied)
+	ErrSyncRefreshRequired                = resultCodeError(LDAPResultSyncRefreshRequired)
+	ErrNetwork                            = resultCodeError(ErrorNetwork)
+	ErrFilterCompile                      = resultCodeError(ErrorFilterCompile)
+	ErrFilterDecompile                    = resultCodeError(ErrorFilterDecompile)
+	ErrDebugging                          = resultCodeError(ErrorDebugging)
+	ErrUnexpectedMessage                  = resultCodeError(ErrorUnexpectedMessage)
+	ErrUnexpectedResponse                 = resultCodeError(ErrorUnexpectedResponse)
+	ErrEmptyPassword                      = resultCodeError(ErrorEmptyPassword)
+)
+
 // Error holds LDAP error information
 type Error struct {
 	// Err is the underlying error
@@ -184,14 +277,32 @@ type Error struct {
 	ResultCode uint16
 	// MatchedDN is the matchedDN returned if any
 	MatchedDN string
+	// Referrals are the referral URIs returned along with LDAPResultReferral, if any
+	Referrals []string
 	// Packet is the returned packet if any
 	Packet *ber.Packet
+	// ADDetails are the details of the diagnostic message if it was returned by Active Directory
+	ADDetails *ADErrorDetails
 }
 
 func (e *Error) Error() string {
 	return fmt.Sprintf("LDAP Result Code %d %q: %s", e.ResultCode, LDAPResultCodeMap[e.ResultCode], e.Err.Error())
 }
 
+// Unwrap returns the underlying error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is returns true if the target is the sentinel error of the result code of the error, e.g. ErrNoSuchObject,
+// or the ErrAD* error identified by its Active Directory details
+func (e *Error) Is(target error) bool {
+	if code, ok := target.(resultCodeError); ok {
+		return e.ResultCode == uint16(code)
+	}
+	return e.ADDetails != nil && e.ADDetails.Err() == target && target != nil
+}
+
 // GetLDAPError creates an Error out of a BER packet representing a LDAPResult
 // The return is an error object. It can be casted to a Error structure.
 // This function returns nil if resultCode in the LDAPResult sequence is success(0).
@@ -210,11 +321,14 @@ func GetLDAPError(packet *ber.Packet) error {
 			if resultCode == 0 { // No error
 				return nil
 			}
+			diagnosticMessage := response.Children[2].Value.(string)
 			return &Error{
 				ResultCode: resultCode,
 				MatchedDN:  response.Children[1].Value.(string),
-				Err:        fmt.Errorf("%s", response.Children[2].Value.(string)),
+				Referrals:  getReferrals(response),
+				Err:        fmt.Errorf("%s", diagnosticMessage),
 				Packet:     packet,
+				ADDetails:  ParseADErrorDetails(diagnosticMessage),
 			}
 		}
 	}
@@ -222,19 +336,49 @@ func GetLDAPError(packet *ber.Packet) error {
 	return &Error{ResultCode: ErrorNetwork, Err: fmt.Errorf("Invalid packet format"), Packet: packet}
 }
 
+// getLDAPResult returns the matchedDN, diagnosticMessage and referrals of the LDAPResult
+// in the given LDAPMessage packet
+func getLDAPResult(packet *ber.Packet) (matchedDN string, diagnosticMessage string, referrals []string) {
+	if len(packet.Children) < 2 || len(packet.Children[1].Children) < 3 {
+		return "", "", nil
+	}
+	response := packet.Children[1]
+	matchedDN, _ = response.Children[1].Value.(string)
+	diagnosticMessage, _ = response.Children[2].Value.(string)
+	return matchedDN, diagnosticMessage, getReferrals(response)
+}
+
+// getReferrals returns the URIs of the referral field ([3] SEQUENCE OF URI) of an LDAPResult
+func getReferrals(response *ber.Packet) []string {
+	var referrals []string
+	for _, child := range response.Children[3:] {
+		if child.ClassType != ber.ClassContext || child.Tag != 3 {
+			continue
+		}
+		for _, uri := range child.Children {
+			if s, ok := uri.Value.(string); ok {
+				referrals = append(referrals, s)
+			} else {
+				referrals = append(referrals, uri.Data.String())
+			}
+		}
+	}
+	return referrals
+}
+
 // NewError creates an LDAP error with the given code and underlying error
 func NewError(resultCode uint16, err error) error {
 	return &Error{ResultCode: resultCode, Err: err}
 }
 
-// IsErrorAnyOf returns true if the given error is an LDAP error with any one of the given result codes
+// IsErrorAnyOf returns true if the given error is, or wraps, an LDAP error with any one of the given result codes
 func IsErrorAnyOf(err error, codes ...uint16) bool {
 	if err == nil {
 		return false
 	}
 
-	serverError, ok := err.(*Error)
-	if !ok {
+	var serverError *Error
+	if !errors.As(err, &serverError) {
 		return false
 	}
 
@@ -251,3 +395,28 @@ func IsErrorAnyOf(err error, codes ...uint16) bool {
 func IsErrorWithCode(err error, desiredResultCode uint16) bool {
 	return IsErrorAnyOf(err, desiredResultCode)
 }
+
+// IsTransient returns true if the given error is likely to be temporary, so that the operation may succeed
+// when retried, possibly on a new connection: the server is busy, unavailable or down, the operation or
+// the connection timed out, or the network failed
+func IsTransient(err error) bool {
+	if IsErrorAnyOf(err, LDAPResultBusy, LDAPResultUnavailable, LDAPResultServerDown, LDAPResultTimeout,
+		LDAPResultConnectError, ErrorNetwork) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// IsAuthFailure returns true if the given error reports that authentication or authorization of the
+// client failed, e.g. because of invalid credentials or an unsupported or insufficient bind method
+func IsAuthFailure(err error) bool {
+	return IsErrorAnyOf(err, LDAPResultAuthMethodNotSupported, LDAPResultStrongAuthRequired,
+		LDAPResultInappropriateAuthentication, LDAPResultInvalidCredentials, LDAPResultAuthUnknown,
+		LDAPResultAuthorizationDenied, ErrorEmptyPassword)
+}
+
+// IsNotFound returns true if the given error reports that the entry does not exist
+func IsNotFound(err error) bool {
+	return IsErrorAnyOf(err, LDAPResultNoSuchObject, LDAPResultNoResultsReturned)
+}
@@ -123,15 +123,19 @@ func addLDAPDescriptions(packet *ber.Packet) (err error) {
 	case ApplicationModifyRequest:
 		err = addRequestDescriptions(packet)
 	case ApplicationModifyResponse:
+		err = addDefaultLDAPResponseDescriptions(packet)
 	case ApplicationAddRequest:
 		err = addRequestDescriptions(packet)
 	case ApplicationAddResponse:
+		err = addDefaultLDAPResponseDescriptions(packet)
 	case ApplicationDelRequest:
 		err = addRequestDescriptions(packet)
 	case ApplicationDelResponse:
+		err = addDefaultLDAPResponseDescriptions(packet)
 	case ApplicationModifyDNRequest:
 		err = addRequestDescriptions(packet)
 	case ApplicationModifyDNResponse:
+		err = addDefaultLDAPResponseDescriptions(packet)
 	case ApplicationCompareRequest:
 		err = addRequestDescriptions(packet)
 	case ApplicationCompareResponse:
@@ -247,6 +251,35 @@ func addControlDescriptions(packet *ber.Packet) error {
 					child.Value = val
 				}
 			}
+
+		case ControlTypeServerSideSorting:
+			value.Description += " (Server Side Sorting)"
+			if value.Value != nil {
+				valueChildren, err := ber.DecodePacketErr(value.Data.Bytes())
+				if err != nil {
+					return fmt.Errorf("failed to decode data bytes: %s", err)
+				}
+				value.Data.Truncate(0)
+				value.Value = nil
+				value.AppendChild(valueChildren)
+			}
+			if len(value.Children) == 0 {
+				return fmt.Errorf("sort control without sort key list")
+			}
+			value.Children[0].Description = "Sort Key List"
+			for _, key := range value.Children[0].Children {
+				key.Description = "Sort Key"
+				for _, child := range key.Children {
+					switch {
+					case child.ClassType == ber.ClassUniversal:
+						child.Description = "Attribute Type"
+					case child.Tag == 0:
+						child.Description = "Ordering Rule"
+					case child.Tag == 1:
+						child.Description = "Reverse Order"
+					}
+				}
+			}
 		}
 	}
 	return nil
@@ -290,14 +323,17 @@ func DebugBinaryFile(fileName string) error {
 	if err != nil {
 		return NewError(ErrorDebugging, err)
 	}
-	ber.PrintBytes(os.Stdout, file, "")
 	packet, err := ber.DecodePacketErr(file)
 	if err != nil {
+		ber.PrintBytes(os.Stdout, file, "")
 		return fmt.Errorf("failed to decode packet: %s", err)
 	}
 	if err := addLDAPDescriptions(packet); err != nil {
+		ber.PrintBytes(os.Stdout, file, "")
 		return err
 	}
+	packet = DefaultRedactor.Redact(packet)
+	ber.PrintBytes(os.Stdout, packet.Bytes(), "")
 	ber.PrintPacket(packet)
 
 	return nil
@@ -337,3 +373,15 @@ func EscapeFilter(filter string) string {
 	}
 	return string(buf)
 }
+
+// EscapeFilterBytes escapes every byte of the given binary value as defined in RFC4515,
+// e.g. for matching the objectSid or objectGUID attributes of Active Directory in filters.
+func EscapeFilterBytes(value []byte) string {
+	buf := make([]byte, len(value)*3)
+	for i, c := range value {
+		buf[i*3+0] = '\\'
+		buf[i*3+1] = hex[c>>4]
+		buf[i*3+2] = hex[c&0xf]
+	}
+	return string(buf)
+}
@@ -0,0 +1,254 @@
+package ldap
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// LDAPURL represents an LDAP URL as defined in https://tools.ietf.org/html/rfc4516
+type LDAPURL struct {
+	// Scheme is one of "ldap", "ldaps" or "ldapi"
+	Scheme string
+	// Host is the host name and optional port of the server, or the path of the
+	// Unix socket for the "ldapi" scheme. It is empty when the URL leaves the
+	// choice of the server to the client.
+	Host string
+	// DN is the distinguished name of the base object, or nil if not present
+	DN *DN
+	// Attributes are the attributes to return
+	Attributes []string
+	// Scope is the search scope, or -1 if the URL does not specify one
+	// (in which case ScopeBaseObject is implied for searches)
+	Scope int
+	// Filter is the search filter, or "" if not present
+	Filter string
+	// Extensions are the extensions of the URL
+	Extensions []LDAPURLExtension
+}
+
+// LDAPURLExtension represents an extension of an LDAP URL
+type LDAPURLExtension struct {
+	// Critical is set for extensions marked with a "!", which must be understood
+	// by a client processing the URL
+	Critical bool
+	// Type is the extension type, e.g. "bindname"
+	Type string
+	// Value is the extension value, if any
+	Value string
+}
+
+// ldapURLScopes maps the scope names of an LDAP URL to scope choices
+var ldapURLScopes = map[string]int{
+	"base": ScopeBaseObject,
+	"one":  ScopeSingleLevel,
+	"sub":  ScopeWholeSubtree,
+}
+
+// ParseLDAPURL parses an LDAP URL of the form "scheme://host:port/dn?attributes?scope?filter?extensions",
+// where every part after the host is optional.
+func ParseLDAPURL(rawURL string) (*LDAPURL, error) {
+	u := &LDAPURL{Scope: -1}
+
+	i := strings.Index(rawURL, "://")
+	if i < 0 {
+		return nil, errors.New("ldap: missing scheme in LDAP URL")
+	}
+	u.Scheme = strings.ToLower(rawURL[:i])
+	switch u.Scheme {
+	case "ldap", "ldaps", "ldapi":
+	default:
+		return nil, fmt.Errorf("ldap: unknown LDAP URL scheme %q", u.Scheme)
+	}
+	rest := rawURL[i+3:]
+
+	if i = strings.IndexByte(rest, '/'); i < 0 {
+		u.Host, rest = rest, ""
+	} else {
+		u.Host, rest = rest[:i], rest[i+1:]
+	}
+	host, err := url.PathUnescape(u.Host)
+	if err != nil {
+		return nil, fmt.Errorf("ldap: invalid host in LDAP URL: %s", err)
+	}
+	u.Host = host
+
+	parts := strings.SplitN(rest, "?", 5)
+	for i := 0; i < len(parts) && i < 4; i++ {
+		if parts[i], err = url.PathUnescape(parts[i]); err != nil {
+			return nil, fmt.Errorf("ldap: invalid escaping in LDAP URL: %s", err)
+		}
+	}
+	if parts[0] != "" {
+		if u.DN, err = ParseDN(parts[0]); err != nil {
+			return nil, fmt.Errorf("ldap: invalid DN in LDAP URL: %s", err)
+		}
+	}
+	if len(parts) > 1 && parts[1] != "" {
+		u.Attributes = strings.Split(parts[1], ",")
+	}
+	if len(parts) > 2 && parts[2] != "" {
+		scope, ok := ldapURLScopes[strings.ToLower(parts[2])]
+		if !ok {
+			return nil, fmt.Errorf("ldap: invalid scope %q in LDAP URL", parts[2])
+		}
+		u.Scope = scope
+	}
+	if len(parts) > 3 && parts[3] != "" {
+		if _, err := CompileFilter(parts[3]); err != nil {
+			return nil, fmt.Errorf("ldap: invalid filter in LDAP URL: %s", err)
+		}
+		u.Filter = parts[3]
+	}
+	if len(parts) > 4 && strings.IndexByte(parts[4], '?') >= 0 {
+		return nil, errors.New("ldap: too many parts in LDAP URL")
+	}
+	if len(parts) > 4 && parts[4] != "" {
+		// extensions are split before unescaping as they may contain escaped commas
+		for _, ext := range strings.Split(parts[4], ",") {
+			// an escaped "!" does not mark the extension as critical
+			var extension LDAPURLExtension
+			if strings.HasPrefix(ext, "!") {
+				extension.Critical = true
+				ext = ext[1:]
+			}
+			if ext, err = url.PathUnescape(ext); err != nil {
+				return nil, fmt.Errorf("ldap: invalid escaping in LDAP URL extension: %s", err)
+			}
+			if i := strings.IndexByte(ext, '='); i >= 0 {
+				extension.Type, extension.Value = ext[:i], ext[i+1:]
+			} else {
+				extension.Type = ext
+			}
+			if extension.Type == "" {
+				return nil, errors.New("ldap: empty extension type in LDAP URL")
+			}
+			u.Extensions = append(u.Extensions, extension)
+		}
+	}
+	return u, nil
+}
+
+// String returns the string representation of the LDAP URL, escaping its parts as needed
+func (u *LDAPURL) String() string {
+	var buf strings.Builder
+	buf.WriteString(u.Scheme)
+	buf.WriteString("://")
+	buf.WriteString(escapeLDAPURLPart(u.Host, "/?"))
+
+	parts := make([]string, 5)
+	if u.DN != nil {
+		parts[0] = escapeLDAPURLPart(u.DN.String(), "?")
+	}
+	attributes := make([]string, len(u.Attributes))
+	for i, attr := range u.Attributes {
+		attributes[i] = escapeLDAPURLPart(attr, "?,")
+	}
+	parts[1] = strings.Join(attributes, ",")
+	for name, scope := range ldapURLScopes {
+		if scope == u.Scope {
+			parts[2] = name
+		}
+	}
+	parts[3] = escapeLDAPURLPart(u.Filter, "?")
+	extensions := make([]string, len(u.Extensions))
+	for i, ext := range u.Extensions {
+		s := escapeLDAPURLPart(ext.Type, "?,")
+		if strings.HasPrefix(s, "!") {
+			s = "%21" + s[1:]
+		}
+		if ext.Value != "" {
+			s += "=" + escapeLDAPURLPart(ext.Value, "?,")
+		}
+		if ext.Critical {
+			s = "!" + s
+		}
+		extensions[i] = s
+	}
+	parts[4] = strings.Join(extensions, ",")
+
+	// omit empty trailing parts
+	n := len(parts)
+	for n > 0 && parts[n-1] == "" {
+		n--
+	}
+	if n > 0 || u.DN != nil {
+		buf.WriteByte('/')
+		buf.WriteString(strings.Join(parts[:n], "?"))
+	}
+	return buf.String()
+}
+
+// Extension returns the extension of the given type (compared case-insensitively), or nil
+func (u *LDAPURL) Extension(extensionType string) *LDAPURLExtension {
+	for i := range u.Extensions {
+		if strings.EqualFold(u.Extensions[i].Type, extensionType) {
+			return &u.Extensions[i]
+		}
+	}
+	return nil
+}
+
+// Address returns the URL of the server named in the LDAP URL, suitable for DialURL
+func (u *LDAPURL) Address() string {
+	if u.Scheme == "ldapi" {
+		// DialURL takes the socket path from the path of the URL rather than from its host
+		return u.Scheme + "://" + (&url.URL{Path: u.Host}).EscapedPath()
+	}
+	return (&LDAPURL{Scheme: u.Scheme, Host: u.Host, Scope: -1}).String()
+}
+
+// SearchRequest returns a search request for the base DN, scope, filter and attributes of the LDAP URL,
+// using the defaults of https://tools.ietf.org/html/rfc4516#section-2 for the parts not present
+func (u *LDAPURL) SearchRequest(controls []Control) *SearchRequest {
+	baseDN := ""
+	if u.DN != nil {
+		baseDN = u.DN.String()
+	}
+	scope := u.Scope
+	if scope < 0 {
+		scope = ScopeBaseObject
+	}
+	filter := u.Filter
+	if filter == "" {
+		filter = "(objectClass=*)"
+	}
+	return NewSearchRequest(baseDN, scope, NeverDerefAliases, 0, 0, false, filter, u.Attributes, controls)
+}
+
+// key identifies the server, entry and search parameters of the URL for loop detection
+func (u *LDAPURL) key() string {
+	dn := ""
+	if u.DN != nil {
+		dn = strings.ToLower(u.DN.String())
+	}
+	return fmt.Sprintf("%s://%s/%s?%d?%s", u.Scheme, strings.ToLower(u.Host), dn, u.Scope, u.Filter)
+}
+
+// escapeLDAPURLPart percent-encodes the characters of s which may not appear in an LDAP URL,
+// as well as the given reserved characters
+func escapeLDAPURLPart(s string, reserved string) string {
+	var buf strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if isLDAPURLChar(c) && strings.IndexByte(reserved, c) < 0 {
+			buf.WriteByte(c)
+			continue
+		}
+		buf.WriteByte('%')
+		buf.WriteByte(hex[c>>4])
+		buf.WriteByte(hex[c&0xf])
+	}
+	return buf.String()
+}
+
+// isLDAPURLChar returns whether c is an unreserved, sub-delims, ":", "@" or "/" character
+// of https://tools.ietf.org/html/rfc3986, or one of "[]" used in IPv6 hosts
+func isLDAPURLChar(c byte) bool {
+	switch {
+	case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		return true
+	}
+	return strings.IndexByte("-._~!$&'()*+,;=:@/[]", c) >= 0
+}
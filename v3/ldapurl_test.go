@@ -0,0 +1,164 @@
+package ldap
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseLDAPURL(t *testing.T) {
+	dn := func(s string) *DN {
+		d, err := ParseDN(s)
+		if err != nil {
+			t.Fatalf("invalid DN %q: %s", s, err)
+		}
+		return d
+	}
+
+	testcases := map[string]*LDAPURL{
+		"ldap://": {Scheme: "ldap", Scope: -1},
+		"ldap://ldap.example.org:389/dc=example,dc=org": {
+			Scheme: "ldap", Host: "ldap.example.org:389", DN: dn("dc=example,dc=org"), Scope: -1},
+		"LDAPS://[2001:db8::7]/c=GB?objectClass?one": {
+			Scheme: "ldaps", Host: "[2001:db8::7]", DN: dn("c=GB"), Attributes: []string{"objectClass"}, Scope: ScopeSingleLevel},
+		"ldap://ldap.example.org/o=University%20of%20Michigan,c=US?postalAddress,cn?sub?(cn=Babs%20Jensen)": {
+			Scheme: "ldap", Host: "ldap.example.org", DN: dn("o=University of Michigan,c=US"), Attributes: []string{"postalAddress", "cn"},
+			Scope: ScopeWholeSubtree, Filter: "(cn=Babs Jensen)"},
+		"ldap://ldap.example.com/o=An%20Example%5C2C%20Inc.,c=US": {
+			Scheme: "ldap", Host: "ldap.example.com", DN: dn(`o=An Example\2C Inc.,c=US`), Scope: -1},
+		"ldapi://%2Fvar%2Frun%2Fldapi": {Scheme: "ldapi", Host: "/var/run/ldapi", Scope: -1},
+		"ldap:///??sub??e-bindname=cn=Manager%2cdc=example%2cdc=com": {
+			Scheme: "ldap", Scope: ScopeWholeSubtree, Extensions: []LDAPURLExtension{
+				{Type: "e-bindname", Value: "cn=Manager,dc=example,dc=com"}}},
+		"ldap:///??sub??!bindname=cn=Manager%2cdc=example%2cdc=com,x-y": {
+			Scheme: "ldap", Scope: ScopeWholeSubtree, Extensions: []LDAPURLExtension{
+				{Critical: true, Type: "bindname", Value: "cn=Manager,dc=example,dc=com"}, {Type: "x-y"}}},
+		"ldap:///????%21x-y": {Scheme: "ldap", Scope: -1, Extensions: []LDAPURLExtension{{Type: "!x-y"}}},
+	}
+	for rawURL, want := range testcases {
+		got, err := ParseLDAPURL(rawURL)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", rawURL, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: got %#v, want %#v", rawURL, got, want)
+		}
+	}
+
+	for _, rawURL := range []string{"ldap.example.org", "http://ldap.example.org/", "ldap:///dc=org??subtree", "ldap:///dc=%zz",
+		"ldap:///??sub?(cn=foo", "ldap:///????!", "ldap:///????x?y"} {
+		if _, err := ParseLDAPURL(rawURL); err == nil {
+			t.Errorf("%q: expected an error", rawURL)
+		}
+	}
+}
+
+func TestLDAPURLString(t *testing.T) {
+	testcases := map[string]string{
+		"ldap://":                      "ldap://",
+		"ldap:///":                     "ldap://",
+		"ldap://ldap.example.org:389/": "ldap://ldap.example.org:389",
+		"ldap://ldap.example.org/o=University%20of%20Michigan,c=US?postalAddress,cn?sub?(cn=Babs%20Jensen)": "ldap://ldap.example.org/o=University%20of%20Michigan,c=US?postalAddress,cn?sub?(cn=Babs%20Jensen)",
+		"ldap://ldap.example.com/o=An%20Example%5C2C%20Inc.,c=US":                                           "ldap://ldap.example.com/o=An%20Example%5c,%20Inc.,c=US",
+		"ldap://host/dc=example,dc=org???(cn=what%3f)":                                                      "ldap://host/dc=example,dc=org???(cn=what%3f)",
+		"ldap:///??sub??!bindname=cn=Manager%2cdc=example%2cdc=com,x-y":                                     "ldap:///??sub??!bindname=cn=Manager%2cdc=example%2cdc=com,x-y",
+		"ldapi://%2Fvar%2Frun%2Fldapi/dc=example,dc=org":                                                    "ldapi://%2fvar%2frun%2fldapi/dc=example,dc=org",
+		"ldap:///????%21x-y": "ldap:///????%21x-y",
+	}
+	for rawURL, want := range testcases {
+		u, err := ParseLDAPURL(rawURL)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", rawURL, err)
+			continue
+		}
+		got := u.String()
+		if got != want {
+			t.Errorf("%q: got %q, want %q", rawURL, got, want)
+		}
+		if _, err := ParseLDAPURL(got); err != nil {
+			t.Errorf("%q: could not parse string representation %q: %s", rawURL, got, err)
+		}
+	}
+}
+
+func TestLDAPURLAddress(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ldapurl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	unixListener, err := net.Listen("unix", filepath.Join(dir, "ldap i?.sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unixListener.Close()
+	tcpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tcpListener.Close()
+
+	// the address of every scheme is dialed by DialURL
+	for _, tc := range []struct {
+		url      *LDAPURL
+		listener net.Listener
+	}{
+		{&LDAPURL{Scheme: "ldapi", Host: unixListener.Addr().String(), Scope: -1}, unixListener},
+		{&LDAPURL{Scheme: "ldap", Host: tcpListener.Addr().String(), Scope: -1}, tcpListener},
+		{&LDAPURL{Scheme: "ldaps", Host: tcpListener.Addr().String(), Scope: -1}, tcpListener},
+	} {
+		u, err := ParseLDAPURL(tc.url.String())
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", tc.url, err)
+		}
+		accepted := make(chan error, 1)
+		go func(listener net.Listener) {
+			conn, err := listener.Accept()
+			if err == nil {
+				conn.Close()
+			}
+			accepted <- err
+		}(tc.listener)
+		// the handshake of ldaps fails, but only after connecting to the server
+		if conn, err := DialURL(u.Address(), DialWithTLSConfig(&tls.Config{InsecureSkipVerify: true})); err == nil {
+			conn.Close()
+		} else if u.Scheme != "ldaps" {
+			t.Errorf("%s: could not dial address %q: %s", u, u.Address(), err)
+			continue
+		}
+		if err := <-accepted; err != nil {
+			t.Errorf("%s: unexpected error: %s", u, err)
+		}
+	}
+}
+
+func TestLDAPURLSearchRequest(t *testing.T) {
+	u, err := ParseLDAPURL("ldaps://ldap.example.org:636/ou=people,dc=example,dc=org?cn,mail??(uid=*)?x-paged")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := u.Address(); got != "ldaps://ldap.example.org:636" {
+		t.Errorf("unexpected address %q", got)
+	}
+	if u.Extension("X-Paged") == nil || u.Extension("bindname") != nil {
+		t.Errorf("unexpected extensions: %v", u.Extensions)
+	}
+
+	req := u.SearchRequest(nil)
+	if req.BaseDN != "ou=people,dc=example,dc=org" || req.Scope != ScopeBaseObject || req.Filter != "(uid=*)" ||
+		!reflect.DeepEqual(req.Attributes, []string{"cn", "mail"}) {
+		t.Errorf("unexpected search request: %#v", req)
+	}
+
+	u, _ = ParseLDAPURL("ldap:///??one")
+	req = u.SearchRequest(nil)
+	if req.BaseDN != "" || req.Scope != ScopeSingleLevel || req.Filter != "(objectClass=*)" {
+		t.Errorf("unexpected search request: %#v", req)
+	}
+}
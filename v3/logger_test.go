@@ -0,0 +1,108 @@
+package ldap
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+)
+
+// testLogger records the log records it receives
+type testLogger struct {
+	mutex   sync.Mutex
+	records []string
+	fields  []map[string]interface{}
+}
+
+func (t *testLogger) Debug(msg string, args ...interface{}) { t.log(LevelDebug, msg, args) }
+func (t *testLogger) Info(msg string, args ...interface{})  { t.log(LevelInfo, msg, args) }
+func (t *testLogger) Warn(msg string, args ...interface{})  { t.log(LevelWarn, msg, args) }
+func (t *testLogger) Error(msg string, args ...interface{}) { t.log(LevelError, msg, args) }
+
+func (t *testLogger) log(level LogLevel, msg string, args []interface{}) {
+	fields := make(map[string]interface{})
+	for i := 0; i+1 < len(args); i += 2 {
+		fields[fmt.Sprint(args[i])] = args[i+1]
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.records = append(t.records, formatLogRecord(level, msg, args))
+	t.fields = append(t.fields, fields)
+}
+
+// find returns the fields of the first record with the given message
+func (t *testLogger) find(msg string) map[string]interface{} {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	for i, record := range t.records {
+		if strings.HasPrefix(record, msg) || strings.Contains(record, " "+msg) {
+			return t.fields[i]
+		}
+	}
+	return nil
+}
+
+func TestConnLogger(t *testing.T) {
+	conn := newTestServerConn(t, func(request *ber.Packet) []*ber.Packet {
+		return []*ber.Packet{newTestLDAPResult(ApplicationBindResponse, LDAPResultSuccess)}
+	})
+	defer conn.Close()
+	logger := &testLogger{}
+	conn.SetLogger(logger)
+
+	if err := conn.Bind("cn=admin,dc=example,dc=org", "s3cr3t-password"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	sent := logger.find("sending request")
+	if sent == nil || sent[LogKeyMessageID] != int64(1) || sent[LogKeyOperation] != "Bind Request" {
+		t.Fatalf("unexpected request record %v in %q", sent, logger.records)
+	}
+	dump := fmt.Sprint(sent[LogKeyPacket])
+	if strings.Contains(dump, "s3cr3t-password") || !strings.Contains(dump, redactedValue) || !strings.Contains(dump, "cn=admin,dc=example,dc=org") {
+		t.Errorf("expected the password to be redacted from the packet dump:\n%s", dump)
+	}
+	// JSON handlers log the text of the dump
+	if text, err := json.Marshal(sent[LogKeyPacket]); err != nil || strings.Contains(string(text), "s3cr3t-password") || !strings.Contains(string(text), "cn=admin,dc=example,dc=org") {
+		t.Errorf("unexpected JSON packet dump %s (%v)", text, err)
+	}
+	if received := logger.find("got response"); received == nil || received[LogKeyPacket] == nil {
+		t.Errorf("expected a response record in %q", logger.records)
+	}
+	finished := logger.find("finished request")
+	if _, ok := finished[LogKeyDuration].(time.Duration); !ok || finished[LogKeyOperation] != "Bind Request" {
+		t.Errorf("unexpected finish record %v", finished)
+	}
+
+	// redaction can be disabled for local debugging
+	conn.SetRedactor(&Redactor{Disabled: true})
+	logger.mutex.Lock()
+	logger.records, logger.fields = nil, nil
+	logger.mutex.Unlock()
+	if err := conn.Bind("cn=admin,dc=example,dc=org", "s3cr3t-password"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if dump := fmt.Sprint(logger.find("sending request")[LogKeyPacket]); !strings.Contains(dump, "s3cr3t-password") {
+		t.Errorf("expected the password in the packet dump:\n%s", dump)
+	}
+}
+
+func TestStdLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewStdLogger(log.New(&buf, "", 0), LevelInfo)
+	logger.Debug("not written")
+	logger.Info("sending request", LogKeyMessageID, 3, LogKeyOperation, "Search Request", "odd")
+	logger.Error("failed", LogKeyError, "multi\nline")
+
+	want := "INFO sending request messageID=3 operation=\"Search Request\" odd=!MISSING\n" +
+		"ERROR failed error=\nmulti\nline\n"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,747 @@
+package ldap
+
+import (
+	"errors"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Matcher implements a matching rule for client-side filter evaluation. The equality, ordering and
+// substrings rules for the same kind of values, e.g. caseIgnoreMatch, caseIgnoreOrderingMatch and
+// caseIgnoreSubstringsMatch, share a Matcher. Errors returned by its functions make the comparison
+// undefined, as for a server.
+type Matcher struct {
+	// Normalize returns the canonical form of a value, so that equal values have the same canonical
+	// form. It is used for equality and substrings matches, and substrings matches are undefined if it is nil.
+	Normalize func(value string) (string, error)
+	// Compare returns a negative number, zero or a positive number if a is less than, equal to or
+	// greater than b. It is used for ordering matches, which are undefined if it is nil.
+	Compare func(a, b string) (int, error)
+	// Match, if set, is used instead of Normalize for equality and extensible matches, e.g. for bitwise rules
+	Match func(value, assertion string) (bool, error)
+
+	// normalizeSubstring, if set, is used instead of Normalize for the components of substrings
+	// assertions, keeping the spaces at their edges if leading or trailing spaces are significant
+	normalizeSubstring func(part string, leading, trailing bool) (string, error)
+}
+
+var (
+	matchersMutex sync.RWMutex
+	matchers      = make(map[string]*Matcher)
+)
+
+// RegisterMatcher registers the matcher for the matching rules with the given names (compared
+// case-insensitively) or OIDs, replacing any matcher registered for them before
+func RegisterMatcher(m *Matcher, namesOrOIDs ...string) {
+	matchersMutex.Lock()
+	defer matchersMutex.Unlock()
+	for _, name := range namesOrOIDs {
+		matchers[strings.ToLower(name)] = m
+	}
+}
+
+// lookupMatcher returns the matcher registered for the matching rule with the given name or OID, or nil
+func lookupMatcher(nameOrOID string) *Matcher {
+	matchersMutex.RLock()
+	defer matchersMutex.RUnlock()
+	return matchers[strings.ToLower(nameOrOID)]
+}
+
+// Matching rules for Active Directory bitwise comparisons of integer attributes, see
+// https://docs.microsoft.com/en-us/windows/win32/adsi/search-filter-syntax
+const (
+	MatchingRuleBitAnd = "1.2.840.113556.1.4.803"
+	MatchingRuleBitOr  = "1.2.840.113556.1.4.804"
+)
+
+func init() {
+	caseIgnore := &Matcher{Normalize: normalizeCaseIgnore, normalizeSubstring: func(part string, leading, trailing bool) (string, error) {
+		return strings.ToLower(collapseSpaces(part, leading, trailing)), nil
+	}}
+	caseIgnore.Compare = compareNormalized(caseIgnore.Normalize)
+	RegisterMatcher(caseIgnore,
+		"2.5.13.2", "caseIgnoreMatch", "2.5.13.3", "caseIgnoreOrderingMatch", "2.5.13.4", "caseIgnoreSubstringsMatch",
+		"1.3.6.1.4.1.1466.109.114.2", "caseIgnoreIA5Match", "1.3.6.1.4.1.1466.109.114.3", "caseIgnoreIA5SubstringsMatch",
+		"2.5.13.11", "caseIgnoreListMatch", "2.5.13.12", "caseIgnoreListSubstringsMatch")
+
+	caseExact := &Matcher{Normalize: normalizeCaseExact, normalizeSubstring: func(part string, leading, trailing bool) (string, error) {
+		return collapseSpaces(part, leading, trailing), nil
+	}}
+	caseExact.Compare = compareNormalized(caseExact.Normalize)
+	RegisterMatcher(caseExact,
+		"2.5.13.5", "caseExactMatch", "2.5.13.6", "caseExactOrderingMatch", "2.5.13.7", "caseExactSubstringsMatch",
+		"1.3.6.1.4.1.1466.109.114.1", "caseExactIA5Match")
+
+	numericString := &Matcher{Normalize: func(value string) (string, error) {
+		return strings.Replace(value, " ", "", -1), nil
+	}}
+	numericString.Compare = compareNormalized(numericString.Normalize)
+	RegisterMatcher(numericString,
+		"2.5.13.8", "numericStringMatch", "2.5.13.9", "numericStringOrderingMatch", "2.5.13.10", "numericStringSubstringsMatch")
+
+	RegisterMatcher(&Matcher{Normalize: func(value string) (string, error) {
+		return strings.ToLower(strings.Map(func(r rune) rune {
+			if r == ' ' || r == '-' {
+				return -1
+			}
+			return r
+		}, value)), nil
+	}}, "2.5.13.20", "telephoneNumberMatch", "2.5.13.21", "telephoneNumberSubstringsMatch")
+
+	octetString := &Matcher{Normalize: func(value string) (string, error) {
+		return value, nil
+	}}
+	octetString.Compare = compareNormalized(octetString.Normalize)
+	RegisterMatcher(octetString, "2.5.13.17", "octetStringMatch", "2.5.13.18", "octetStringOrderingMatch")
+
+	RegisterMatcher(&Matcher{Normalize: func(value string) (string, error) {
+		if value != "TRUE" && value != "FALSE" {
+			return "", errors.New("ldap: not a boolean")
+		}
+		return value, nil
+	}}, "2.5.13.13", "booleanMatch")
+
+	RegisterMatcher(&Matcher{Normalize: func(value string) (string, error) {
+		return strings.ToLower(strings.TrimSpace(value)), nil
+	}}, "2.5.13.0", "objectIdentifierMatch")
+
+	RegisterMatcher(&Matcher{
+		Normalize: func(value string) (string, error) {
+			i, err := parseBigInt(value)
+			if err != nil {
+				return "", err
+			}
+			return i.String(), nil
+		},
+		Compare: func(a, b string) (int, error) {
+			x, err := parseBigInt(a)
+			if err != nil {
+				return 0, err
+			}
+			y, err := parseBigInt(b)
+			if err != nil {
+				return 0, err
+			}
+			return x.Cmp(y), nil
+		},
+	}, "2.5.13.14", "integerMatch", "2.5.13.15", "integerOrderingMatch")
+
+	RegisterMatcher(&Matcher{
+		Normalize: func(value string) (string, error) {
+			t, err := parseGeneralizedTime(value)
+			if err != nil {
+				return "", err
+			}
+			return t.UTC().Format("20060102150405.999999999Z"), nil
+		},
+		Compare: func(a, b string) (int, error) {
+			x, err := parseGeneralizedTime(a)
+			if err != nil {
+				return 0, err
+			}
+			y, err := parseGeneralizedTime(b)
+			if err != nil {
+				return 0, err
+			}
+			return compareTimes(x, y), nil
+		},
+	}, "2.5.13.27", "generalizedTimeMatch", "2.5.13.28", "generalizedTimeOrderingMatch")
+
+	RegisterMatcher(&Matcher{Match: func(value, assertion string) (bool, error) {
+		x, err := ParseDN(value)
+		if err != nil {
+			return false, err
+		}
+		y, err := ParseDN(assertion)
+		if err != nil {
+			return false, err
+		}
+		return x.EqualFold(y), nil
+	}}, "2.5.13.1", "distinguishedNameMatch")
+
+	RegisterMatcher(&Matcher{Match: bitwiseMatch(func(value, assertion int64) bool {
+		return value&assertion == assertion
+	})}, MatchingRuleBitAnd)
+	RegisterMatcher(&Matcher{Match: bitwiseMatch(func(value, assertion int64) bool {
+		return value&assertion != 0
+	})}, MatchingRuleBitOr)
+}
+
+// normalizeCaseExact removes leading and trailing spaces and collapses inner spaces, see https://tools.ietf.org/html/rfc4518#section-2.6.1
+func normalizeCaseExact(value string) (string, error) {
+	return collapseSpaces(value, false, false), nil
+}
+
+// collapseSpaces collapses inner spaces into one space and removes leading and trailing spaces, or
+// replaces them by one space if they are significant, as at the edges of the components of substrings
+// assertions, see https://tools.ietf.org/html/rfc4518#section-2.6.1
+func collapseSpaces(value string, leading, trailing bool) string {
+	collapsed := strings.Join(strings.Fields(value), " ")
+	if collapsed == "" {
+		if value != "" && (leading || trailing) {
+			return " "
+		}
+		return ""
+	}
+	if leading && strings.TrimLeftFunc(value, unicode.IsSpace) != value {
+		collapsed = " " + collapsed
+	}
+	if trailing && strings.TrimRightFunc(value, unicode.IsSpace) != value {
+		collapsed += " "
+	}
+	return collapsed
+}
+
+func normalizeCaseIgnore(value string) (string, error) {
+	value, _ = normalizeCaseExact(value)
+	return strings.ToLower(value), nil
+}
+
+func compareNormalized(normalize func(string) (string, error)) func(a, b string) (int, error) {
+	return func(a, b string) (int, error) {
+		x, err := normalize(a)
+		if err != nil {
+			return 0, err
+		}
+		y, err := normalize(b)
+		if err != nil {
+			return 0, err
+		}
+		return strings.Compare(x, y), nil
+	}
+}
+
+func parseBigInt(value string) (*big.Int, error) {
+	i, ok := new(big.Int).SetString(value, 10)
+	if !ok {
+		return nil, errors.New("ldap: not an integer")
+	}
+	return i, nil
+}
+
+func compareTimes(x, y time.Time) int {
+	switch {
+	case x.Before(y):
+		return -1
+	case x.After(y):
+		return 1
+	}
+	return 0
+}
+
+func bitwiseMatch(match func(value, assertion int64) bool) func(value, assertion string) (bool, error) {
+	return func(value, assertion string) (bool, error) {
+		x, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return false, err
+		}
+		y, err := strconv.ParseInt(assertion, 10, 64)
+		if err != nil {
+			return false, err
+		}
+		return match(x, y), nil
+	}
+}
+
+// defaultAttributeMatchingRules are the equality rules of common attributes, used when the schema
+// of the evaluator does not define them. Other attributes are compared with caseIgnoreMatch.
+var defaultAttributeMatchingRules = map[string]string{
+	"objectclass":                        "objectIdentifierMatch",
+	"uidnumber":                          "integerMatch",
+	"gidnumber":                          "integerMatch",
+	"useraccountcontrol":                 "integerMatch",
+	"msds-user-account-control-computed": "integerMatch",
+	"grouptype":                          "integerMatch",
+	"samaccounttype":                     "integerMatch",
+	"primarygroupid":                     "integerMatch",
+	"pwdlastset":                         "integerMatch",
+	"accountexpires":                     "integerMatch",
+	"lastlogon":                          "integerMatch",
+	"lastlogontimestamp":                 "integerMatch",
+	"badpwdcount":                        "integerMatch",
+	"logoncount":                         "integerMatch",
+	"usnchanged":                         "integerMatch",
+	"usncreated":                         "integerMatch",
+	"createtimestamp":                    "generalizedTimeMatch",
+	"modifytimestamp":                    "generalizedTimeMatch",
+	"whencreated":                        "generalizedTimeMatch",
+	"whenchanged":                        "generalizedTimeMatch",
+	"pwdchangedtime":                     "generalizedTimeMatch",
+	"member":                             "distinguishedNameMatch",
+	"memberof":                           "distinguishedNameMatch",
+	"manager":                            "distinguishedNameMatch",
+	"directreports":                      "distinguishedNameMatch",
+	"owner":                              "distinguishedNameMatch",
+	"seealso":                            "distinguishedNameMatch",
+	"secretary":                          "distinguishedNameMatch",
+	"distinguishedname":                  "distinguishedNameMatch",
+	"creatorsname":                       "distinguishedNameMatch",
+	"modifiersname":                      "distinguishedNameMatch",
+	"telephonenumber":                    "telephoneNumberMatch",
+	"mobile":                             "telephoneNumberMatch",
+	"homephone":                          "telephoneNumberMatch",
+	"facsimiletelephonenumber":           "telephoneNumberMatch",
+}
+
+// syntaxMatchingRules are the equality rules of attributes with the given syntax that define none
+var syntaxMatchingRules = map[string]string{
+	SyntaxBoolean:         "booleanMatch",
+	SyntaxDN:              "distinguishedNameMatch",
+	SyntaxGeneralizedTime: "generalizedTimeMatch",
+	SyntaxInteger:         "integerMatch",
+	SyntaxNumericString:   "numericStringMatch",
+	SyntaxOID:             "objectIdentifierMatch",
+	SyntaxTelephoneNumber: "telephoneNumberMatch",
+}
+
+// filterResult is the result of evaluating a filter, see https://tools.ietf.org/html/rfc4511#section-4.5.1.7
+type filterResult int
+
+const (
+	filterFalse filterResult = iota
+	filterTrue
+	filterUndefined
+)
+
+func newFilterResult(matched bool) filterResult {
+	if matched {
+		return filterTrue
+	}
+	return filterFalse
+}
+
+// kinds of matching rules of attribute types
+const (
+	equalityRule = iota
+	orderingRule
+	substringsRule
+)
+
+// FilterEvaluator evaluates filters against entries on the client side, e.g. to check an entry read
+// earlier or to filter cached entries. Comparisons which cannot be decided, like ordering matches on
+// attributes without ordering or matches using unknown matching rules, are undefined and never match,
+// even when negated.
+type FilterEvaluator struct {
+	// Schema, if set, provides the matching rules and superior types of attribute types. Without a
+	// schema, common attributes are compared with their usual rules and others with caseIgnoreMatch.
+	Schema *Schema
+}
+
+var defaultFilterEvaluator = &FilterEvaluator{}
+
+// MatchFilter returns true if the entry matches the filter, evaluated without a schema
+func MatchFilter(filter string, entry *Entry) (bool, error) {
+	f, err := ParseFilter(filter)
+	if err != nil {
+		return false, err
+	}
+	return f.Matches(entry), nil
+}
+
+// Matches returns true if the entry matches the filter
+func (ev *FilterEvaluator) Matches(filter Filter, entry *Entry) bool {
+	return ev.evaluate(filter, entry) == filterTrue
+}
+
+func (ev *FilterEvaluator) evaluate(filter Filter, entry *Entry) filterResult {
+	switch f := filter.(type) {
+	case *AndFilter:
+		result := filterTrue
+		for _, child := range f.Filters {
+			switch ev.evaluate(child, entry) {
+			case filterFalse:
+				return filterFalse
+			case filterUndefined:
+				result = filterUndefined
+			}
+		}
+		return result
+	case *OrFilter:
+		result := filterFalse
+		for _, child := range f.Filters {
+			switch ev.evaluate(child, entry) {
+			case filterTrue:
+				return filterTrue
+			case filterUndefined:
+				result = filterUndefined
+			}
+		}
+		return result
+	case *NotFilter:
+		switch ev.evaluate(f.Filter, entry) {
+		case filterTrue:
+			return filterFalse
+		case filterFalse:
+			return filterTrue
+		}
+		return filterUndefined
+	case *EqualityFilter:
+		return ev.evaluateValues(f.Attribute, entry, func(m *Matcher, value string) (bool, error) {
+			return matcherEqual(m, value, f.Value)
+		}, equalityRule)
+	case *SubstringsFilter:
+		return ev.evaluateValues(f.Attribute, entry, func(m *Matcher, value string) (bool, error) {
+			return matchSubstrings(m, value, f)
+		}, substringsRule)
+	case *GreaterOrEqualFilter:
+		return ev.evaluateValues(f.Attribute, entry, func(m *Matcher, value string) (bool, error) {
+			c, err := m.Compare(value, f.Value)
+			return c >= 0, err
+		}, orderingRule)
+	case *LessOrEqualFilter:
+		return ev.evaluateValues(f.Attribute, entry, func(m *Matcher, value string) (bool, error) {
+			c, err := m.Compare(value, f.Value)
+			return c <= 0, err
+		}, orderingRule)
+	case *PresentFilter:
+		// every entry has an object class, even if it was not requested
+		if strings.EqualFold(f.Attribute, "objectClass") {
+			return filterTrue
+		}
+		return newFilterResult(len(ev.attributeValues(f.Attribute, entry)) > 0)
+	case *ApproxFilter:
+		return ev.evaluateValues(f.Attribute, entry, func(m *Matcher, value string) (bool, error) {
+			if matched, err := matcherEqual(m, value, f.Value); err == nil && matched {
+				return true, nil
+			}
+			return approxMatch(value, f.Value), nil
+		}, equalityRule)
+	case *ExtensibleFilter:
+		return ev.evaluateExtensible(f, entry)
+	}
+	return filterUndefined
+}
+
+// evaluateValues matches the values of the attribute with the matcher of the given kind of rule.
+// The result is true if any value matches, undefined if there is no usable matcher or a value
+// could not be compared, and false otherwise.
+func (ev *FilterEvaluator) evaluateValues(attribute string, entry *Entry, match func(m *Matcher, value string) (bool, error), kind int) filterResult {
+	m := ev.attributeMatcher(attribute, kind)
+	if m == nil {
+		return filterUndefined
+	}
+	switch kind {
+	case orderingRule:
+		if m.Compare == nil {
+			return filterUndefined
+		}
+	case substringsRule:
+		if m.Normalize == nil {
+			return filterUndefined
+		}
+	}
+
+	result := filterFalse
+	for _, value := range ev.attributeValues(attribute, entry) {
+		matched, err := match(m, value)
+		if err != nil {
+			result = filterUndefined
+		} else if matched {
+			return filterTrue
+		}
+	}
+	return result
+}
+
+func (ev *FilterEvaluator) evaluateExtensible(f *ExtensibleFilter, entry *Entry) filterResult {
+	var m *Matcher
+	if f.MatchingRule != "" {
+		if m = lookupMatcher(f.MatchingRule); m == nil {
+			return filterUndefined
+		}
+	} else if f.Attribute != "" {
+		m = ev.attributeMatcher(f.Attribute, equalityRule)
+	}
+
+	result := filterFalse
+	check := func(attribute, value string) {
+		matcher := m
+		if matcher == nil {
+			matcher = ev.attributeMatcher(attribute, equalityRule)
+		}
+		matched, err := matcherEqual(matcher, value, f.Value)
+		if err != nil {
+			if result == filterFalse {
+				result = filterUndefined
+			}
+		} else if matched {
+			result = filterTrue
+		}
+	}
+
+	if f.Attribute != "" {
+		for _, value := range ev.attributeValues(f.Attribute, entry) {
+			check(f.Attribute, value)
+		}
+	} else {
+		for _, attr := range entry.Attributes {
+			for _, value := range attr.Values {
+				check(attr.Name, value)
+			}
+		}
+	}
+	if f.DNAttributes {
+		if dn, err := ParseDN(entry.DN); err == nil {
+			for _, rdn := range dn.RDNs {
+				for _, attr := range rdn.Attributes {
+					if f.Attribute == "" || ev.attributeDescriptionMatches(f.Attribute, attr.Type) {
+						check(attr.Type, attr.Value)
+					}
+				}
+			}
+		}
+	}
+	return result
+}
+
+// attributeMatcher returns the matcher of the given kind of rule for the attribute, falling back to its
+// equality rule and the defaults of the package, or nil if the schema defines an unknown rule
+func (ev *FilterEvaluator) attributeMatcher(attribute string, kind int) *Matcher {
+	attrType := attributeDescriptionType(attribute)
+	if ev.Schema != nil {
+		var rule, equality, syntax string
+		for _, at := range ev.Schema.AttributeTypeChain(attrType) {
+			if rule == "" {
+				rule = [...]string{at.Equality, at.Ordering, at.Substring}[kind]
+			}
+			if equality == "" {
+				equality = at.Equality
+			}
+			if syntax == "" {
+				syntax = at.Syntax
+			}
+		}
+		if rule != "" {
+			return lookupMatcher(rule)
+		}
+		if equality != "" {
+			return lookupMatcher(equality)
+		}
+		if rule, ok := syntaxMatchingRules[syntax]; ok {
+			return lookupMatcher(rule)
+		}
+	}
+	if rule, ok := defaultAttributeMatchingRules[strings.ToLower(attrType)]; ok {
+		return lookupMatcher(rule)
+	}
+	return lookupMatcher("caseIgnoreMatch")
+}
+
+// attributeValues returns the values of all attributes of the entry matching the attribute description
+func (ev *FilterEvaluator) attributeValues(description string, entry *Entry) []string {
+	var values []string
+	for _, attr := range entry.Attributes {
+		if ev.attributeDescriptionMatches(description, attr.Name) {
+			values = append(values, attr.Values...)
+		}
+	}
+	return values
+}
+
+// attributeDescriptionMatches returns true if the attribute description of a filter matches the
+// description of an attribute of an entry, i.e. the attribute has the same type or one of its
+// subtypes and at least the options of the filter, see https://tools.ietf.org/html/rfc4512#section-2.5
+func (ev *FilterEvaluator) attributeDescriptionMatches(description, attribute string) bool {
+	filterType := attributeDescriptionType(description)
+	attrType := attributeDescriptionType(attribute)
+	if !strings.EqualFold(filterType, attrType) {
+		if ev.Schema == nil {
+			return false
+		}
+		key := ev.Schema.attributeKey(filterType)
+		found := false
+		for _, at := range ev.Schema.AttributeTypeChain(attrType) {
+			if at.OID == key {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	options := strings.Split(strings.ToLower(attribute[len(attrType):]), ";")
+	for _, option := range strings.Split(strings.ToLower(description[len(filterType):]), ";") {
+		if option != "" && !containsString(options, option) {
+			return false
+		}
+	}
+	return true
+}
+
+func matcherEqual(m *Matcher, value, assertion string) (bool, error) {
+	if m == nil {
+		return false, errors.New("ldap: no matching rule")
+	}
+	if m.Match != nil {
+		return m.Match(value, assertion)
+	}
+	if m.Normalize == nil {
+		return false, errors.New("ldap: matching rule without equality")
+	}
+	x, err := m.Normalize(value)
+	if err != nil {
+		return false, err
+	}
+	y, err := m.Normalize(assertion)
+	if err != nil {
+		return false, err
+	}
+	return x == y, nil
+}
+
+func matchSubstrings(m *Matcher, value string, f *SubstringsFilter) (bool, error) {
+	value, err := m.Normalize(value)
+	if err != nil {
+		return false, err
+	}
+	normalize := func(part string, leading, trailing bool) (string, error) {
+		if part == "" {
+			return "", nil
+		}
+		if m.normalizeSubstring != nil {
+			return m.normalizeSubstring(part, leading, trailing)
+		}
+		return m.Normalize(part)
+	}
+
+	initial, err := normalize(f.Initial, false, true)
+	if err != nil {
+		return false, err
+	}
+	if !strings.HasPrefix(value, initial) {
+		return false, nil
+	}
+	value = value[len(initial):]
+
+	final, err := normalize(f.Final, true, false)
+	if err != nil {
+		return false, err
+	}
+	if !strings.HasSuffix(value, final) {
+		return false, nil
+	}
+	value = value[:len(value)-len(final)]
+
+	for _, part := range f.Any {
+		part, err := normalize(part, true, true)
+		if err != nil {
+			return false, err
+		}
+		i := strings.Index(value, part)
+		if i < 0 {
+			return false, nil
+		}
+		value = value[i+len(part):]
+	}
+	return true, nil
+}
+
+// approxMatch compares the words of the value and the assertion by their soundex codes, or
+// case-insensitively for words without letters
+func approxMatch(value, assertion string) bool {
+	x, y := strings.Fields(value), strings.Fields(assertion)
+	if len(x) != len(y) {
+		return false
+	}
+	for i := range x {
+		a, b := soundex(x[i]), soundex(y[i])
+		if a == "" || b == "" {
+			if !strings.EqualFold(x[i], y[i]) {
+				return false
+			}
+		} else if a != b {
+			return false
+		}
+	}
+	return true
+}
+
+// soundex returns the American soundex code of the letters of the word, or "" if it has no letters
+func soundex(word string) string {
+	const codes = "01230120022455012623010202"
+
+	var code []byte
+	var last byte
+	for _, r := range word {
+		r = unicode.ToUpper(r)
+		if r < 'A' || r > 'Z' {
+			continue
+		}
+		c := codes[r-'A']
+		if len(code) == 0 {
+			code = append(code, byte(r))
+		} else if c != '0' && c != last {
+			code = append(code, c)
+			if len(code) == 4 {
+				break
+			}
+		}
+		// H and W do not separate letters with the same code
+		if r != 'H' && r != 'W' {
+			last = c
+		}
+	}
+	if len(code) == 0 {
+		return ""
+	}
+	for len(code) < 4 {
+		code = append(code, '0')
+	}
+	return string(code)
+}
+
+// Matches returns true if the entry matches the filter, evaluated without a schema
+func (f *AndFilter) Matches(entry *Entry) bool {
+	return defaultFilterEvaluator.Matches(f, entry)
+}
+
+// Matches returns true if the entry matches the filter, evaluated without a schema
+func (f *OrFilter) Matches(entry *Entry) bool {
+	return defaultFilterEvaluator.Matches(f, entry)
+}
+
+// Matches returns true if the entry matches the filter, evaluated without a schema
+func (f *NotFilter) Matches(entry *Entry) bool {
+	return defaultFilterEvaluator.Matches(f, entry)
+}
+
+// Matches returns true if the entry matches the filter, evaluated without a schema
+func (f *EqualityFilter) Matches(entry *Entry) bool {
+	return defaultFilterEvaluator.Matches(f, entry)
+}
+
+// Matches returns true if the entry matches the filter, evaluated without a schema
+func (f *SubstringsFilter) Matches(entry *Entry) bool {
+	return defaultFilterEvaluator.Matches(f, entry)
+}
+
+// Matches returns true if the entry matches the filter, evaluated without a schema
+func (f *GreaterOrEqualFilter) Matches(entry *Entry) bool {
+	return defaultFilterEvaluator.Matches(f, entry)
+}
+
+// Matches returns true if the entry matches the filter, evaluated without a schema
+func (f *LessOrEqualFilter) Matches(entry *Entry) bool {
+	return defaultFilterEvaluator.Matches(f, entry)
+}
+
+// Matches returns true if the entry matches the filter, evaluated without a schema
+func (f *PresentFilter) Matches(entry *Entry) bool {
+	return defaultFilterEvaluator.Matches(f, entry)
+}
+
+// Matches returns true if the entry matches the filter, evaluated without a schema
+func (f *ApproxFilter) Matches(entry *Entry) bool {
+	return defaultFilterEvaluator.Matches(f, entry)
+}
+
+// Matches returns true if the entry matches the filter, evaluated without a schema
+func (f *ExtensibleFilter) Matches(entry *Entry) bool {
+	return defaultFilterEvaluator.Matches(f, entry)
+}
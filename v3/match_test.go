@@ -0,0 +1,170 @@
+package ldap
+
+import (
+	"testing"
+)
+
+func TestMatchFilter(t *testing.T) {
+	entry := NewEntry("cn=John Smith,ou=People,dc=example,dc=org", map[string][]string{
+		"cn":                 {"John  Smith", "Johnny"},
+		"cn;lang-de":         {"Johann"},
+		"sn":                 {"Smith"},
+		"mail":               {"john@example.org"},
+		"uidNumber":          {"1000"},
+		"userAccountControl": {"66050"},
+		"modifyTimestamp":    {"20201231235959Z"},
+		"memberOf":           {"CN=Admins,OU=Groups,DC=example,DC=org"},
+		"telephoneNumber":    {"+1 555-0100"},
+		"description":        {"not a number"},
+	})
+
+	testcases := []struct {
+		filter string
+		want   bool
+	}{
+		{"(cn=john smith)", true},
+		{"(CN=JOHNNY)", true},
+		{"(cn=johann)", true},
+		{"(cn;lang-de=johann)", true},
+		{"(cn;lang-de=johnny)", false},
+		{"(cn=jane)", false},
+		{"(cn=jo*)", true},
+		{"(cn=*smi*)", true},
+		{"(cn=j*n*th)", true},
+		{"(cn=*n*n*n*)", false},
+		{"(cn=*n  S*)", true},
+		{"(cn=*ny *)", false},
+		{"(cn=* Smith)", true},
+		{"(mail=*@example.org)", true},
+		{"(sn=*x*)", false},
+		{"(uidNumber=01000)", true},
+		{"(uidNumber>=999)", true},
+		{"(uidNumber<=999)", false},
+		{"(uidNumber>=1000)", true},
+		{"(modifyTimestamp>=20201231225959-0100)", true},
+		{"(modifyTimestamp<=20201231235958Z)", false},
+		{"(modifyTimestamp=202101010059.99+0100)", false},
+		{"(modifyTimestamp=20210101005959+0100)", true},
+		{"(memberOf=CN=Admins,OU=Groups,DC=example,DC=org)", true},
+		{"(memberOf=cn=admins, ou=groups, dc=example, dc=org)", true},
+		{"(memberOf=*)", true},
+		{"(memberOf>=cn=a)", false},
+		{"(telephoneNumber=+15550100)", true},
+		{"(objectClass=*)", true},
+		{"(objectClass=person)", false},
+		{"(givenName=*)", false},
+		{"(sn~=Smyth)", true},
+		{"(cn~=Jon Smith)", true},
+		{"(sn~=Jones)", false},
+		{"(userAccountControl:1.2.840.113556.1.4.803:=2)", true},
+		{"(userAccountControl:1.2.840.113556.1.4.803:=18)", false},
+		{"(userAccountControl:1.2.840.113556.1.4.804:=18)", true},
+		{"(sn:caseExactMatch:=smith)", false},
+		{"(sn:caseExactMatch:=Smith)", true},
+		{"(:caseExactMatch:=Johann)", true},
+		{"(ou:dn:=people)", true},
+		{"(ou=people)", false},
+		{"(:dn:caseIgnoreMatch:=EXAMPLE)", true},
+		{"(&(sn=smith)(uidNumber=1000)(!(cn=jane)))", true},
+		{"(|(sn=jones)(uidNumber=1001))", false},
+		{"(|(sn=jones)(sn=smith))", true},
+		{"(description>=5)", true},
+		// undefined comparisons never match, even when negated
+		{"(uidNumber:1.2.3.4:=1)", false},
+		{"(!(uidNumber:1.2.3.4:=1))", false},
+		{"(!(description:1.2.840.113556.1.4.803:=1))", false},
+		{"(|(description:1.2.840.113556.1.4.803:=1)(sn=smith))", true},
+		{"(&(memberOf>=x)(sn=jones))", false},
+		{"(!(&(memberOf>=x)(sn=jones)))", true},
+	}
+	for _, tc := range testcases {
+		matched, err := MatchFilter(tc.filter, entry)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", tc.filter, err)
+		} else if matched != tc.want {
+			t.Errorf("%q: got %t, want %t", tc.filter, matched, tc.want)
+		}
+	}
+
+	if _, err := MatchFilter("(cn=foo", entry); err == nil {
+		t.Error("expected an error for an invalid filter")
+	}
+}
+
+func TestMatchFilterNormalization(t *testing.T) {
+	entry := NewEntry("cn=Johnny,dc=example,dc=org", map[string][]string{
+		"cn":              {"Johnny"},
+		"modifyTimestamp": {"20201231120000+0200"},
+	})
+
+	testcases := []struct {
+		filter string
+		want   bool
+	}{
+		// the spaces at the edges of substrings components are significant
+		{"(cn=John *)", false},
+		{"(cn=John*)", true},
+		{"(cn=* ohnny)", false},
+		{"(cn=*o hn*)", false},
+		// times are compared in UTC
+		{"(modifyTimestamp=20201231100000Z)", true},
+		{"(modifyTimestamp=20201231120000Z)", false},
+	}
+	for _, tc := range testcases {
+		matched, err := MatchFilter(tc.filter, entry)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", tc.filter, err)
+		} else if matched != tc.want {
+			t.Errorf("%q: got %t, want %t", tc.filter, matched, tc.want)
+		}
+	}
+}
+
+func TestFilterEvaluatorSchema(t *testing.T) {
+	ev := &FilterEvaluator{Schema: newTestSchema(t)}
+	entry := NewEntry("cn=a,dc=example,dc=org", map[string][]string{
+		"commonName":     {"Alice"},
+		"sn":             {"Smith"},
+		"employeeNumber": {"0042"},
+		"expires":        {"20201231235959Z"},
+		"seeAlso":        {"cn=b,dc=example,dc=org"},
+	})
+
+	testcases := []struct {
+		filter Filter
+		want   bool
+	}{
+		{Eq("cn", "alice"), true},
+		{Eq("name", "smith"), true},
+		{Substr("name", "al", nil, ""), true},
+		{Eq("employeeNumber", "42"), false},
+		{Eq("employeeNumber", "0042"), true},
+		{GE("expires", "20210101000000Z"), false},
+		{LE("expires", "20210101000000Z"), true},
+		{Eq("seeAlso", "CN=b, DC=example, DC=org"), true},
+		{Substr("seeAlso", "cn=b", nil, ""), false},
+		{Not(Substr("seeAlso", "cn=b", nil, "")), false},
+	}
+	for _, tc := range testcases {
+		if matched := ev.Matches(tc.filter, entry); matched != tc.want {
+			t.Errorf("%s: got %t, want %t", tc.filter, matched, tc.want)
+		}
+	}
+}
+
+func TestSoundex(t *testing.T) {
+	testcases := map[string]string{
+		"Robert":   "R163",
+		"Rupert":   "R163",
+		"Ashcraft": "A261",
+		"Tymczak":  "T522",
+		"Pfister":  "P236",
+		"Lee":      "L000",
+		"42":       "",
+	}
+	for word, want := range testcases {
+		if got := soundex(word); got != want {
+			t.Errorf("%q: got %q, want %q", word, got, want)
+		}
+	}
+}
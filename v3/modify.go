@@ -1,8 +1,6 @@
 package ldap
 
 import (
-	"log"
-
 	ber "github.com/go-asn1-ber/asn1-ber"
 )
 
@@ -78,6 +76,27 @@ func (req *ModifyRequest) Increment(attrType string, attrVal string) {
 	req.appendChange(IncrementAttribute, attrType, []string{attrVal})
 }
 
+// AddBytes appends the given attribute with binary values to the list of changes to be made
+func (req *ModifyRequest) AddBytes(attrType string, attrVals [][]byte) {
+	req.Add(attrType, bytesToStrings(attrVals))
+}
+
+// DeleteBytes appends the given attribute with binary values to the list of changes to be made
+func (req *ModifyRequest) DeleteBytes(attrType string, attrVals [][]byte) {
+	req.Delete(attrType, bytesToStrings(attrVals))
+}
+
+// ReplaceBytes appends the given attribute with binary values to the list of changes to be made
+func (req *ModifyRequest) ReplaceBytes(attrType string, attrVals [][]byte) {
+	req.Replace(attrType, bytesToStrings(attrVals))
+}
+
+// ReplaceUnicodePwd appends the change setting the password of an Active Directory account to the list
+// of changes to be made. Active Directory only accepts it over an encrypted connection, e.g. LDAPS.
+func (req *ModifyRequest) ReplaceUnicodePwd(password string) {
+	req.ReplaceBytes("unicodePwd", [][]byte{EncodeUnicodePwd(password)})
+}
+
 func (req *ModifyRequest) appendChange(operation uint, attrType string, attrVals []string) {
 	req.Changes = append(req.Changes, Change{operation, PartialAttribute{Type: attrType, Vals: attrVals}})
 }
@@ -107,26 +126,71 @@ func NewModifyRequest(dn string, controls []Control) *ModifyRequest {
 	}
 }
 
+// ModifyResult holds the server's response to a modify request
+type ModifyResult struct {
+	// MatchedDN is the matchedDN returned by the server, if any
+	MatchedDN string
+	// DiagnosticMessage is the diagnostic message returned by the server, if any
+	DiagnosticMessage string
+	// Referrals are the referral URIs returned with LDAPResultReferral, if any
+	Referrals []string
+	// Controls are the returned controls
+	Controls []Control
+}
+
 // Modify performs the ModifyRequest
 func (l *Conn) Modify(modifyRequest *ModifyRequest) error {
+	_, err := l.ModifyWithResult(modifyRequest)
+	return err
+}
+
+// ModifyWithResult performs the ModifyRequest and returns the result
+func (l *Conn) ModifyWithResult(modifyRequest *ModifyRequest) (*ModifyResult, error) {
+	result, err := l.modify(modifyRequest)
+	if l.chasesReferral(err) {
+		err = l.chaseReferrals(err, 0, map[string]bool{}, func(conn *Conn, u *LDAPURL) error {
+			referred := *modifyRequest
+			referred.DN = referredDN(modifyRequest.DN, u)
+			result, err = conn.ModifyWithResult(&referred)
+			return err
+		})
+	}
+	return result, err
+}
+
+func (l *Conn) modify(modifyRequest *ModifyRequest) (*ModifyResult, error) {
 	msgCtx, err := l.doRequest(modifyRequest)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer l.finishMessage(msgCtx)
 
 	packet, err := l.readPacket(msgCtx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	result := &ModifyResult{}
 	if packet.Children[1].Tag == ApplicationModifyResponse {
-		err := GetLDAPError(packet)
+		result.MatchedDN, result.DiagnosticMessage, result.Referrals = getLDAPResult(packet)
+		result.Controls, err = decodeResultControls(packet)
 		if err != nil {
-			return err
+			return result, err
 		}
 	} else {
-		log.Printf("Unexpected Response: %d", packet.Children[1].Tag)
+		l.log(LevelWarn, "unexpected response", LogKeyMessageID, msgCtx.id, LogKeyOperation, operationName(packet))
 	}
-	return nil
+	return result, nil
+}
+
+// bytesToStrings converts binary values to strings, which hold any bytes, for encoding
+func bytesToStrings(values [][]byte) []string {
+	if values == nil {
+		return nil
+	}
+	strs := make([]string, len(values))
+	for i, value := range values {
+		strs[i] = string(value)
+	}
+	return strs
 }
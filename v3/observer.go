@@ -0,0 +1,201 @@
+package ldap
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+)
+
+// RequestInfo describes a request sent by a Conn to its Observer
+type RequestInfo struct {
+	// MessageID is the message ID of the request
+	MessageID int64
+	// Operation is the name of the protocol op of the request, e.g. "Search Request"
+	Operation string
+	// Sent is the time the request was sent
+	Sent time.Time
+}
+
+// Observer is notified of the requests of a Conn and their responses, e.g. to collect metrics such as
+// latencies, result codes and traffic. Its methods are called synchronously by the goroutines of the
+// connection, so they must be safe for concurrent use and return quickly.
+type Observer interface {
+	// RequestSent is called once the request of the given size in bytes was written to the connection
+	RequestSent(req *RequestInfo, size int)
+	// ResponseReceived is called for each response to the request, e.g. for each entry of a search,
+	// with the name of its protocol op, e.g. "Search Result Entry", and its size in bytes
+	ResponseReceived(req *RequestInfo, operation string, size int)
+	// RequestFinished is called when the request is complete, with the result code of its last
+	// response, LDAPResultTimeout if it timed out or ErrorNetwork if no response was received, and
+	// the time elapsed since it was sent
+	RequestFinished(req *RequestInfo, resultCode uint16, duration time.Duration)
+	// OutstandingRequests is called with the number of outstanding requests whenever it changes
+	OutstandingRequests(count int)
+}
+
+// NopObserver is an Observer doing nothing, to be embedded by observers only implementing some of
+// the methods
+type NopObserver struct{}
+
+// RequestSent does nothing
+func (NopObserver) RequestSent(*RequestInfo, int) {}
+
+// ResponseReceived does nothing
+func (NopObserver) ResponseReceived(*RequestInfo, string, int) {}
+
+// RequestFinished does nothing
+func (NopObserver) RequestFinished(*RequestInfo, uint16, time.Duration) {}
+
+// OutstandingRequests does nothing
+func (NopObserver) OutstandingRequests(int) {}
+
+// multiObserver notifies several observers in order
+type multiObserver []Observer
+
+// MultiObserver returns an Observer notifying all the given observers in order, e.g. a metrics
+// collector and a tracer
+func MultiObserver(observers ...Observer) Observer {
+	return multiObserver(observers)
+}
+
+func (m multiObserver) RequestSent(req *RequestInfo, size int) {
+	for _, o := range m {
+		o.RequestSent(req, size)
+	}
+}
+
+func (m multiObserver) ResponseReceived(req *RequestInfo, operation string, size int) {
+	for _, o := range m {
+		o.ResponseReceived(req, operation, size)
+	}
+}
+
+func (m multiObserver) RequestFinished(req *RequestInfo, resultCode uint16, duration time.Duration) {
+	for _, o := range m {
+		o.RequestFinished(req, resultCode, duration)
+	}
+}
+
+func (m multiObserver) OutstandingRequests(count int) {
+	for _, o := range m {
+		o.OutstandingRequests(count)
+	}
+}
+
+// Tracer starts a span for each request, e.g. by adapting an OpenTelemetry trace.Tracer, see
+// NewTracingObserver
+type Tracer interface {
+	// StartSpan starts the span of the given request, when it is sent
+	StartSpan(req *RequestInfo) Span
+}
+
+// Span is the span of a request started by a Tracer
+type Span interface {
+	// AddResponse records a response to the request, with the name of its protocol op
+	AddResponse(operation string, size int)
+	// End ends the span when the request is complete, with the result code as for
+	// Observer.RequestFinished
+	End(resultCode uint16, duration time.Duration)
+}
+
+// tracingObserver starts a span per request with a Tracer
+type tracingObserver struct {
+	NopObserver
+	tracer Tracer
+
+	mutex sync.Mutex
+	spans map[*RequestInfo]Span
+}
+
+// NewTracingObserver returns an Observer starting a span with the given tracer for each request when
+// it is sent and ending it when the request is complete
+func NewTracingObserver(tracer Tracer) Observer {
+	return &tracingObserver{tracer: tracer, spans: make(map[*RequestInfo]Span)}
+}
+
+func (o *tracingObserver) RequestSent(req *RequestInfo, size int) {
+	span := o.tracer.StartSpan(req)
+	o.mutex.Lock()
+	o.spans[req] = span
+	o.mutex.Unlock()
+}
+
+func (o *tracingObserver) ResponseReceived(req *RequestInfo, operation string, size int) {
+	o.mutex.Lock()
+	span := o.spans[req]
+	o.mutex.Unlock()
+	if span != nil {
+		span.AddResponse(operation, size)
+	}
+}
+
+func (o *tracingObserver) RequestFinished(req *RequestInfo, resultCode uint16, duration time.Duration) {
+	o.mutex.Lock()
+	span := o.spans[req]
+	delete(o.spans, req)
+	o.mutex.Unlock()
+	if span != nil {
+		span.End(resultCode, duration)
+	}
+}
+
+// observerHolder holds the Observer of a Conn in an atomic.Value, which requires values of the same type
+type observerHolder struct {
+	observer Observer
+}
+
+// SetObserver sets the Observer notified of the requests of the connection and their responses, or
+// removes it if nil
+func (l *Conn) SetObserver(observer Observer) {
+	l.observer.Store(observerHolder{observer})
+}
+
+func (l *Conn) getObserver() Observer {
+	holder, _ := l.observer.Load().(observerHolder)
+	return holder.observer
+}
+
+// noResultCode is the result code of a message context until a response with a result code is received
+const noResultCode = -1
+
+// setResultCode records the result code of the last response to the request
+func (msgCtx *messageContext) setResultCode(resultCode uint16) {
+	atomic.StoreInt32(&msgCtx.resultCode, int32(resultCode))
+}
+
+// getResultCode returns the result code of the last response to the request, or ErrorNetwork if none
+func (msgCtx *messageContext) getResultCode() uint16 {
+	resultCode := atomic.LoadInt32(&msgCtx.resultCode)
+	if resultCode == noResultCode {
+		return ErrorNetwork
+	}
+	return uint16(resultCode)
+}
+
+// responseResultCode returns the result code of the given response, if its protocol op has one
+func responseResultCode(packet *ber.Packet) (uint16, bool) {
+	if len(packet.Children) < 2 || packet.Children[1].ClassType != ber.ClassApplication {
+		return 0, false
+	}
+	op := packet.Children[1]
+	if len(op.Children) == 0 || op.Children[0].ClassType != ber.ClassUniversal || op.Children[0].Tag != ber.TagEnumerated {
+		return 0, false
+	}
+	resultCode, ok := op.Children[0].Value.(int64)
+	return uint16(resultCode), ok
+}
+
+// countingReader counts the bytes read from its reader
+type countingReader struct {
+	io.Reader
+	count int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.count += n
+	return n, err
+}
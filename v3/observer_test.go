@@ -0,0 +1,136 @@
+package ldap
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+)
+
+// testObserver records the notifications it receives
+type testObserver struct {
+	mutex  sync.Mutex
+	events []string
+}
+
+func (o *testObserver) record(format string, args ...interface{}) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	o.events = append(o.events, fmt.Sprintf(format, args...))
+}
+
+func (o *testObserver) RequestSent(req *RequestInfo, size int) {
+	o.record("sent %d %s %t", req.MessageID, req.Operation, size > 0)
+}
+
+func (o *testObserver) ResponseReceived(req *RequestInfo, operation string, size int) {
+	o.record("received %d %s %t", req.MessageID, operation, size > 0)
+}
+
+func (o *testObserver) RequestFinished(req *RequestInfo, resultCode uint16, duration time.Duration) {
+	o.record("finished %d %s %d %t", req.MessageID, req.Operation, resultCode, duration > 0)
+}
+
+func (o *testObserver) OutstandingRequests(count int) {
+	o.record("outstanding %d", count)
+}
+
+// testTracer records the spans it starts
+type testTracer struct {
+	testObserver
+}
+
+type testSpan struct {
+	tracer *testTracer
+	req    *RequestInfo
+}
+
+func (t *testTracer) StartSpan(req *RequestInfo) Span {
+	t.record("start %d", req.MessageID)
+	return &testSpan{t, req}
+}
+
+func (s *testSpan) AddResponse(operation string, size int) {
+	s.tracer.record("response %d %s", s.req.MessageID, operation)
+}
+
+func (s *testSpan) End(resultCode uint16, duration time.Duration) {
+	s.tracer.record("end %d %d", s.req.MessageID, resultCode)
+}
+
+func TestObserver(t *testing.T) {
+	conn := newTestServerConn(t, func(request *ber.Packet) []*ber.Packet {
+		switch request.Children[1].Tag {
+		case ApplicationSearchRequest:
+			return []*ber.Packet{
+				newTestSearchResultEntry("cn=a,dc=example,dc=org"),
+				newTestSearchResultEntry("cn=b,dc=example,dc=org"),
+				newTestLDAPResult(ApplicationSearchResultDone, LDAPResultSuccess),
+			}
+		default:
+			return []*ber.Packet{newTestLDAPResult(ApplicationDelResponse, LDAPResultNoSuchObject)}
+		}
+	})
+	defer conn.Close()
+	observer := &testObserver{}
+	tracer := &testTracer{}
+	conn.SetObserver(MultiObserver(observer, NewTracingObserver(tracer)))
+
+	if _, err := conn.Search(NewSearchRequest("dc=example,dc=org", ScopeWholeSubtree, NeverDerefAliases, 0, 0, false, "(cn=*)", nil, nil)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := conn.Del(NewDelRequest("cn=c,dc=example,dc=org", nil)); !IsErrorWithCode(err, LDAPResultNoSuchObject) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{
+		"outstanding 1",
+		"sent 1 Search Request true",
+		"received 1 Search Result Entry true",
+		"received 1 Search Result Entry true",
+		"received 1 Search Result Done true",
+		"finished 1 Search Request 0 true",
+		"outstanding 0",
+		"outstanding 1",
+		"sent 2 Del Request true",
+		"received 2 Del Response true",
+		"finished 2 Del Request 32 true",
+		"outstanding 0",
+	}
+	if !reflect.DeepEqual(observer.events, want) {
+		t.Errorf("got events %q, want %q", observer.events, want)
+	}
+	want = []string{
+		"start 1",
+		"response 1 Search Result Entry",
+		"response 1 Search Result Entry",
+		"response 1 Search Result Done",
+		"end 1 0",
+		"start 2",
+		"response 2 Del Response",
+		"end 2 32",
+	}
+	if !reflect.DeepEqual(tracer.events, want) {
+		t.Errorf("got spans %q, want %q", tracer.events, want)
+	}
+}
+
+func TestObserverNoResponse(t *testing.T) {
+	conn := newTestServerConn(t, func(request *ber.Packet) []*ber.Packet { return nil })
+	defer conn.Close()
+	observer := &testObserver{}
+	conn.SetObserver(observer)
+	conn.SetTimeout(10 * time.Millisecond)
+
+	if _, err := conn.Compare("cn=a,dc=example,dc=org", "sn", "Smith"); err == nil {
+		t.Fatal("expected a timeout")
+	}
+	observer.mutex.Lock()
+	defer observer.mutex.Unlock()
+	if len(observer.events) != 4 || observer.events[2] != fmt.Sprintf("finished 1 Compare Request %d true", LDAPResultTimeout) {
+		t.Errorf("unexpected events %q", observer.events)
+	}
+}
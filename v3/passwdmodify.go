@@ -28,6 +28,8 @@ type PasswordModifyResult struct {
 	GeneratedPassword string
 	// Referral are the returned referral
 	Referral string
+	// Controls are the returned controls
+	Controls []Control
 }
 
 func (req *PasswordModifyRequest) appendTo(envelope *ber.Packet) error {
@@ -95,7 +97,7 @@ func (l *Conn) PasswordModify(passwordModifyRequest *PasswordModifyRequest) (*Pa
 	result := &PasswordModifyResult{}
 
 	if packet.Children[1].Tag == ApplicationExtendedResponse {
-		err := GetLDAPError(packet)
+		result.Controls, err = decodeResultControls(packet)
 		if err != nil {
 			if IsErrorWithCode(err, LDAPResultReferral) {
 				for _, child := range packet.Children[1].Children {
@@ -0,0 +1,119 @@
+package ldap
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+)
+
+// newTestMessage returns the decoded LDAP message of the given request or protocol op, as received
+func newTestMessage(t *testing.T, req request) *ber.Packet {
+	envelope := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Request")
+	envelope.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, int64(1), "MessageID"))
+	if err := req.appendTo(envelope); err != nil {
+		t.Fatal(err)
+	}
+	packet, err := ber.DecodePacketErr(envelope.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := addLDAPDescriptions(packet); err != nil {
+		t.Fatal(err)
+	}
+	return packet
+}
+
+// protocolOp returns a request appending the given protocol op
+func protocolOp(op *ber.Packet) request {
+	return requestFunc(func(envelope *ber.Packet) error {
+		envelope.AppendChild(op)
+		return nil
+	})
+}
+
+func TestRedactor(t *testing.T) {
+	saslBind := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ApplicationBindRequest, nil, "Bind Request")
+	saslBind.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, 3, "Version"))
+	saslBind.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "User Name"))
+	auth := ber.Encode(ber.ClassContext, ber.TypeConstructed, 3, "", "authentication")
+	auth.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "DIGEST-MD5", "SASL Mech"))
+	auth.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "response=s3cr3t", "Credentials"))
+	saslBind.AppendChild(auth)
+
+	passwordModifyResponse := newTestLDAPResult(ApplicationExtendedResponse, LDAPResultSuccess)
+	genPasswd := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "PasswdModifyResponseValue")
+	genPasswd.AppendChild(ber.NewString(ber.ClassContext, ber.TypePrimitive, 0, "s3cr3t", "genPasswd"))
+	passwordModifyResponse.AppendChild(ber.NewString(ber.ClassContext, ber.TypePrimitive, 11, string(genPasswd.Bytes()), "Response Value"))
+
+	whoAmIResponse := newTestLDAPResult(ApplicationExtendedResponse, LDAPResultSuccess)
+	whoAmIResponse.AppendChild(ber.NewString(ber.ClassContext, ber.TypePrimitive, 11, "dn:cn=kept", "Response Value"))
+
+	compare := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ApplicationCompareRequest, nil, "Compare Request")
+	compare.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "cn=kept", "DN"))
+	ava := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "AttributeValueAssertion")
+	ava.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "userPassword", "AttributeDesc"))
+	ava.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "s3cr3t", "AssertionValue"))
+	compare.AppendChild(ava)
+
+	modify := NewModifyRequest("cn=kept", nil)
+	modify.Replace("description", []string{"kept"})
+	modify.Replace("userPassword;binary", []string{"s3cr3t"})
+
+	testcases := []struct {
+		name     string
+		request  request
+		redactor *Redactor
+		redacted bool
+		keep     string
+	}{
+		{"simple bind", NewSimpleBindRequest("cn=kept", "s3cr3t", nil), nil, true, "cn=kept"},
+		{"unauthenticated bind", NewSimpleBindRequest("cn=kept", "", nil), nil, false, ""},
+		{"SASL bind", protocolOp(saslBind), nil, true, "DIGEST-MD5"},
+		{"password modify", NewPasswordModifyRequest("cn=kept", "s3cr3t", "s3cr3t"), nil, true, ""},
+		{"password modify response", protocolOp(passwordModifyResponse), nil, true, ""},
+		{"who am I response", protocolOp(whoAmIResponse), nil, false, ""},
+		{"add", &AddRequest{DN: "cn=kept", Attributes: []Attribute{{"cn", []string{"kept"}}, {"unicodePwd", []string{"s3cr3t"}}}}, nil, true, "\"kept\""},
+		{"modify", modify, nil, true, "\"kept\""},
+		{"compare", protocolOp(compare), nil, true, "cn=kept"},
+		{"search filter", NewSearchRequest("cn=kept", ScopeBaseObject, NeverDerefAliases, 0, 0, false,
+			"(&(cn=kept)(|(userPassword=s3cr3t)(userPassword=*s3cr3t*)(userPassword:caseExactMatch:=s3cr3t)))", nil, nil), nil, true, "\"kept\""},
+		{"search result entry", protocolOp(newTestSearchResultEntryWithAttributes("cn=kept", map[string][]string{
+			"cn": {"kept"}, "userPassword": {"s3cr3t"}})), nil, true, "\"kept\""},
+		{"search result entry without credentials", protocolOp(newTestSearchResultEntryWithAttributes("cn=kept", map[string][]string{
+			"cn": {"kept"}})), nil, false, ""},
+		{"configured attribute", &AddRequest{DN: "cn=kept", Attributes: []Attribute{{"secretAnswer", []string{"s3cr3t"}}}},
+			&Redactor{SensitiveAttributes: []string{"secretanswer"}}, true, "cn=kept"},
+		{"unconfigured attribute", &AddRequest{DN: "cn=kept", Attributes: []Attribute{{"unicodePwd", []string{"s3cr3t"}}}},
+			&Redactor{SensitiveAttributes: []string{}}, false, ""},
+		{"disabled", NewSimpleBindRequest("cn=kept", "s3cr3t", nil), &Redactor{Disabled: true}, false, ""},
+	}
+	for _, tc := range testcases {
+		packet := newTestMessage(t, tc.request)
+		original := packet.Bytes()
+
+		redacted := tc.redactor.Redact(packet)
+		if !bytes.Equal(packet.Bytes(), original) {
+			t.Errorf("%s: the original packet was modified", tc.name)
+		}
+		if !tc.redacted {
+			if redacted != packet {
+				t.Errorf("%s: expected the packet not to be redacted", tc.name)
+			}
+			continue
+		}
+
+		var dump bytes.Buffer
+		ber.WritePacket(&dump, redacted)
+		if strings.Contains(dump.String(), "s3cr3t") || bytes.Contains(redacted.Bytes(), []byte("s3cr3t")) {
+			t.Errorf("%s: the credentials were not redacted:\n%s", tc.name, dump.String())
+		}
+		if !strings.Contains(dump.String(), redactedValue) || !strings.Contains(dump.String(), tc.keep) {
+			t.Errorf("%s: expected a redacted value and %s:\n%s", tc.name, tc.keep, dump.String())
+		}
+		if _, err := ber.DecodePacketErr(redacted.Bytes()); err != nil {
+			t.Errorf("%s: the redacted packet cannot be decoded: %s", tc.name, err)
+		}
+	}
+}
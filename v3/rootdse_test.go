@@ -0,0 +1,60 @@
+package ldap
+
+import (
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+)
+
+// newTestSearchResultEntryWithAttributes returns a SearchResultEntry protocol op with the given attributes
+func newTestSearchResultEntryWithAttributes(dn string, attributes map[string][]string) *ber.Packet {
+	entry := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ApplicationSearchResultEntry, nil, "Search Result Entry")
+	entry.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, dn, "Object Name"))
+	attrs := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attributes")
+	for name, values := range attributes {
+		attr := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attribute")
+		attr.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, "Type"))
+		set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "AttributeValue")
+		for _, value := range values {
+			set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, "Vals"))
+		}
+		attr.AppendChild(set)
+		attrs.AppendChild(attr)
+	}
+	entry.AppendChild(attrs)
+	return entry
+}
+
+func TestRootDSE(t *testing.T) {
+	conn := newTestServerConn(t, func(request *ber.Packet) []*ber.Packet {
+		return []*ber.Packet{
+			newTestSearchResultEntryWithAttributes("", map[string][]string{
+				"namingContexts":          {"dc=example,dc=org"},
+				"supportedControl":        {ControlTypeManageDsaIT},
+				"supportedExtension":      {"1.3.6.1.4.1.4203.1.11.1"},
+				"supportedLDAPVersion":    {"3"},
+				"supportedSASLMechanisms": {"DIGEST-MD5", "EXTERNAL"},
+				"vendorName":              {"Example Inc."},
+			}),
+			newTestLDAPResult(ApplicationSearchResultDone, LDAPResultSuccess),
+		}
+	})
+	defer conn.Close()
+
+	rootDSE, err := conn.RootDSE()
+	if err != nil {
+		t.Fatalf("could not read root DSE: %s", err)
+	}
+	if len(rootDSE.NamingContexts) != 1 || rootDSE.NamingContexts[0] != "dc=example,dc=org" || rootDSE.VendorName != "Example Inc." {
+		t.Errorf("unexpected root DSE: %#v", rootDSE)
+	}
+	if !rootDSE.SupportsControl(ControlTypeManageDsaIT) || rootDSE.SupportsControl(ControlTypePaging) {
+		t.Errorf("unexpected supported controls: %v", rootDSE.SupportedControls)
+	}
+	if !rootDSE.SupportsExtension("1.3.6.1.4.1.4203.1.11.1") {
+		t.Errorf("unexpected supported extensions: %v", rootDSE.SupportedExtensions)
+	}
+	if mech := rootDSE.PreferredSASLMechanism("GSSAPI", "external", "DIGEST-MD5"); mech != "external" {
+		t.Errorf("unexpected preferred SASL mechanism %q", mech)
+	}
+}
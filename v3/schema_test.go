@@ -0,0 +1,166 @@
+package ldap
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseAttributeType(t *testing.T) {
+	testcases := map[string]*AttributeType{
+		"( 2.5.4.41 NAME 'name' EQUALITY caseIgnoreMatch SUBSTR caseIgnoreSubstringsMatch SYNTAX 1.3.6.1.4.1.1466.115.121.1.15{32768} )": {
+			OID: "2.5.4.41", Names: []string{"name"}, Equality: "caseIgnoreMatch", Substring: "caseIgnoreSubstringsMatch",
+			Syntax: "1.3.6.1.4.1.1466.115.121.1.15", SyntaxLength: 32768, Usage: AttributeUsageUserApplications, Extensions: map[string][]string{}},
+		"( 2.5.4.3 NAME ( 'cn' 'commonName' ) DESC 'RFC4519: common name(s) for which the entity is known by' SUP name )": {
+			OID: "2.5.4.3", Names: []string{"cn", "commonName"}, Description: "RFC4519: common name(s) for which the entity is known by",
+			Superior: "name", Usage: AttributeUsageUserApplications, Extensions: map[string][]string{}},
+		"( 2.5.18.1 NAME 'createTimestamp' EQUALITY generalizedTimeMatch ORDERING generalizedTimeOrderingMatch SYNTAX 1.3.6.1.4.1.1466.115.121.1.24 SINGLE-VALUE NO-USER-MODIFICATION USAGE directoryOperation )": {
+			OID: "2.5.18.1", Names: []string{"createTimestamp"}, Equality: "generalizedTimeMatch", Ordering: "generalizedTimeOrderingMatch",
+			Syntax: "1.3.6.1.4.1.1466.115.121.1.24", SingleValue: true, NoUserModification: true, Usage: AttributeUsageDirectoryOperation,
+			Extensions: map[string][]string{}},
+		"( 1.2.3.4 NAME 'x' DESC 'it\\27s a \\5C' SYNTAX '1.3.6.1.4.1.1466.115.121.1.15' X-ORIGIN ( 'RFC 1' 'RFC 2' ) X-ORDERED 'VALUES' X-FLAG )": {
+			OID: "1.2.3.4", Names: []string{"x"}, Description: `it's a \`, Syntax: "1.3.6.1.4.1.1466.115.121.1.15", Usage: AttributeUsageUserApplications,
+			Extensions: map[string][]string{"X-ORIGIN": {"RFC 1", "RFC 2"}, "X-ORDERED": {"VALUES"}, "X-FLAG": nil}},
+	}
+	for str, want := range testcases {
+		got, err := ParseAttributeType(str)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", str, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: got %#v, want %#v", str, got, want)
+		}
+	}
+
+	for _, str := range []string{
+		"2.5.4.41 NAME 'name' SYNTAX 1.2",
+		"( 2.5.4.41 NAME 'name' )",
+		"( 2.5.4.41 NAME 'name SYNTAX 1.2 )",
+		"( 2.5.4.41 NAME ( 'a' 'b' SYNTAX 1.2 )",
+		"( 2.5.4.41 SYNTAX 1.2{x} )",
+		"( 2.5.4.41 SYNTAX 1.2 USAGE other )",
+		"( 2.5.4.41 SYNTAX 1.2 SYNTAX 1.3 )",
+		"( 2.5.4.41 DESC '\\41' SYNTAX 1.2 )",
+		"( 'x' SYNTAX 1.2 )",
+	} {
+		if _, err := ParseAttributeType(str); err == nil {
+			t.Errorf("%q: expected an error", str)
+		}
+	}
+}
+
+func TestParseSchemaDescriptions(t *testing.T) {
+	oc, err := ParseObjectClass("( 2.5.6.6 NAME 'person' DESC 'RFC2256: a person' SUP top STRUCTURAL MUST ( sn $ cn ) MAY ( userPassword $ telephoneNumber $ seeAlso $ description ) )")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if oc.OID != "2.5.6.6" || !reflect.DeepEqual(oc.Superiors, []string{"top"}) || oc.Kind != ObjectClassStructural ||
+		!reflect.DeepEqual(oc.Must, []string{"sn", "cn"}) || len(oc.May) != 4 {
+		t.Errorf("unexpected object class: %#v", oc)
+	}
+	oc, err = ParseObjectClass("( 2.5.6.0 NAME 'top' ABSTRACT MUST objectClass )")
+	if err != nil || oc.Kind != ObjectClassAbstract || !reflect.DeepEqual(oc.Must, []string{"objectClass"}) {
+		t.Errorf("unexpected object class: %#v (%v)", oc, err)
+	}
+	if _, err := ParseObjectClass("( 2.5.6.0 NAME 'top' ABSTRACT AUXILIARY )"); err == nil {
+		t.Error("expected an error for an object class with two kinds")
+	}
+
+	mr, err := ParseMatchingRule("( 2.5.13.2 NAME 'caseIgnoreMatch' SYNTAX 1.3.6.1.4.1.1466.115.121.1.15 )")
+	if err != nil || mr.Names[0] != "caseIgnoreMatch" || mr.Syntax != "1.3.6.1.4.1.1466.115.121.1.15" {
+		t.Errorf("unexpected matching rule: %#v (%v)", mr, err)
+	}
+
+	syntax, err := ParseLDAPSyntax("( 1.3.6.1.4.1.1466.115.121.1.5 DESC 'Binary' X-NOT-HUMAN-READABLE 'TRUE' )")
+	if err != nil || syntax.Description != "Binary" || !reflect.DeepEqual(syntax.Extensions["X-NOT-HUMAN-READABLE"], []string{"TRUE"}) {
+		t.Errorf("unexpected syntax: %#v (%v)", syntax, err)
+	}
+
+	rule, err := ParseDITContentRule("( 2.5.6.6 NAME 'personRule' AUX ( posixAccount $ shadowAccount ) MAY mail NOT telephoneNumber )")
+	if err != nil || !reflect.DeepEqual(rule.Aux, []string{"posixAccount", "shadowAccount"}) || !reflect.DeepEqual(rule.Not, []string{"telephoneNumber"}) {
+		t.Errorf("unexpected DIT content rule: %#v (%v)", rule, err)
+	}
+
+	nf, err := ParseNameForm("( 1.2.3.4 NAME 'personNameForm' OC person MUST cn )")
+	if err != nil || nf.ObjectClass != "person" || !reflect.DeepEqual(nf.Must, []string{"cn"}) {
+		t.Errorf("unexpected name form: %#v (%v)", nf, err)
+	}
+	if _, err := ParseNameForm("( 1.2.3.4 NAME 'personNameForm' MUST cn )"); err == nil {
+		t.Error("expected an error for a name form without object class")
+	}
+}
+
+func TestSchema(t *testing.T) {
+	schema := NewSchema(NewEntry("cn=Subschema", map[string][]string{
+		"attributeTypes": {
+			"( 2.5.4.0 NAME 'objectClass' EQUALITY objectIdentifierMatch SYNTAX 1.3.6.1.4.1.1466.115.121.1.38 )",
+			"( 2.5.4.41 NAME 'name' EQUALITY caseIgnoreMatch SYNTAX 1.3.6.1.4.1.1466.115.121.1.15 )",
+			"( 2.5.4.3 NAME ( 'cn' 'commonName' ) SUP name )",
+			"( 2.5.4.4 NAME ( 'sn' 'surname' ) SUP name )",
+			"( 2.5.4.13 NAME 'description' SUP name )",
+			"( 0.9.2342.19200300.100.1.3 NAME ( 'mail' 'rfc822Mailbox' ) EQUALITY caseIgnoreIA5Match SYNTAX 1.3.6.1.4.1.1466.115.121.1.26 )",
+		},
+		"objectClasses": {
+			"( 2.5.6.0 NAME 'top' ABSTRACT MUST objectClass )",
+			"( 2.5.6.6 NAME 'person' SUP top STRUCTURAL MUST ( sn $ cn ) MAY ( description ) )",
+			"( 2.5.6.7 NAME 'organizationalPerson' SUP person STRUCTURAL MAY ( commonName ) )",
+		},
+		"dITContentRules": {
+			"( 2.5.6.7 NAME 'organizationalPersonRule' MAY mail )",
+		},
+		"ldapSyntaxes": {
+			"( 1.3.6.1.4.1.1466.115.121.1.15 DESC 'Directory String' )",
+		},
+	}))
+	if len(schema.Errors) > 0 {
+		t.Fatal(schema.Errors)
+	}
+
+	if at := schema.AttributeType("CommonName"); at == nil || at.OID != "2.5.4.3" || schema.AttributeType("2.5.4.3") != at {
+		t.Errorf("unexpected attribute type: %#v", at)
+	}
+	if syntax := schema.AttributeSyntax("cn"); syntax != "1.3.6.1.4.1.1466.115.121.1.15" {
+		t.Errorf("unexpected inherited syntax %q", syntax)
+	}
+	if equality := schema.AttributeEquality("sn"); equality != "caseIgnoreMatch" {
+		t.Errorf("unexpected inherited equality %q", equality)
+	}
+	if schema.LDAPSyntax("1.3.6.1.4.1.1466.115.121.1.15") == nil || schema.ObjectClass("unknown") != nil {
+		t.Error("unexpected lookup results")
+	}
+
+	var chain []string
+	for _, oc := range schema.ObjectClassChain("organizationalPerson") {
+		chain = append(chain, oc.Names[0])
+	}
+	if !reflect.DeepEqual(chain, []string{"organizationalPerson", "person", "top"}) {
+		t.Errorf("unexpected object class chain: %v", chain)
+	}
+
+	if must := schema.RequiredAttributes("organizationalPerson"); !reflect.DeepEqual(must, []string{"sn", "cn", "objectClass"}) {
+		t.Errorf("unexpected required attributes: %v", must)
+	}
+	if may := schema.AllowedAttributes("organizationalPerson"); !reflect.DeepEqual(may, []string{"description", "mail"}) {
+		t.Errorf("unexpected allowed attributes: %v", may)
+	}
+}
+
+func TestSchemaInvalidDefinitions(t *testing.T) {
+	// definitions which cannot be parsed are skipped
+	schema := NewSchema(NewEntry("cn=Subschema", map[string][]string{
+		"attributeTypes": {
+			"( 2.5.4.3 NAME 'cn' SYNTAX 1.3.6.1.4.1.1466.115.121.1.15 )",
+			"( 1.2.840.113556.1.4.7000.102.50 NAME 'msExchQuirk' SYNTAX '1.2.840.113556.1.4.906' X-ORIGIN ( 'vendor' )",
+		},
+		"objectClasses": {
+			"( 2.5.6.6 NAME 'person' STRUCTURAL MUST cn )",
+			"NAME 'unnumbered'",
+		},
+	}))
+	if schema.AttributeType("cn") == nil || schema.ObjectClass("person") == nil {
+		t.Error("expected the valid definitions in the schema")
+	}
+	if len(schema.AttributeTypes) != 1 || len(schema.ObjectClasses) != 1 || len(schema.Errors) != 2 {
+		t.Errorf("unexpected definitions %v and %v, errors %v", schema.AttributeTypes, schema.ObjectClasses, schema.Errors)
+	}
+}
@@ -0,0 +1,626 @@
+package ldap
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// OIDs of the syntaxes checked by schema validation, see https://tools.ietf.org/html/rfc4517#section-3.3
+const (
+	SyntaxBoolean         = "1.3.6.1.4.1.1466.115.121.1.7"
+	SyntaxDN              = "1.3.6.1.4.1.1466.115.121.1.12"
+	SyntaxDirectoryString = "1.3.6.1.4.1.1466.115.121.1.15"
+	SyntaxGeneralizedTime = "1.3.6.1.4.1.1466.115.121.1.24"
+	SyntaxIA5String       = "1.3.6.1.4.1.1466.115.121.1.26"
+	SyntaxInteger         = "1.3.6.1.4.1.1466.115.121.1.27"
+	SyntaxNumericString   = "1.3.6.1.4.1.1466.115.121.1.36"
+	SyntaxOID             = "1.3.6.1.4.1.1466.115.121.1.38"
+	SyntaxPrintableString = "1.3.6.1.4.1.1466.115.121.1.44"
+	SyntaxTelephoneNumber = "1.3.6.1.4.1.1466.115.121.1.50"
+)
+
+// extensibleObjectOID is the OID of the extensibleObject object class, which allows any user attribute
+const extensibleObjectOID = "1.3.6.1.4.1.1466.101.120.111"
+
+// syntaxValidators check values of the syntaxes known by schema validation
+var syntaxValidators = map[string]func(value string) error{
+	SyntaxBoolean: func(value string) error {
+		if value != "TRUE" && value != "FALSE" {
+			return errors.New("not TRUE or FALSE")
+		}
+		return nil
+	},
+	SyntaxDN: func(value string) error {
+		_, err := ParseDN(value)
+		return err
+	},
+	SyntaxDirectoryString: func(value string) error {
+		if value == "" {
+			return errors.New("empty value")
+		}
+		if !utf8.ValidString(value) {
+			return errors.New("invalid UTF-8")
+		}
+		return nil
+	},
+	SyntaxGeneralizedTime: func(value string) error {
+		_, err := parseGeneralizedTime(value)
+		return err
+	},
+	SyntaxIA5String: func(value string) error {
+		for i := 0; i < len(value); i++ {
+			if value[i] > 0x7f {
+				return errors.New("not an IA5 string")
+			}
+		}
+		return nil
+	},
+	SyntaxInteger: func(value string) error {
+		digits := strings.TrimPrefix(value, "-")
+		if digits == "" || strings.Trim(digits, "0123456789") != "" || (digits[0] == '0' && (len(digits) > 1 || len(value) > 1)) {
+			return errors.New("not an integer")
+		}
+		return nil
+	},
+	SyntaxNumericString: func(value string) error {
+		if value == "" || strings.Trim(value, "0123456789 ") != "" {
+			return errors.New("not a numeric string")
+		}
+		return nil
+	},
+	SyntaxOID: func(value string) error {
+		if !isNumericOID(value) && !isDescr(value) {
+			return errors.New("not an OID or a name")
+		}
+		return nil
+	},
+	SyntaxPrintableString: validatePrintableString,
+	SyntaxTelephoneNumber: validatePrintableString,
+}
+
+func validatePrintableString(value string) error {
+	if value == "" {
+		return errors.New("empty value")
+	}
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || strings.IndexByte(" '()+,-./:=?", c) >= 0) {
+			return fmt.Errorf("invalid character %q", c)
+		}
+	}
+	return nil
+}
+
+// isNumericOID returns whether value is a numericoid of https://tools.ietf.org/html/rfc4512#section-1.4
+func isNumericOID(value string) bool {
+	for _, number := range strings.Split(value, ".") {
+		if number == "" || strings.Trim(number, "0123456789") != "" || (number[0] == '0' && len(number) > 1) {
+			return false
+		}
+	}
+	return strings.Contains(value, ".")
+}
+
+// isDescr returns whether value is a descr of https://tools.ietf.org/html/rfc4512#section-1.4
+func isDescr(value string) bool {
+	if value == "" || !('a' <= value[0] && value[0] <= 'z' || 'A' <= value[0] && value[0] <= 'Z') {
+		return false
+	}
+	for i := 1; i < len(value); i++ {
+		c := value[i]
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// parseGeneralizedTime parses a value of the Generalized Time syntax of https://tools.ietf.org/html/rfc4517#section-3.3.13
+func parseGeneralizedTime(value string) (time.Time, error) {
+	errInvalid := fmt.Errorf("ldap: invalid generalized time %q", value)
+	number := func(s string) (int, bool) {
+		if s == "" || strings.Trim(s, "0123456789") != "" {
+			return 0, false
+		}
+		n, err := strconv.Atoi(s)
+		return n, err == nil
+	}
+
+	if len(value) < 11 {
+		return time.Time{}, errInvalid
+	}
+	year, ok1 := number(value[0:4])
+	month, ok2 := number(value[4:6])
+	day, ok3 := number(value[6:8])
+	hour, ok4 := number(value[8:10])
+	if !ok1 || !ok2 || !ok3 || !ok4 || month < 1 || month > 12 || day < 1 || hour > 23 {
+		return time.Time{}, errInvalid
+	}
+	rest := value[10:]
+
+	minute, second := 0, 0
+	unit := time.Hour
+	if len(rest) >= 2 {
+		if n, ok := number(rest[:2]); ok {
+			if minute, unit, rest = n, time.Minute, rest[2:]; minute > 59 {
+				return time.Time{}, errInvalid
+			}
+			if len(rest) >= 2 {
+				if n, ok := number(rest[:2]); ok {
+					// allow leap seconds
+					if second, unit, rest = n, time.Second, rest[2:]; second > 60 {
+						return time.Time{}, errInvalid
+					}
+				}
+			}
+		}
+	}
+
+	var fraction time.Duration
+	if rest != "" && (rest[0] == '.' || rest[0] == ',') {
+		end := 1
+		for end < len(rest) && '0' <= rest[end] && rest[end] <= '9' {
+			end++
+		}
+		if end == 1 {
+			return time.Time{}, errInvalid
+		}
+		f, err := strconv.ParseFloat("0."+rest[1:end], 64)
+		if err != nil {
+			return time.Time{}, errInvalid
+		}
+		fraction = time.Duration(f * float64(unit))
+		rest = rest[end:]
+	}
+
+	var location *time.Location
+	switch {
+	case rest == "Z":
+		location = time.UTC
+	case len(rest) == 3 || len(rest) == 5:
+		if rest[0] != '+' && rest[0] != '-' {
+			return time.Time{}, errInvalid
+		}
+		hours, ok := number(rest[1:3])
+		minutes := 0
+		if len(rest) == 5 {
+			minutes, ok1 = number(rest[3:5])
+			ok = ok && ok1
+		}
+		if !ok || hours > 23 || minutes > 59 {
+			return time.Time{}, errInvalid
+		}
+		offset := hours*3600 + minutes*60
+		if rest[0] == '-' {
+			offset = -offset
+		}
+		location = time.FixedZone("", offset)
+	default:
+		return time.Time{}, errInvalid
+	}
+
+	t := time.Date(year, time.Month(month), day, hour, minute, second, 0, location)
+	if t.Day() != day && second != 60 {
+		return time.Time{}, errInvalid
+	}
+	return t.Add(fraction), nil
+}
+
+// SchemaViolation describes a problem found by validating a request against a schema
+type SchemaViolation struct {
+	// ResultCode is the result code a server is expected to reject the request with
+	ResultCode uint16
+	// Attribute is the attribute type or object class concerned
+	Attribute string
+	// Message describes the violation
+	Message string
+}
+
+func (v *SchemaViolation) Error() string {
+	return v.Message
+}
+
+// SchemaViolations is the list of violations returned by AddRequest.Validate and ModifyRequest.Validate
+// as the Err of an *Error
+type SchemaViolations []*SchemaViolation
+
+func (v SchemaViolations) Error() string {
+	messages := make([]string, len(v))
+	for i, violation := range v {
+		messages[i] = violation.Message
+	}
+	return "ldap: schema violation: " + strings.Join(messages, "; ")
+}
+
+// schemaValidator collects the violations found while validating a request
+type schemaValidator struct {
+	schema     *Schema
+	violations SchemaViolations
+}
+
+func (v *schemaValidator) addf(resultCode uint16, attribute string, format string, args ...interface{}) {
+	v.violations = append(v.violations, &SchemaViolation{
+		ResultCode: resultCode,
+		Attribute:  attribute,
+		Message:    fmt.Sprintf(format, args...),
+	})
+}
+
+// err returns the violations found as an *Error with the result code of the first one, or nil
+func (v *schemaValidator) err() error {
+	if len(v.violations) == 0 {
+		return nil
+	}
+	return NewError(v.violations[0].ResultCode, v.violations)
+}
+
+// attributeType returns the attribute type of the given attribute description, reporting unknown types
+func (v *schemaValidator) attributeType(description string) *AttributeType {
+	name := attributeDescriptionType(description)
+	at := v.schema.AttributeType(name)
+	if at == nil {
+		v.addf(LDAPResultUndefinedAttributeType, name, "unknown attribute type %q", name)
+	}
+	return at
+}
+
+// validateValues checks that the values conform to the syntax of the attribute type
+func (v *schemaValidator) validateValues(description string, at *AttributeType, values []string) {
+	validate := syntaxValidators[v.schema.AttributeSyntax(at.OID)]
+	if validate == nil {
+		return
+	}
+	for _, value := range values {
+		if err := validate(value); err != nil {
+			v.addf(LDAPResultInvalidAttributeSyntax, description, "invalid value %q for attribute %q: %s", value, description, err)
+		}
+	}
+}
+
+// validateAttribute checks an attribute of an entry to add or a value added by a modification
+func (v *schemaValidator) validateAttribute(description string, values []string) {
+	at := v.attributeType(description)
+	if at == nil {
+		return
+	}
+	if at.NoUserModification {
+		v.addf(LDAPResultConstraintViolation, description, "attribute %q cannot be modified by clients", description)
+	}
+	if at.SingleValue && len(values) > 1 {
+		v.addf(LDAPResultConstraintViolation, description, "attribute %q is single-valued, got %d values", description, len(values))
+	}
+	v.validateValues(description, at, values)
+}
+
+// validateEntry checks the object classes of an entry, and that it has all required attributes and
+// only allowed ones. The values of the attributes are not checked.
+func (v *schemaValidator) validateEntry(attributes []Attribute) {
+	var objectClasses []string
+	for _, attr := range attributes {
+		if v.schema.attributeDescriptionKey(attr.Type) == v.schema.attributeKey("objectClass") {
+			objectClasses = append(objectClasses, attr.Vals...)
+		}
+	}
+	if len(objectClasses) == 0 {
+		v.addf(LDAPResultObjectClassViolation, "objectClass", "entry has no object class")
+		return
+	}
+
+	var known []string
+	var structural []*ObjectClass
+	extensible := false
+	for _, name := range objectClasses {
+		chain := v.schema.ObjectClassChain(name)
+		if chain == nil {
+			v.addf(LDAPResultObjectClassViolation, name, "unknown object class %q", name)
+			continue
+		}
+		known = append(known, name)
+		for _, oc := range chain {
+			if oc.Kind == ObjectClassStructural {
+				structural = append(structural, oc)
+			}
+			extensible = extensible || oc.OID == extensibleObjectOID
+		}
+	}
+
+	structuralClass := v.structuralObjectClass(structural)
+	if structuralClass == nil && len(structural) == 0 && len(known) == len(objectClasses) {
+		v.addf(LDAPResultObjectClassViolation, "objectClass", "entry has no structural object class")
+	}
+	var rule *DITContentRule
+	if structuralClass != nil {
+		if rule = v.schema.DITContentRule(structuralClass.OID); rule != nil && rule.Obsolete {
+			rule = nil
+		}
+	}
+	if rule != nil {
+		for _, name := range known {
+			if oc := v.schema.ObjectClass(name); oc.Kind == ObjectClassAuxiliary && !v.containsObjectClass(rule.Aux, oc) {
+				v.addf(LDAPResultObjectClassViolation, name, "auxiliary object class %q is not allowed by the DIT content rule of %q", name, schemaDefinitionName(structuralClass.OID, structuralClass.Names))
+			}
+		}
+	}
+
+	present := make(map[string]bool)
+	for _, attr := range attributes {
+		present[v.schema.attributeKey(attributeDescriptionType(attr.Type))] = true
+	}
+	must, may := v.schema.objectClassAttributes(known)
+	allowed := make(map[string]bool)
+	for _, name := range must {
+		key := v.schema.attributeKey(name)
+		allowed[key] = true
+		if !present[key] {
+			v.addf(LDAPResultObjectClassViolation, name, "missing required attribute %q", name)
+		}
+	}
+	for _, name := range may {
+		allowed[v.schema.attributeKey(name)] = true
+	}
+	precluded := make(map[string]bool)
+	if rule != nil {
+		for _, name := range rule.Not {
+			key := v.schema.attributeKey(name)
+			precluded[key] = true
+			if present[key] {
+				v.addf(LDAPResultObjectClassViolation, name, "attribute %q is precluded by the DIT content rule of %q", name, schemaDefinitionName(structuralClass.OID, structuralClass.Names))
+			}
+		}
+	}
+
+	for _, attr := range attributes {
+		name := attributeDescriptionType(attr.Type)
+		at := v.schema.AttributeType(name)
+		if at == nil || at.Usage != AttributeUsageUserApplications || precluded[at.OID] {
+			continue
+		}
+		if !allowed[at.OID] && !extensible {
+			v.addf(LDAPResultObjectClassViolation, name, "attribute %q is not allowed by the object classes of the entry", name)
+		}
+	}
+}
+
+// structuralObjectClass returns the most specific of the structural object classes, reporting
+// structural classes which are not superiors of each other
+func (v *schemaValidator) structuralObjectClass(structural []*ObjectClass) *ObjectClass {
+	var result *ObjectClass
+	for _, oc := range structural {
+		if result == nil || v.containsObjectClass(objectClassNames(v.schema.ObjectClassChain(oc.OID)), result) {
+			result = oc
+		}
+	}
+	if result == nil {
+		return nil
+	}
+	chain := objectClassNames(v.schema.ObjectClassChain(result.OID))
+	for _, oc := range structural {
+		if !v.containsObjectClass(chain, oc) {
+			v.addf(LDAPResultObjectClassViolation, "objectClass", "structural object classes %q and %q are not in the same superclass chain",
+				schemaDefinitionName(result.OID, result.Names), schemaDefinitionName(oc.OID, oc.Names))
+			return nil
+		}
+	}
+	return result
+}
+
+// containsObjectClass returns whether one of the names or OIDs refers to the object class
+func (v *schemaValidator) containsObjectClass(names []string, oc *ObjectClass) bool {
+	for _, name := range names {
+		if v.schema.ObjectClass(name) == oc {
+			return true
+		}
+	}
+	return false
+}
+
+func objectClassNames(classes []*ObjectClass) []string {
+	names := make([]string, len(classes))
+	for i, oc := range classes {
+		names[i] = oc.OID
+	}
+	return names
+}
+
+// schemaDefinitionName returns the first name of a definition, or its OID if it has no name
+func schemaDefinitionName(oid string, names []string) string {
+	if len(names) > 0 {
+		return names[0]
+	}
+	return oid
+}
+
+// attributeDescriptionKey returns a key identifying an attribute description, so that descriptions
+// using different names of the same attribute type with the same options compare equal
+func (s *Schema) attributeDescriptionKey(description string) string {
+	attrType := attributeDescriptionType(description)
+	return s.attributeKey(attrType) + strings.ToLower(description[len(attrType):])
+}
+
+// attributeDescriptionType returns the attribute type of an attribute description, without its options
+func attributeDescriptionType(description string) string {
+	if i := strings.IndexByte(description, ';'); i >= 0 {
+		return description[:i]
+	}
+	return description
+}
+
+// Validate checks the entry to add against the schema, reporting missing required attributes, unknown
+// or disallowed attributes, multiple values for single-valued attributes, invalid object class
+// combinations and values not conforming to the syntax of their attribute for the syntaxes known
+// by the package (see the Syntax* constants). The error returned is an *Error with the result code of
+// the first violation found and SchemaViolations listing all of them.
+func (req *AddRequest) Validate(schema *Schema) error {
+	v := &schemaValidator{schema: schema}
+	// the values of an attribute listed more than once are checked together, as by the server
+	var attributes []Attribute
+	indexes := make(map[string]int)
+	for _, attr := range req.Attributes {
+		key := schema.attributeDescriptionKey(attr.Type)
+		if i, ok := indexes[key]; ok {
+			attributes[i].Vals = append(attributes[i].Vals, attr.Vals...)
+			continue
+		}
+		indexes[key] = len(attributes)
+		attributes = append(attributes, Attribute{Type: attr.Type, Vals: append([]string(nil), attr.Vals...)})
+	}
+	for _, attr := range attributes {
+		v.validateAttribute(attr.Type, attr.Vals)
+	}
+	v.validateEntry(attributes)
+	return v.err()
+}
+
+// Validate checks the changes against the schema like AddRequest.Validate. When the current entry is
+// given, with all its user attributes, the changes are applied to a copy of it and the resulting entry
+// is validated as well, also reporting values to delete which are not present and changes of the
+// structural object class. The error returned is an *Error with the result code of the first violation
+// found and SchemaViolations listing all of them.
+func (req *ModifyRequest) Validate(schema *Schema, current *Entry) error {
+	v := &schemaValidator{schema: schema}
+	var attributes []Attribute
+	if current != nil {
+		for _, attr := range current.Attributes {
+			attributes = append(attributes, Attribute{Type: attr.Name, Vals: append([]string(nil), attr.Values...)})
+		}
+	}
+	find := func(description string) int {
+		key := v.schema.attributeDescriptionKey(description)
+		for i, attr := range attributes {
+			if v.schema.attributeDescriptionKey(attr.Type) == key {
+				return i
+			}
+		}
+		return -1
+	}
+
+	for _, change := range req.Changes {
+		description, values := change.Modification.Type, change.Modification.Vals
+		at := v.attributeType(description)
+		if at != nil && at.NoUserModification {
+			v.addf(LDAPResultConstraintViolation, description, "attribute %q cannot be modified by clients", description)
+		}
+		switch change.Operation {
+		case AddAttribute, ReplaceAttribute:
+			if at != nil {
+				v.validateValues(description, at, values)
+				if current == nil && at.SingleValue && len(values) > 1 {
+					v.addf(LDAPResultConstraintViolation, description, "attribute %q is single-valued, got %d values", description, len(values))
+				}
+			}
+		case IncrementAttribute:
+			if len(values) != 1 || syntaxValidators[SyntaxInteger](values[0]) != nil {
+				v.addf(LDAPResultInvalidAttributeSyntax, description, "invalid increment %q for attribute %q", values, description)
+			}
+		}
+		if current == nil {
+			continue
+		}
+
+		i := find(description)
+		switch change.Operation {
+		case AddAttribute:
+			if i < 0 {
+				attributes = append(attributes, Attribute{Type: description})
+				i = len(attributes) - 1
+			}
+			for _, value := range values {
+				if v.findValue(description, attributes[i].Vals, value) >= 0 {
+					v.addf(LDAPResultAttributeOrValueExists, description, "value %q of attribute %q already exists", value, description)
+					continue
+				}
+				attributes[i].Vals = append(attributes[i].Vals, value)
+			}
+		case DeleteAttribute:
+			if i < 0 {
+				v.addf(LDAPResultNoSuchAttribute, description, "attribute %q to delete is not present", description)
+				continue
+			}
+			if len(values) == 0 {
+				attributes = append(attributes[:i], attributes[i+1:]...)
+				continue
+			}
+			for _, value := range values {
+				j := v.findValue(description, attributes[i].Vals, value)
+				if j < 0 {
+					v.addf(LDAPResultNoSuchAttribute, description, "value %q of attribute %q to delete is not present", value, description)
+					continue
+				}
+				attributes[i].Vals = append(attributes[i].Vals[:j], attributes[i].Vals[j+1:]...)
+			}
+			if len(attributes[i].Vals) == 0 {
+				attributes = append(attributes[:i], attributes[i+1:]...)
+			}
+		case ReplaceAttribute:
+			switch {
+			case len(values) == 0 && i >= 0:
+				attributes = append(attributes[:i], attributes[i+1:]...)
+			case i >= 0:
+				attributes[i].Vals = append([]string(nil), values...)
+			case len(values) > 0:
+				attributes = append(attributes, Attribute{Type: description, Vals: append([]string(nil), values...)})
+			}
+		case IncrementAttribute:
+			if i < 0 {
+				v.addf(LDAPResultNoSuchAttribute, description, "attribute %q to increment is not present", description)
+			}
+		}
+	}
+
+	if current != nil {
+		for _, attr := range attributes {
+			if at := v.schema.AttributeType(attributeDescriptionType(attr.Type)); at != nil && at.SingleValue && len(attr.Vals) > 1 {
+				v.addf(LDAPResultConstraintViolation, attr.Type, "attribute %q is single-valued, got %d values", attr.Type, len(attr.Vals))
+			}
+		}
+		before := v.entryStructuralObjectClass(current.GetEqualFoldAttributeValues("objectClass"))
+		var objectClasses []string
+		for _, attr := range attributes {
+			if v.schema.attributeDescriptionKey(attr.Type) == v.schema.attributeKey("objectClass") {
+				objectClasses = append(objectClasses, attr.Vals...)
+			}
+		}
+		after := v.entryStructuralObjectClass(objectClasses)
+		if before != nil && after != nil && before != after {
+			v.addf(LDAPResultObjectClassModsProhibited, "objectClass", "structural object class cannot be changed from %q to %q",
+				schemaDefinitionName(before.OID, before.Names), schemaDefinitionName(after.OID, after.Names))
+		}
+		v.validateEntry(attributes)
+	}
+	return v.err()
+}
+
+// entryStructuralObjectClass returns the structural object class of an entry, if it can be determined
+func (v *schemaValidator) entryStructuralObjectClass(objectClasses []string) *ObjectClass {
+	var structural []*ObjectClass
+	for _, name := range objectClasses {
+		for _, oc := range v.schema.ObjectClassChain(name) {
+			if oc.Kind == ObjectClassStructural {
+				structural = append(structural, oc)
+			}
+		}
+	}
+	// violations are reported when validating the resulting entry
+	check := &schemaValidator{schema: v.schema}
+	return check.structuralObjectClass(structural)
+}
+
+// findValue returns the index of the first value equal to the given one under the equality matching
+// rule of the attribute, as for a filter, or under case folding if it cannot be compared, and -1 if none
+func (v *schemaValidator) findValue(description string, values []string, value string) int {
+	m := (&FilterEvaluator{Schema: v.schema}).attributeMatcher(description, equalityRule)
+	for i, existing := range values {
+		equal, err := matcherEqual(m, existing, value)
+		if err != nil {
+			equal = strings.EqualFold(existing, value)
+		}
+		if equal {
+			return i
+		}
+	}
+	return -1
+}
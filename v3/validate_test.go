@@ -0,0 +1,227 @@
+package ldap
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestSchema(t *testing.T) *Schema {
+	schema := NewSchema(NewEntry("cn=Subschema", map[string][]string{
+		"attributeTypes": {
+			"( 2.5.4.0 NAME 'objectClass' EQUALITY objectIdentifierMatch SYNTAX 1.3.6.1.4.1.1466.115.121.1.38 )",
+			"( 2.5.4.41 NAME 'name' EQUALITY caseIgnoreMatch SYNTAX 1.3.6.1.4.1.1466.115.121.1.15 )",
+			"( 2.5.4.3 NAME ( 'cn' 'commonName' ) SUP name )",
+			"( 2.5.4.4 NAME ( 'sn' 'surname' ) SUP name )",
+			"( 2.5.4.13 NAME 'description' SUP name )",
+			"( 2.5.4.34 NAME 'seeAlso' SYNTAX 1.3.6.1.4.1.1466.115.121.1.12 )",
+			"( 2.16.840.1.113730.3.1.3 NAME 'employeeNumber' SYNTAX 1.3.6.1.4.1.1466.115.121.1.15 SINGLE-VALUE )",
+			"( 1.3.6.1.1.1.1.0 NAME 'uidNumber' EQUALITY integerMatch SYNTAX 1.3.6.1.4.1.1466.115.121.1.27 SINGLE-VALUE )",
+			"( 1.2.3.1 NAME 'expires' SYNTAX 1.3.6.1.4.1.1466.115.121.1.24 )",
+			"( 2.5.18.1 NAME 'createTimestamp' SYNTAX 1.3.6.1.4.1.1466.115.121.1.24 SINGLE-VALUE NO-USER-MODIFICATION USAGE directoryOperation )",
+		},
+		"objectClasses": {
+			"( 2.5.6.0 NAME 'top' ABSTRACT MUST objectClass )",
+			"( 2.5.6.6 NAME 'person' SUP top STRUCTURAL MUST ( sn $ cn ) MAY ( description $ seeAlso ) )",
+			"( 2.16.840.1.113730.3.2.2 NAME 'inetOrgPerson' SUP person STRUCTURAL MAY ( employeeNumber $ expires ) )",
+			"( 2.5.6.11 NAME 'applicationProcess' SUP top STRUCTURAL MUST cn )",
+			"( 1.3.6.1.1.1.2.0 NAME 'posixAccount' SUP top AUXILIARY MUST ( cn $ uidNumber ) )",
+		},
+	}))
+	if len(schema.Errors) > 0 {
+		t.Fatal(schema.Errors)
+	}
+	return schema
+}
+
+func schemaViolationCodes(t *testing.T, err error) []uint16 {
+	if err == nil {
+		return nil
+	}
+	ldapErr, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("unexpected error type %T", err)
+	}
+	violations, ok := ldapErr.Err.(SchemaViolations)
+	if !ok || len(violations) == 0 || ldapErr.ResultCode != violations[0].ResultCode {
+		t.Fatalf("unexpected error %#v", ldapErr)
+	}
+	codes := make([]uint16, len(violations))
+	for i, violation := range violations {
+		codes[i] = violation.ResultCode
+	}
+	return codes
+}
+
+func TestAddRequestValidate(t *testing.T) {
+	schema := newTestSchema(t)
+
+	testcases := []struct {
+		attributes map[string][]string
+		codes      []uint16
+	}{
+		{map[string][]string{"objectClass": {"inetOrgPerson", "posixAccount"}, "cn": {"a"}, "SN": {"b"}, "uidNumber": {"1000"},
+			"expires": {"20201231235959Z"}, "seeAlso": {"cn=c,dc=example,dc=org"}, "cn;lang-en": {"a"}}, nil},
+		{map[string][]string{"objectClass": {"person"}, "cn": {"a"}},
+			[]uint16{LDAPResultObjectClassViolation}},
+		{map[string][]string{"cn": {"a"}, "sn": {"b"}},
+			[]uint16{LDAPResultObjectClassViolation}},
+		{map[string][]string{"objectClass": {"posixAccount"}, "cn": {"a"}, "uidNumber": {"1"}},
+			[]uint16{LDAPResultObjectClassViolation}},
+		{map[string][]string{"objectClass": {"person", "applicationProcess"}, "cn": {"a"}, "sn": {"b"}},
+			[]uint16{LDAPResultObjectClassViolation}},
+		{map[string][]string{"objectClass": {"person"}, "cn": {"a"}, "sn": {"b"}, "employeeNumber": {"1"}},
+			[]uint16{LDAPResultObjectClassViolation}},
+		{map[string][]string{"objectClass": {"person"}, "cn": {"a"}, "sn": {"b"}, "unknown": {"1"}},
+			[]uint16{LDAPResultUndefinedAttributeType}},
+		{map[string][]string{"objectClass": {"inetOrgPerson"}, "cn": {"a"}, "sn": {"b"}, "employeeNumber": {"1", "2"}},
+			[]uint16{LDAPResultConstraintViolation}},
+		{map[string][]string{"objectClass": {"person"}, "cn": {"a"}, "sn": {"b"}, "createTimestamp": {"20200101000000Z"}},
+			[]uint16{LDAPResultConstraintViolation}},
+		{map[string][]string{"objectClass": {"inetOrgPerson", "posixAccount"}, "cn": {"a"}, "sn": {"b"}, "uidNumber": {"01"}},
+			[]uint16{LDAPResultInvalidAttributeSyntax}},
+		{map[string][]string{"objectClass": {"inetOrgPerson"}, "cn": {"a"}, "sn": {"b"}, "expires": {"2020-12-31"}, "seeAlso": {"invalid"}},
+			[]uint16{LDAPResultInvalidAttributeSyntax, LDAPResultInvalidAttributeSyntax}},
+	}
+	for i, tc := range testcases {
+		req := NewAddRequest("cn=a,dc=example,dc=org", nil)
+		for _, name := range []string{"objectClass", "cn", "SN", "sn", "cn;lang-en", "uidNumber", "employeeNumber", "expires", "seeAlso", "createTimestamp", "unknown"} {
+			if values, ok := tc.attributes[name]; ok {
+				req.Attribute(name, values)
+			}
+		}
+		codes := schemaViolationCodes(t, req.Validate(schema))
+		if len(codes) != len(tc.codes) {
+			t.Errorf("#%d: got violations %v, want %v (%v)", i, codes, tc.codes, req.Validate(schema))
+			continue
+		}
+		for j := range codes {
+			if codes[j] != tc.codes[j] {
+				t.Errorf("#%d: got violations %v, want %v (%v)", i, codes, tc.codes, req.Validate(schema))
+			}
+		}
+	}
+
+	// a single-valued attribute listed twice, under any of its names
+	for _, names := range [][2]string{{"uidNumber", "uidNumber"}, {"uidNumber", "UIDNUMBER"}} {
+		req := NewAddRequest("cn=a,dc=example,dc=org", nil)
+		req.Attribute("objectClass", []string{"inetOrgPerson", "posixAccount"})
+		req.Attribute("cn", []string{"a"})
+		req.Attribute("sn", []string{"b"})
+		req.Attribute(names[0], []string{"1000"})
+		req.Attribute(names[1], []string{"1001"})
+		if codes := schemaViolationCodes(t, req.Validate(schema)); len(codes) != 1 || codes[0] != LDAPResultConstraintViolation {
+			t.Errorf("%v: got violations %v, want a constraint violation", names, codes)
+		}
+	}
+}
+
+func TestModifyRequestValidate(t *testing.T) {
+	schema := newTestSchema(t)
+	current := NewEntry("cn=a,dc=example,dc=org", map[string][]string{
+		"objectClass": {"top", "person"},
+		"cn":          {"a"},
+		"sn":          {"b"},
+		"description": {"one", "two"},
+	})
+
+	req := NewModifyRequest(current.DN, nil)
+	req.Add("objectClass", []string{"posixAccount"})
+	req.Replace("uidNumber", []string{"42"})
+	req.Delete("description", []string{"ONE"})
+	if err := req.Validate(schema, current); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	testcases := []struct {
+		change Change
+		codes  []uint16
+	}{
+		{Change{DeleteAttribute, PartialAttribute{Type: "sn"}}, []uint16{LDAPResultObjectClassViolation}},
+		{Change{DeleteAttribute, PartialAttribute{Type: "seeAlso"}}, []uint16{LDAPResultNoSuchAttribute}},
+		{Change{DeleteAttribute, PartialAttribute{Type: "description", Vals: []string{"three"}}}, []uint16{LDAPResultNoSuchAttribute}},
+		{Change{AddAttribute, PartialAttribute{Type: "description", Vals: []string{"two"}}}, []uint16{LDAPResultAttributeOrValueExists}},
+		// values are compared with the equality matching rule of the attribute for both adds and deletes
+		{Change{AddAttribute, PartialAttribute{Type: "description", Vals: []string{"TWO"}}}, []uint16{LDAPResultAttributeOrValueExists}},
+		{Change{AddAttribute, PartialAttribute{Type: "description", Vals: []string{"Three"}}}, nil},
+		{Change{AddAttribute, PartialAttribute{Type: "employeeNumber", Vals: []string{"1"}}}, []uint16{LDAPResultObjectClassViolation}},
+		{Change{AddAttribute, PartialAttribute{Type: "objectClass", Vals: []string{"inetOrgPerson"}}}, []uint16{LDAPResultObjectClassModsProhibited}},
+		{Change{ReplaceAttribute, PartialAttribute{Type: "objectClass", Vals: []string{"applicationProcess"}}}, []uint16{LDAPResultObjectClassModsProhibited, LDAPResultObjectClassViolation, LDAPResultObjectClassViolation}},
+		{Change{ReplaceAttribute, PartialAttribute{Type: "seeAlso", Vals: []string{"x"}}}, []uint16{LDAPResultInvalidAttributeSyntax}},
+		{Change{ReplaceAttribute, PartialAttribute{Type: "createTimestamp", Vals: []string{"20200101000000Z"}}}, []uint16{LDAPResultConstraintViolation}},
+		{Change{IncrementAttribute, PartialAttribute{Type: "description", Vals: []string{"x"}}}, []uint16{LDAPResultInvalidAttributeSyntax}},
+	}
+	for i, tc := range testcases {
+		req := &ModifyRequest{DN: current.DN, Changes: []Change{tc.change}}
+		codes := schemaViolationCodes(t, req.Validate(schema, current))
+		if len(codes) != len(tc.codes) {
+			t.Errorf("#%d: got violations %v, want %v (%v)", i, codes, tc.codes, req.Validate(schema, current))
+			continue
+		}
+		for j := range codes {
+			if codes[j] != tc.codes[j] {
+				t.Errorf("#%d: got violations %v, want %v (%v)", i, codes, tc.codes, req.Validate(schema, current))
+			}
+		}
+	}
+
+	// without the current entry, only the changes themselves are checked
+	req = NewModifyRequest(current.DN, nil)
+	req.Delete("sn", nil)
+	req.Replace("uidNumber", []string{"1", "2"})
+	if codes := schemaViolationCodes(t, req.Validate(schema, nil)); len(codes) != 1 || codes[0] != LDAPResultConstraintViolation {
+		t.Errorf("unexpected violations %v", codes)
+	}
+}
+
+func TestSchemaValidatorFindValue(t *testing.T) {
+	v := &schemaValidator{schema: newTestSchema(t)}
+	testcases := []struct {
+		attribute string
+		values    []string
+		value     string
+		index     int
+	}{
+		{"description", []string{"one", "Two"}, "TWO", 1},
+		{"description", []string{"one  two"}, " One Two ", 0},
+		{"uidNumber", []string{"42"}, "042", 0},
+		{"uidNumber", []string{"42"}, "43", -1},
+		// values of unknown attributes are compared under case folding
+		{"unknown", []string{"Foo"}, "foo", 0},
+	}
+	for _, tc := range testcases {
+		if index := v.findValue(tc.attribute, tc.values, tc.value); index != tc.index {
+			t.Errorf("%s: got index %d of %q in %q, want %d", tc.attribute, index, tc.value, tc.values, tc.index)
+		}
+	}
+}
+
+func TestParseGeneralizedTime(t *testing.T) {
+	testcases := map[string]time.Time{
+		"20201231235959Z":        time.Date(2020, 12, 31, 23, 59, 59, 0, time.UTC),
+		"2020123123Z":            time.Date(2020, 12, 31, 23, 0, 0, 0, time.UTC),
+		"202012312359Z":          time.Date(2020, 12, 31, 23, 59, 0, 0, time.UTC),
+		"20201231235959.5Z":      time.Date(2020, 12, 31, 23, 59, 59, 500000000, time.UTC),
+		"2020123123,25Z":         time.Date(2020, 12, 31, 23, 15, 0, 0, time.UTC),
+		"20201231235959.0Z":      time.Date(2020, 12, 31, 23, 59, 59, 0, time.UTC),
+		"20201231235959+0130":    time.Date(2020, 12, 31, 22, 29, 59, 0, time.UTC),
+		"20201231235959-05":      time.Date(2021, 1, 1, 4, 59, 59, 0, time.UTC),
+		"19991231235960Z":        time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+		"20200229000000.123456Z": time.Date(2020, 2, 29, 0, 0, 0, 123456000, time.UTC),
+	}
+	for value, want := range testcases {
+		got, err := parseGeneralizedTime(value)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", value, err)
+			continue
+		}
+		if !got.Equal(want) {
+			t.Errorf("%q: got %s, want %s", value, got, want)
+		}
+	}
+
+	for _, value := range []string{"", "20201231235959", "2020123124Z", "20201331000000Z", "20210229000000Z", "20201231235959.Z", "20201231235959+2400", "2020-12-31T23:59:59Z"} {
+		if _, err := parseGeneralizedTime(value); err == nil {
+			t.Errorf("%q: expected an error", value)
+		}
+	}
+}
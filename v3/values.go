@@ -0,0 +1,385 @@
+package ldap
+
+import (
+	"encoding/binary"
+	enchex "encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// UserAccountControl holds the flags of the userAccountControl attribute of Active Directory,
+// see https://docs.microsoft.com/en-us/troubleshoot/windows-server/identity/useraccountcontrol-manipulate-account-properties
+type UserAccountControl uint32
+
+// UserAccountControl flags
+const (
+	UACScript                       UserAccountControl = 0x0001
+	UACAccountDisable               UserAccountControl = 0x0002
+	UACHomeDirRequired              UserAccountControl = 0x0008
+	UACLockout                      UserAccountControl = 0x0010
+	UACPasswordNotRequired          UserAccountControl = 0x0020
+	UACPasswordCantChange           UserAccountControl = 0x0040
+	UACEncryptedTextPasswordAllowed UserAccountControl = 0x0080
+	UACTempDuplicateAccount         UserAccountControl = 0x0100
+	UACNormalAccount                UserAccountControl = 0x0200
+	UACInterdomainTrustAccount      UserAccountControl = 0x0800
+	UACWorkstationTrustAccount      UserAccountControl = 0x1000
+	UACServerTrustAccount           UserAccountControl = 0x2000
+	UACDontExpirePassword           UserAccountControl = 0x10000
+	UACMNSLogonAccount              UserAccountControl = 0x20000
+	UACSmartcardRequired            UserAccountControl = 0x40000
+	UACTrustedForDelegation         UserAccountControl = 0x80000
+	UACNotDelegated                 UserAccountControl = 0x100000
+	UACUseDESKeyOnly                UserAccountControl = 0x200000
+	UACDontRequirePreauth           UserAccountControl = 0x400000
+	UACPasswordExpired              UserAccountControl = 0x800000
+	UACTrustedToAuthForDelegation   UserAccountControl = 0x1000000
+	UACNoAuthDataRequired           UserAccountControl = 0x2000000
+	UACPartialSecretsAccount        UserAccountControl = 0x4000000
+	UACUseAESKeys                   UserAccountControl = 0x8000000
+)
+
+// Has returns whether all the given flags are set
+func (u UserAccountControl) Has(flags UserAccountControl) bool {
+	return u&flags == flags
+}
+
+// String returns the decimal representation of the flags, as used for the userAccountControl attribute
+func (u UserAccountControl) String() string {
+	return strconv.FormatUint(uint64(u), 10)
+}
+
+// fileTimeEpochOffset is the number of seconds between the Windows FILETIME epoch, 1601-01-01 UTC,
+// and the Unix epoch
+const fileTimeEpochOffset = 11644473600
+
+// fileTimeNever is the FILETIME value used by Active Directory for "never", e.g. in accountExpires
+const fileTimeNever = 1<<63 - 1
+
+// ParseFileTime parses a Windows FILETIME, the number of 100-nanosecond intervals since 1601-01-01 UTC,
+// as used by Active Directory attributes like pwdLastSet, lastLogonTimestamp or accountExpires.
+// The special values 0 and 0x7FFFFFFFFFFFFFFF (never) result in the zero time.
+func ParseFileTime(value string) (time.Time, error) {
+	ticks, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("ldap: invalid file time %q: %s", value, err)
+	}
+	if ticks == 0 || ticks == fileTimeNever {
+		return time.Time{}, nil
+	}
+	if ticks < 0 {
+		return time.Time{}, fmt.Errorf("ldap: invalid file time %q", value)
+	}
+	return time.Unix(ticks/1e7-fileTimeEpochOffset, ticks%1e7*100).UTC(), nil
+}
+
+// EncodeFileTime returns the Windows FILETIME representation of t. The zero time is encoded as 0.
+func EncodeFileTime(t time.Time) string {
+	if t.IsZero() {
+		return "0"
+	}
+	return strconv.FormatInt((t.Unix()+fileTimeEpochOffset)*1e7+int64(t.Nanosecond()/100), 10)
+}
+
+// ParseGeneralizedTime parses a value of the Generalized Time syntax from https://tools.ietf.org/html/rfc4517#section-3.3.13
+func ParseGeneralizedTime(value string) (time.Time, error) {
+	return parseGeneralizedTime(value)
+}
+
+// EncodeGeneralizedTime returns the Generalized Time representation of t in UTC, with fractional seconds
+// only if t has any
+func EncodeGeneralizedTime(t time.Time) string {
+	return t.UTC().Format("20060102150405.999999999Z")
+}
+
+// ParseBool parses a value of the Boolean syntax from https://tools.ietf.org/html/rfc4517#section-3.3.3
+func ParseBool(value string) (bool, error) {
+	switch value {
+	case "TRUE":
+		return true, nil
+	case "FALSE":
+		return false, nil
+	}
+	return false, fmt.Errorf("ldap: invalid boolean %q", value)
+}
+
+// EncodeBool returns the Boolean syntax representation of b
+func EncodeBool(b bool) string {
+	if b {
+		return "TRUE"
+	}
+	return "FALSE"
+}
+
+// EncodeUnicodePwd returns the value of the unicodePwd attribute of Active Directory for the password,
+// i.e. the password in double quotes encoded in UTF-16LE, see
+// https://docs.microsoft.com/en-us/openspecs/windows_protocols/ms-adts/6e803168-f140-4d23-b2d3-c3a8ab5917d2
+func EncodeUnicodePwd(password string) []byte {
+	encoded := utf16.Encode([]rune("\"" + password + "\""))
+	b := make([]byte, 2*len(encoded))
+	for i, c := range encoded {
+		binary.LittleEndian.PutUint16(b[2*i:], c)
+	}
+	return b
+}
+
+// SID represents a Windows security identifier as found in the objectSid attribute of Active Directory,
+// see https://docs.microsoft.com/en-us/openspecs/windows_protocols/ms-dtyp/f992ad60-0fe4-4b87-9fed-beb478836861
+type SID struct {
+	// Revision is the revision level of the SID, always 1
+	Revision uint8
+	// IdentifierAuthority is the authority under which the SID was created, e.g. 5 for NT Authority
+	IdentifierAuthority uint64
+	// SubAuthorities are the sub-authority values, the last one being the relative identifier (RID) of
+	// security principals
+	SubAuthorities []uint32
+}
+
+// ParseSID parses the binary representation of a SID
+func ParseSID(b []byte) (*SID, error) {
+	if len(b) < 8 || len(b) != 8+4*int(b[1]) {
+		return nil, fmt.Errorf("ldap: invalid SID of %d bytes", len(b))
+	}
+	sid := &SID{
+		Revision:       b[0],
+		SubAuthorities: make([]uint32, b[1]),
+	}
+	for _, c := range b[2:8] {
+		sid.IdentifierAuthority = sid.IdentifierAuthority<<8 | uint64(c)
+	}
+	for i := range sid.SubAuthorities {
+		sid.SubAuthorities[i] = binary.LittleEndian.Uint32(b[8+4*i:])
+	}
+	return sid, nil
+}
+
+// ParseSIDString parses the string representation of a SID, e.g. "S-1-5-21-1004336348-1177238915-682003330-512"
+func ParseSIDString(s string) (*SID, error) {
+	parts := strings.Split(s, "-")
+	if len(parts) < 3 || !strings.EqualFold(parts[0], "S") {
+		return nil, fmt.Errorf("ldap: invalid SID %q", s)
+	}
+	revision, err := strconv.ParseUint(parts[1], 10, 8)
+	if err != nil {
+		return nil, fmt.Errorf("ldap: invalid SID revision in %q: %s", s, err)
+	}
+	authority, err := strconv.ParseUint(parts[2], 0, 48)
+	if err != nil {
+		return nil, fmt.Errorf("ldap: invalid SID identifier authority in %q: %s", s, err)
+	}
+	if len(parts)-3 > 255 {
+		return nil, fmt.Errorf("ldap: too many sub-authorities in SID %q", s)
+	}
+	sid := &SID{Revision: uint8(revision), IdentifierAuthority: authority, SubAuthorities: make([]uint32, len(parts)-3)}
+	for i, part := range parts[3:] {
+		subAuthority, err := strconv.ParseUint(part, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("ldap: invalid SID sub-authority in %q: %s", s, err)
+		}
+		sid.SubAuthorities[i] = uint32(subAuthority)
+	}
+	return sid, nil
+}
+
+// String returns the string representation of the SID, e.g. "S-1-5-32-544"
+func (sid *SID) String() string {
+	var buf strings.Builder
+	buf.WriteString("S-")
+	buf.WriteString(strconv.FormatUint(uint64(sid.Revision), 10))
+	buf.WriteByte('-')
+	if sid.IdentifierAuthority >= 1<<32 {
+		buf.WriteString(fmt.Sprintf("0x%012X", sid.IdentifierAuthority))
+	} else {
+		buf.WriteString(strconv.FormatUint(sid.IdentifierAuthority, 10))
+	}
+	for _, subAuthority := range sid.SubAuthorities {
+		buf.WriteByte('-')
+		buf.WriteString(strconv.FormatUint(uint64(subAuthority), 10))
+	}
+	return buf.String()
+}
+
+// Bytes returns the binary representation of the SID. Use string(sid.Bytes()) as an attribute value
+// and EscapeFilterBytes(sid.Bytes()) in filters.
+func (sid *SID) Bytes() []byte {
+	b := make([]byte, 8+4*len(sid.SubAuthorities))
+	b[0] = sid.Revision
+	b[1] = byte(len(sid.SubAuthorities))
+	for i := 0; i < 6; i++ {
+		b[7-i] = byte(sid.IdentifierAuthority >> (8 * uint(i)))
+	}
+	for i, subAuthority := range sid.SubAuthorities {
+		binary.LittleEndian.PutUint32(b[8+4*i:], subAuthority)
+	}
+	return b
+}
+
+// RID returns the relative identifier of the SID, its last sub-authority, or 0 if it has none
+func (sid *SID) RID() uint32 {
+	if len(sid.SubAuthorities) == 0 {
+		return 0
+	}
+	return sid.SubAuthorities[len(sid.SubAuthorities)-1]
+}
+
+// GUID represents a UUID, such as the objectGUID attribute of Active Directory or the entryUUID
+// attribute of https://tools.ietf.org/html/rfc4530. The bytes are in the order of the string
+// representation of https://tools.ietf.org/html/rfc4122.
+type GUID [16]byte
+
+// ParseGUIDString parses the string representation of a UUID, e.g. "6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+// optionally enclosed in braces
+func ParseGUIDString(s string) (GUID, error) {
+	var g GUID
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "{"), "}")
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return g, fmt.Errorf("ldap: invalid GUID %q", s)
+	}
+	if _, err := enchex.Decode(g[:], []byte(s[0:8]+s[9:13]+s[14:18]+s[19:23]+s[24:])); err != nil {
+		return g, fmt.Errorf("ldap: invalid GUID %q: %s", s, err)
+	}
+	return g, nil
+}
+
+// ParseGUID parses the binary representation of an Active Directory objectGUID, whose first three
+// fields are little-endian
+func ParseGUID(b []byte) (GUID, error) {
+	var g GUID
+	if len(b) != len(g) {
+		return g, fmt.Errorf("ldap: invalid GUID of %d bytes", len(b))
+	}
+	copy(g[:], b)
+	g.swapEndianness()
+	return g, nil
+}
+
+// Bytes returns the binary representation of the GUID used by Active Directory for objectGUID. Use
+// string(g.Bytes()) as an attribute value and EscapeFilterBytes(g.Bytes()) in filters.
+func (g GUID) Bytes() []byte {
+	g.swapEndianness()
+	return g[:]
+}
+
+// String returns the string representation of the GUID, e.g. "6ba7b810-9dad-11d1-80b4-00c04fd430c8"
+func (g GUID) String() string {
+	s := enchex.EncodeToString(g[:])
+	return s[0:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:]
+}
+
+// swapEndianness converts between the mixed-endian order of Active Directory and the RFC 4122 order
+func (g *GUID) swapEndianness() {
+	g[0], g[1], g[2], g[3] = g[3], g[2], g[1], g[0]
+	g[4], g[5] = g[5], g[4]
+	g[6], g[7] = g[7], g[6]
+}
+
+// firstValue returns the first value of the attribute, compared case-insensitively, or an error if it is not present
+func (e *Entry) firstValue(attribute string) ([]byte, error) {
+	for _, attr := range e.Attributes {
+		if strings.EqualFold(attr.Name, attribute) && len(attr.ByteValues) > 0 {
+			return attr.ByteValues[0], nil
+		}
+	}
+	return nil, fmt.Errorf("ldap: attribute %q not present in entry %q", attribute, e.DN)
+}
+
+// GetTimeValue returns the first value of the attribute as a Generalized Time, e.g. for createTimestamp
+// or whenChanged. Attribute names are compared case-insensitively. An error is returned if the attribute
+// is not present or its value cannot be parsed.
+func (e *Entry) GetTimeValue(attribute string) (time.Time, error) {
+	value, err := e.firstValue(attribute)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return parseGeneralizedTime(string(value))
+}
+
+// GetFileTimeValue returns the first value of the attribute as a Windows FILETIME, see ParseFileTime.
+// Attribute names are compared case-insensitively. An error is returned if the attribute is not present
+// or its value cannot be parsed.
+func (e *Entry) GetFileTimeValue(attribute string) (time.Time, error) {
+	value, err := e.firstValue(attribute)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return ParseFileTime(string(value))
+}
+
+// GetIntValue returns the first value of the attribute as an Integer. Attribute names are compared
+// case-insensitively. An error is returned if the attribute is not present or its value cannot be parsed.
+func (e *Entry) GetIntValue(attribute string) (int64, error) {
+	value, err := e.firstValue(attribute)
+	if err != nil {
+		return 0, err
+	}
+	i, err := strconv.ParseInt(string(value), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("ldap: invalid integer %q: %s", value, err)
+	}
+	return i, nil
+}
+
+// GetBoolValue returns the first value of the attribute as a Boolean. Attribute names are compared
+// case-insensitively. An error is returned if the attribute is not present or its value cannot be parsed.
+func (e *Entry) GetBoolValue(attribute string) (bool, error) {
+	value, err := e.firstValue(attribute)
+	if err != nil {
+		return false, err
+	}
+	return ParseBool(string(value))
+}
+
+// GetDNValue returns the first value of the attribute as a DN, e.g. for manager. Attribute names are
+// compared case-insensitively. An error is returned if the attribute is not present or its value
+// cannot be parsed.
+func (e *Entry) GetDNValue(attribute string) (*DN, error) {
+	value, err := e.firstValue(attribute)
+	if err != nil {
+		return nil, err
+	}
+	return ParseDN(string(value))
+}
+
+// GetSIDValue returns the first value of the attribute as a binary SID, e.g. for objectSid. Attribute names
+// are compared case-insensitively. An error is returned if the attribute is not present or its value
+// cannot be parsed.
+func (e *Entry) GetSIDValue(attribute string) (*SID, error) {
+	value, err := e.firstValue(attribute)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSID(value)
+}
+
+// GetGUIDValue returns the first value of the attribute as a GUID, either in the binary form of the objectGUID
+// attribute of Active Directory or in the string form of entryUUID. Attribute names are compared
+// case-insensitively. An error is returned if the attribute is not present or its value cannot be parsed.
+func (e *Entry) GetGUIDValue(attribute string) (GUID, error) {
+	value, err := e.firstValue(attribute)
+	if err != nil {
+		return GUID{}, err
+	}
+	if len(value) == len(GUID{}) {
+		return ParseGUID(value)
+	}
+	return ParseGUIDString(string(value))
+}
+
+// GetUserAccountControlValue returns the first value of the attribute as UserAccountControl flags, e.g. for
+// userAccountControl or msDS-User-Account-Control-Computed. Attribute names are compared case-insensitively.
+// An error is returned if the attribute is not present or its value cannot be parsed.
+func (e *Entry) GetUserAccountControlValue(attribute string) (UserAccountControl, error) {
+	i, err := e.GetIntValue(attribute)
+	if err != nil {
+		return 0, err
+	}
+	if i < -1<<31 || i >= 1<<32 {
+		return 0, errors.New("ldap: user account control value out of range")
+	}
+	// values with the high bit set are returned as negative 32-bit integers
+	return UserAccountControl(uint32(i)), nil
+}
@@ -0,0 +1,67 @@
+package ldap
+
+import (
+	"fmt"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+)
+
+const (
+	whoAmIOID = "1.3.6.1.4.1.4203.1.11.3"
+)
+
+// WhoAmIRequest implements the "Who Am I?" Extended Operation as defined in https://tools.ietf.org/html/rfc4532
+type WhoAmIRequest struct {
+	// Controls are optional controls to send with the request
+	Controls []Control
+}
+
+// WhoAmIResult holds the server response to a WhoAmIRequest
+type WhoAmIResult struct {
+	// AuthzID is the authorization identity of the session, e.g. "dn:cn=admin,dc=example,dc=org" or
+	// "u:admin", and is empty for anonymous sessions
+	AuthzID string
+	// Controls are the returned controls
+	Controls []Control
+}
+
+func (req *WhoAmIRequest) appendTo(envelope *ber.Packet) error {
+	pkt := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ApplicationExtendedRequest, nil, "Who Am I Extended Operation")
+	pkt.AppendChild(ber.NewString(ber.ClassContext, ber.TypePrimitive, 0, whoAmIOID, "Extended Request Name: Who Am I OID"))
+	envelope.AppendChild(pkt)
+	if len(req.Controls) > 0 {
+		envelope.AppendChild(encodeControls(req.Controls))
+	}
+
+	return nil
+}
+
+// WhoAmI returns the authorization identity of the session
+func (l *Conn) WhoAmI(controls []Control) (*WhoAmIResult, error) {
+	msgCtx, err := l.doRequest(&WhoAmIRequest{Controls: controls})
+	if err != nil {
+		return nil, err
+	}
+	defer l.finishMessage(msgCtx)
+
+	packet, err := l.readPacket(msgCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	if packet.Children[1].Tag != ApplicationExtendedResponse {
+		return nil, NewError(ErrorUnexpectedResponse, fmt.Errorf("unexpected Response: %d", packet.Children[1].Tag))
+	}
+	result := &WhoAmIResult{}
+	result.Controls, err = decodeResultControls(packet)
+	if err != nil {
+		return result, err
+	}
+
+	for _, child := range packet.Children[1].Children {
+		if child.ClassType == ber.ClassContext && child.Tag == 11 {
+			result.AuthzID = ber.DecodeString(child.Data.Bytes())
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,148 @@
+package ldap
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+)
+
+func TestEntryTypedValues(t *testing.T) {
+	sid := []byte{1, 5, 0, 0, 0, 0, 0, 5, 21, 0, 0, 0, 0xdc, 0xf4, 0xdc, 0x3b, 0x83, 0x3d, 0x2b, 0x46, 0x82, 0x8b, 0xa6, 0x28, 0x00, 0x02, 0x00, 0x00}
+	guid := []byte{0x10, 0xb8, 0xa7, 0x6b, 0xad, 0x9d, 0xd1, 0x11, 0x80, 0xb4, 0x00, 0xc0, 0x4f, 0xd4, 0x30, 0xc8}
+	entry := NewEntry("cn=user,dc=example,dc=org", map[string][]string{
+		"createTimestamp":    {"20201231235959Z"},
+		"pwdLastSet":         {"132539327990000000"},
+		"accountExpires":     {"9223372036854775807"},
+		"uidNumber":          {"1000"},
+		"pwdReset":           {"TRUE"},
+		"manager":            {"cn=boss,dc=example,dc=org"},
+		"objectSid":          {string(sid)},
+		"objectGUID":         {string(guid)},
+		"entryUUID":          {"6ba7b810-9dad-11d1-80b4-00c04fd430c8"},
+		"userAccountControl": {"66048"},
+		"description":        {"not a number"},
+	})
+
+	if tm, err := entry.GetTimeValue("createtimestamp"); err != nil || !tm.Equal(time.Date(2020, 12, 31, 23, 59, 59, 0, time.UTC)) {
+		t.Errorf("unexpected time value %s (%v)", tm, err)
+	}
+	if tm, err := entry.GetFileTimeValue("pwdLastSet"); err != nil || !tm.Equal(time.Date(2020, 12, 31, 23, 59, 59, 0, time.UTC)) {
+		t.Errorf("unexpected file time value %s (%v)", tm, err)
+	}
+	if tm, err := entry.GetFileTimeValue("accountExpires"); err != nil || !tm.IsZero() {
+		t.Errorf("expected the zero time for never, got %s (%v)", tm, err)
+	}
+	if i, err := entry.GetIntValue("uidNumber"); err != nil || i != 1000 {
+		t.Errorf("unexpected integer value %d (%v)", i, err)
+	}
+	if _, err := entry.GetIntValue("description"); err == nil {
+		t.Error("expected an error for an invalid integer")
+	}
+	if _, err := entry.GetIntValue("missing"); err == nil {
+		t.Error("expected an error for a missing attribute")
+	}
+	if b, err := entry.GetBoolValue("pwdReset"); err != nil || !b {
+		t.Errorf("unexpected boolean value %t (%v)", b, err)
+	}
+	if dn, err := entry.GetDNValue("manager"); err != nil || len(dn.RDNs) != 3 || dn.RDNs[0].Attributes[0].Value != "boss" {
+		t.Errorf("unexpected DN value %v (%v)", dn, err)
+	}
+	if s, err := entry.GetSIDValue("objectSid"); err != nil || s.String() != "S-1-5-21-1004336348-1177238915-682003330-512" || s.RID() != 512 {
+		t.Errorf("unexpected SID value %v (%v)", s, err)
+	}
+	if g, err := entry.GetGUIDValue("objectGUID"); err != nil || g.String() != "6ba7b810-9dad-11d1-80b4-00c04fd430c8" {
+		t.Errorf("unexpected GUID value %v (%v)", g, err)
+	}
+	if g, err := entry.GetGUIDValue("entryUUID"); err != nil || !bytes.Equal(g.Bytes(), guid) {
+		t.Errorf("unexpected GUID value %v (%v)", g, err)
+	}
+	if uac, err := entry.GetUserAccountControlValue("userAccountControl"); err != nil || !uac.Has(UACNormalAccount|UACDontExpirePassword) || uac.Has(UACAccountDisable) {
+		t.Errorf("unexpected user account control value %v (%v)", uac, err)
+	}
+}
+
+func TestValueEncoders(t *testing.T) {
+	tm := time.Date(2020, 12, 31, 23, 59, 59, 0, time.FixedZone("", 3600))
+	if s := EncodeGeneralizedTime(tm); s != "20201231225959Z" {
+		t.Errorf("unexpected generalized time %q", s)
+	}
+	if s := EncodeGeneralizedTime(tm.Add(250 * time.Millisecond)); s != "20201231225959.25Z" {
+		t.Errorf("unexpected generalized time %q", s)
+	}
+	if s := EncodeFileTime(time.Date(2020, 12, 31, 23, 59, 59, 0, time.UTC)); s != "132539327990000000" {
+		t.Errorf("unexpected file time %q", s)
+	}
+	if s := EncodeFileTime(time.Time{}); s != "0" {
+		t.Errorf("unexpected file time %q", s)
+	}
+	if s := EncodeBool(false); s != "FALSE" {
+		t.Errorf("unexpected boolean %q", s)
+	}
+	if s := (UACNormalAccount | UACAccountDisable).String(); s != "514" {
+		t.Errorf("unexpected user account control %q", s)
+	}
+
+	sid, err := ParseSIDString("S-1-5-32-544")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParseSID(sid.Bytes())
+	if err != nil || parsed.String() != "S-1-5-32-544" {
+		t.Errorf("unexpected SID round trip %v (%v)", parsed, err)
+	}
+	if s := EscapeFilterBytes(sid.Bytes()); s != `\01\02\00\00\00\00\00\05\20\00\00\00\20\02\00\00` {
+		t.Errorf("unexpected escaped SID %q", s)
+	}
+	for _, invalid := range []string{"S-1", "X-1-5", "S-1-5-x", "S-1-5-4294967296"} {
+		if _, err := ParseSIDString(invalid); err == nil {
+			t.Errorf("%q: expected an error", invalid)
+		}
+	}
+	if _, err := ParseSID([]byte{1, 2, 0, 0, 0, 0, 0, 5, 0, 0, 0, 0}); err == nil {
+		t.Error("expected an error for a truncated SID")
+	}
+
+	guid, err := ParseGUIDString("{6BA7B810-9DAD-11D1-80B4-00C04FD430C8}")
+	if err != nil || guid.String() != "6ba7b810-9dad-11d1-80b4-00c04fd430c8" {
+		t.Errorf("unexpected GUID %v (%v)", guid, err)
+	}
+	if _, err := ParseGUIDString("6ba7b810-9dad-11d1-80b4-00c04fd430cx"); err == nil {
+		t.Error("expected an error for an invalid GUID")
+	}
+}
+
+func TestBinaryValues(t *testing.T) {
+	if b := EncodeUnicodePwd("Pä$"); !bytes.Equal(b, []byte{'"', 0, 'P', 0, 0xe4, 0, '$', 0, '"', 0}) {
+		t.Errorf("unexpected unicodePwd value %x", b)
+	}
+
+	photo := []byte{0xff, 0xd8, 0x00, 0xc3, 0x28}
+	addReq := NewAddRequest("cn=a,dc=example,dc=org", nil)
+	addReq.AttributeBytes("jpegPhoto", [][]byte{photo})
+	modifyReq := NewModifyRequest("cn=a,dc=example,dc=org", nil)
+	modifyReq.ReplaceBytes("jpegPhoto", [][]byte{photo})
+	modifyReq.DeleteBytes("userCertificate;binary", nil)
+	modifyReq.ReplaceUnicodePwd("secret")
+
+	for _, req := range []request{addReq, modifyReq} {
+		envelope := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Request")
+		if err := req.appendTo(envelope); err != nil {
+			t.Fatal(err)
+		}
+		packet := ber.DecodePacket(envelope.Bytes())
+		var attr *ber.Packet
+		if req == addReq {
+			attr = packet.Children[0].Children[1].Children[0]
+		} else {
+			attr = packet.Children[0].Children[1].Children[0].Children[1]
+		}
+		if value := attr.Children[1].Children[0].Data.Bytes(); !bytes.Equal(value, photo) {
+			t.Errorf("got value %x, want %x", value, photo)
+		}
+	}
+	if change := modifyReq.Changes[2]; change.Operation != ReplaceAttribute || change.Modification.Type != "unicodePwd" || change.Modification.Vals[0] != string(EncodeUnicodePwd("secret")) {
+		t.Errorf("unexpected unicodePwd change %+v", change)
+	}
+}
@@ -0,0 +1,40 @@
+package ldap
+
+import (
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+)
+
+func TestWhoAmI(t *testing.T) {
+	authzID := "dn:cn=admin,dc=example,dc=org"
+	conn := newTestServerConn(t, func(request *ber.Packet) []*ber.Packet {
+		if oid := ber.DecodeString(request.Children[1].Children[0].Data.Bytes()); oid != whoAmIOID {
+			t.Errorf("unexpected request name %q", oid)
+		}
+		response := newTestLDAPResult(ApplicationExtendedResponse, LDAPResultSuccess)
+		if authzID != "" {
+			response.AppendChild(ber.NewString(ber.ClassContext, ber.TypePrimitive, 11, authzID, "Response Value"))
+		}
+		return []*ber.Packet{response}
+	})
+	defer conn.Close()
+
+	result, err := conn.WhoAmI(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if result.AuthzID != authzID {
+		t.Errorf("got authzID %q, want %q", result.AuthzID, authzID)
+	}
+
+	// anonymous sessions have no authorization identity
+	authzID = ""
+	result, err = conn.WhoAmI(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if result.AuthzID != "" {
+		t.Errorf("got authzID %q, want none", result.AuthzID)
+	}
+}